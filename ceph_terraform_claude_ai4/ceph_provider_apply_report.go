@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Apply reports. With apply_report set, the provider snapshots `ceph
+// status` and `ceph df` when it is configured, before any resource
+// changes, and a ceph_apply_report that depends on the other resources
+// compares that baseline with the cluster after they have been applied.
+
+// clusterSnapshot is the part of the cluster state an apply report covers.
+type clusterSnapshot struct {
+	Health string
+	// Checks maps health check names to their summary message.
+	Checks     map[string]string
+	TotalBytes int64
+	UsedBytes  int64
+	// PoolStored maps pool names to the bytes stored in them.
+	PoolStored map[string]int64
+}
+
+type statusHealth struct {
+	Health struct {
+		Status string `json:"status"`
+		Checks map[string]struct {
+			Summary struct {
+				Message string `json:"message"`
+			} `json:"summary"`
+		} `json:"checks"`
+	} `json:"health"`
+}
+
+type dfOutput struct {
+	Stats struct {
+		TotalBytes        int64 `json:"total_bytes"`
+		TotalUsedRawBytes int64 `json:"total_used_raw_bytes"`
+	} `json:"stats"`
+	Pools []struct {
+		Name  string `json:"name"`
+		Stats struct {
+			Stored      int64   `json:"stored"`
+			Objects     int64   `json:"objects"`
+			PercentUsed float64 `json:"percent_used"`
+		} `json:"stats"`
+	} `json:"pools"`
+}
+
+// ClusterSnapshot captures the cluster's health and usage.
+func (c *CephClient) ClusterSnapshot() (*clusterSnapshot, error) {
+	var status statusHealth
+	if err := c.ExecuteJSON(NewCommand("ceph", "status"), &status); err != nil {
+		return nil, err
+	}
+	var df dfOutput
+	if err := c.ExecuteJSON(NewCommand("ceph", "df"), &df); err != nil {
+		return nil, err
+	}
+
+	snap := &clusterSnapshot{
+		Health:     status.Health.Status,
+		Checks:     make(map[string]string),
+		TotalBytes: df.Stats.TotalBytes,
+		UsedBytes:  df.Stats.TotalUsedRawBytes,
+		PoolStored: make(map[string]int64),
+	}
+	for name, check := range status.Health.Checks {
+		snap.Checks[name] = check.Summary.Message
+	}
+	for _, pool := range df.Pools {
+		snap.PoolStored[pool.Name] = pool.Stats.Stored
+	}
+	return snap, nil
+}
+
+// applyReport is the difference between two snapshots.
+type applyReport struct {
+	HealthBefore   string
+	HealthAfter    string
+	UsedDelta      int64
+	PoolDeltas     map[string]int64
+	NewChecks      []string
+	ResolvedChecks []string
+
+	before, after *clusterSnapshot
+}
+
+func diffSnapshots(before, after *clusterSnapshot) *applyReport {
+	report := &applyReport{
+		HealthBefore: before.Health,
+		HealthAfter:  after.Health,
+		UsedDelta:    after.UsedBytes - before.UsedBytes,
+		PoolDeltas:   make(map[string]int64),
+		before:       before,
+		after:        after,
+	}
+	for name, stored := range after.PoolStored {
+		if delta := stored - before.PoolStored[name]; delta != 0 {
+			report.PoolDeltas[name] = delta
+		} else if _, ok := before.PoolStored[name]; !ok {
+			report.PoolDeltas[name] = 0
+		}
+	}
+	for name, stored := range before.PoolStored {
+		if _, ok := after.PoolStored[name]; !ok {
+			report.PoolDeltas[name] = -stored
+		}
+	}
+	for name := range after.Checks {
+		if _, ok := before.Checks[name]; !ok {
+			report.NewChecks = append(report.NewChecks, name)
+		}
+	}
+	for name := range before.Checks {
+		if _, ok := after.Checks[name]; !ok {
+			report.ResolvedChecks = append(report.ResolvedChecks, name)
+		}
+	}
+	sort.Strings(report.NewChecks)
+	sort.Strings(report.ResolvedChecks)
+	return report
+}
+
+// Summary renders the report as a few lines for a change record.
+func (r *applyReport) Summary() string {
+	var lines []string
+	if r.HealthBefore == r.HealthAfter {
+		lines = append(lines, "Health: "+r.HealthAfter)
+	} else {
+		lines = append(lines, fmt.Sprintf("Health: %s -> %s", r.HealthBefore, r.HealthAfter))
+	}
+	lines = append(lines, fmt.Sprintf("Raw capacity used: %s (%s of %s)",
+		formatByteDelta(r.UsedDelta), formatBytes(r.after.UsedBytes), formatBytes(r.after.TotalBytes)))
+
+	pools := make([]string, 0, len(r.PoolDeltas))
+	for name := range r.PoolDeltas {
+		pools = append(pools, name)
+	}
+	sort.Strings(pools)
+	for _, name := range pools {
+		_, existed := r.before.PoolStored[name]
+		_, exists := r.after.PoolStored[name]
+		switch {
+		case !existed:
+			lines = append(lines, fmt.Sprintf("Pool %s created (%s stored)", name, formatBytes(r.after.PoolStored[name])))
+		case !exists:
+			lines = append(lines, fmt.Sprintf("Pool %s removed (%s stored)", name, formatBytes(r.before.PoolStored[name])))
+		default:
+			lines = append(lines, fmt.Sprintf("Pool %s stored: %s", name, formatByteDelta(r.PoolDeltas[name])))
+		}
+	}
+	for _, name := range r.NewChecks {
+		lines = append(lines, fmt.Sprintf("New health check %s: %s", name, r.after.Checks[name]))
+	}
+	for _, name := range r.ResolvedChecks {
+		lines = append(lines, "Resolved health check "+name)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatBytes renders a byte count with binary units, e.g. 1.5 GiB.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit && n > -unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, exp := float64(n), 0
+	for value >= unit*unit || value <= -unit*unit {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value/unit, "KMGTPE"[exp])
+}
+
+// formatByteDelta renders a signed change in bytes.
+func formatByteDelta(n int64) string {
+	if n > 0 {
+		return "+" + formatBytes(n)
+	}
+	return formatBytes(n)
+}
+
+// Apply Report Resource
+//
+// An action resource: creating it compares the cluster with the baseline
+// taken when the provider was configured. Give it depends_on on the
+// resources being applied and a trigger that changes every run, such as
+// timestamp(), to get a report for each apply.
+type applyReportResource struct {
+	client *CephClient
+}
+
+type applyReportResourceModel struct {
+	ID                   types.String `tfsdk:"id"`
+	Triggers             types.Map    `tfsdk:"triggers"`
+	Warn                 types.Bool   `tfsdk:"warn"`
+	Summary              types.String `tfsdk:"summary"`
+	HealthBefore         types.String `tfsdk:"health_before"`
+	HealthAfter          types.String `tfsdk:"health_after"`
+	RawUsedDeltaBytes    types.Int64  `tfsdk:"raw_used_delta_bytes"`
+	PoolStoredDeltaBytes types.Map    `tfsdk:"pool_stored_delta_bytes"`
+	NewHealthChecks      types.List   `tfsdk:"new_health_checks"`
+	ResolvedHealthChecks types.List   `tfsdk:"resolved_health_checks"`
+	GeneratedAt          types.String `tfsdk:"generated_at"`
+}
+
+func NewApplyReportResource() resource.Resource {
+	return &applyReportResource{}
+}
+
+func (r *applyReportResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_apply_report"
+}
+
+func (r *applyReportResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	keep := []planmodifier.String{stringplanmodifier.UseStateForUnknown()}
+	resp.Schema = schema.Schema{
+		Description: "Summarizes how health and capacity changed during an apply, compared with the snapshot taken when the provider was configured with apply_report = true",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Time the report was generated, in RFC 3339 form"),
+			"triggers": schema.MapAttribute{
+				Description: "Arbitrary values that generate a new report when changed, e.g. { run = timestamp() } to report on every apply",
+				ElementType: types.StringType,
+				Optional:    true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"warn": schema.BoolAttribute{
+				Description: "Also show the summary as a warning in the apply output",
+				Optional:    true,
+			},
+			"summary": schema.StringAttribute{
+				Description:   "Human-readable summary of the changes, one per line",
+				Computed:      true,
+				PlanModifiers: keep,
+			},
+			"health_before": schema.StringAttribute{
+				Description:   "Cluster health before the apply",
+				Computed:      true,
+				PlanModifiers: keep,
+			},
+			"health_after": schema.StringAttribute{
+				Description:   "Cluster health after the apply",
+				Computed:      true,
+				PlanModifiers: keep,
+			},
+			"raw_used_delta_bytes": schema.Int64Attribute{
+				Description: "Change in raw capacity used, in bytes",
+				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"pool_stored_delta_bytes": schema.MapAttribute{
+				Description: "Change in bytes stored, keyed by pool, for pools that changed, were created or were removed",
+				ElementType: types.Int64Type,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"new_health_checks": schema.ListAttribute{
+				Description: "Health checks raised during the apply",
+				ElementType: types.StringType,
+				Computed:    true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"resolved_health_checks": schema.ListAttribute{
+				Description: "Health checks cleared during the apply",
+				ElementType: types.StringType,
+				Computed:    true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"generated_at": schema.StringAttribute{
+				Description:   "RFC 3339 time the report was generated",
+				Computed:      true,
+				PlanModifiers: keep,
+			},
+		},
+	}
+}
+
+func (r *applyReportResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *applyReportResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan applyReportResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if r.client.baseline == nil {
+		detail := "Set apply_report = true in the provider block, so the cluster is captured before resources are applied."
+		if r.client.baselineErr != nil {
+			detail = fmt.Sprintf("The snapshot taken when the provider was configured failed: %s", r.client.baselineErr)
+		}
+		resp.Diagnostics.AddError("No apply baseline", detail)
+		return
+	}
+	after, err := r.client.ClusterSnapshot()
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to capture cluster state for the apply report", err)
+		return
+	}
+	report := diffSnapshots(r.client.baseline, after)
+
+	generatedAt := time.Now().UTC().Format(time.RFC3339)
+	plan.ID = types.StringValue(generatedAt)
+	plan.GeneratedAt = types.StringValue(generatedAt)
+	plan.Summary = types.StringValue(report.Summary())
+	plan.HealthBefore = types.StringValue(report.HealthBefore)
+	plan.HealthAfter = types.StringValue(report.HealthAfter)
+	plan.RawUsedDeltaBytes = types.Int64Value(report.UsedDelta)
+	plan.PoolStoredDeltaBytes, diags = types.MapValueFrom(ctx, types.Int64Type, report.PoolDeltas)
+	resp.Diagnostics.Append(diags...)
+	plan.NewHealthChecks, diags = types.ListValueFrom(ctx, types.StringType, append([]string{}, report.NewChecks...))
+	resp.Diagnostics.Append(diags...)
+	plan.ResolvedHealthChecks, diags = types.ListValueFrom(ctx, types.StringType, append([]string{}, report.ResolvedChecks...))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.Warn.ValueBool() {
+		resp.Diagnostics.AddWarning("Ceph apply report", report.Summary())
+	}
+	tflog.Info(ctx, "Generated Ceph apply report", map[string]interface{}{
+		"summary": report.Summary(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *applyReportResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// A report describes a past apply; there is nothing to refresh.
+}
+
+func (r *applyReportResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Only warn can change in place; the report itself is kept.
+	var plan applyReportResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *applyReportResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// Removing a report from state does not touch the cluster.
+}
+
+// captureBaseline snapshots the cluster for apply reports.
+func (c *CephClient) captureBaseline() {
+	c.baseline, c.baselineErr = c.ClusterSnapshot()
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Audit log for audit_log_path. Unlike record_commands_file, which holds
+// the current run only, the audit log is appended to across runs, one JSON
+// object per line, so security teams can keep a record of everything the
+// provider ran against the cluster. Secrets passed as arguments are
+// redacted before anything is written.
+
+// Options whose value is a secret, as "--opt value" or "--opt=value".
+var secretOptions = []string{"--access-key", "--secret", "--secret-key", "--key", "--password"}
+
+const redacted = "<redacted>"
+
+type auditEntry struct {
+	Time       time.Time `json:"time"`
+	Cluster    string    `json:"cluster,omitempty"`
+	Entity     string    `json:"entity"`
+	Command    []string  `json:"command"`
+	ExitCode   int       `json:"exit_code"`
+	DurationMS int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// newAuditLog opens path for appending, creating it readable only by the
+// provider's user.
+func newAuditLog(path string) (*auditLog, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &auditLog{file: file}, nil
+}
+
+// redactArgs returns args with secret option values replaced, and the
+// secrets it removed.
+func redactArgs(args []string) ([]string, []string) {
+	out := append([]string(nil), args...)
+	var secrets []string
+	for i := 0; i < len(out); i++ {
+		for _, opt := range secretOptions {
+			switch {
+			case out[i] == opt && i+1 < len(out):
+				secrets = append(secrets, out[i+1])
+				out[i+1] = redacted
+				i++
+			case strings.HasPrefix(out[i], opt+"="):
+				secrets = append(secrets, strings.TrimPrefix(out[i], opt+"="))
+				out[i] = opt + "=" + redacted
+			default:
+				continue
+			}
+			break
+		}
+	}
+	// RGW cloud tiers take their credentials inside --tier-config.
+	for i, arg := range out {
+		if value, ok := strings.CutPrefix(arg, "--tier-config="); ok {
+			settings := strings.Split(value, ",")
+			for j, setting := range settings {
+				key, secret, _ := strings.Cut(setting, "=")
+				if key == "access_key" || key == "secret" {
+					secrets = append(secrets, secret)
+					settings[j] = key + "=" + redacted
+				}
+			}
+			out[i] = "--tier-config=" + strings.Join(settings, ",")
+		}
+	}
+	// config-key values are often credentials.
+	if len(out) > 4 && out[0] == "ceph" && out[1] == "config-key" && out[2] == "set" {
+		secrets = append(secrets, out[4])
+		out[4] = redacted
+	}
+	return out, secrets
+}
+
+// redactError returns the text of err with the secrets redactArgs found
+// replaced, since Ceph often echoes its arguments back in errors.
+func redactError(err error, secrets []string) string {
+	text := err.Error()
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redacted)
+		}
+	}
+	return text
+}
+
+// Log appends one entry for a command that ran for duration and ended with
+// err.
+func (l *auditLog) Log(c *CephClient, args []string, duration time.Duration, err error) error {
+	command, secrets := redactArgs(args)
+	entry := auditEntry{
+		Time:       time.Now().UTC(),
+		Cluster:    c.FSID,
+		Entity:     c.entity(),
+		Command:    command,
+		DurationMS: duration.Milliseconds(),
+	}
+	if entry.Cluster == "" {
+		entry.Cluster = c.Cluster
+	}
+	if err != nil {
+		entry.ExitCode = -1
+		var cmdErr *CommandError
+		if errors.As(err, &cmdErr) {
+			entry.ExitCode = cmdErr.ExitCode
+		}
+		entry.Error = redactError(err, secrets)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit log entry: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
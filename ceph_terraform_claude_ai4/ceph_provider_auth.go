@@ -0,0 +1,224 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// generateCephKey returns a new secret in the base64 form used in keyrings:
+// key type (AES), creation time and a 16 byte secret, as ceph-authtool
+// --gen-key produces.
+func generateCephKey(random io.Reader, now time.Time) (string, error) {
+	secret := make([]byte, 16)
+	if _, err := io.ReadFull(random, secret); err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	buf := make([]byte, 12, 12+len(secret))
+	binary.LittleEndian.PutUint16(buf[0:], 1)
+	binary.LittleEndian.PutUint32(buf[2:], uint32(now.Unix()))
+	binary.LittleEndian.PutUint32(buf[6:], uint32(now.Nanosecond()))
+	binary.LittleEndian.PutUint16(buf[10:], uint16(len(secret)))
+	buf = append(buf, secret...)
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// renderKeyring renders a single-entity keyring with the given caps,
+// sorted by daemon type.
+func renderKeyring(entity, key string, caps map[string]string) string {
+	daemons := make([]string, 0, len(caps))
+	for daemon := range caps {
+		daemons = append(daemons, daemon)
+	}
+	sort.Strings(daemons)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s]\n\tkey = %s\n", entity, key)
+	for _, daemon := range daemons {
+		fmt.Fprintf(&b, "\tcaps %s = %q\n", daemon, caps[daemon])
+	}
+	return b.String()
+}
+
+// authEntry is an auth entity as listed by `ceph auth get` and `ceph auth
+// get-or-create` with --format json.
+type authEntry struct {
+	Entity string            `json:"entity"`
+	Key    string            `json:"key"`
+	Caps   map[string]string `json:"caps"`
+}
+
+// firstAuthEntry returns the single entity a command printed.
+func firstAuthEntry(entries []authEntry, entity string) (*authEntry, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("ceph printed no auth entry for %s", entity)
+	}
+	return &entries[0], nil
+}
+
+// isAuthNotFound reports whether a `ceph auth` command failed because the
+// entity does not exist: `ceph auth get` prints "failed to find <entity>
+// in keyring", `ceph auth del` "entity <entity> does not exist".
+func isAuthNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "failed to find") || strings.Contains(msg, "does not exist")
+}
+
+// GetAuth returns an auth entity with its key and caps.
+func (c *CephClient) GetAuth(entity string) (*authEntry, error) {
+	var entries []authEntry
+	if err := c.ExecuteJSON(NewCommand("ceph", "auth", "get").Arg(entity), &entries); err != nil {
+		return nil, err
+	}
+	return firstAuthEntry(entries, entity)
+}
+
+// GetOrCreateAuth creates an auth entity with the given caps unless it
+// exists, and returns it.
+func (c *CephClient) GetOrCreateAuth(entity string, caps map[string]string) (*authEntry, error) {
+	// Each cap string stays one argument, so grants such as
+	// "profile rbd pool=x" keep their spaces.
+	cmd := NewCommand("ceph", "auth", "get-or-create").Arg(entity)
+	for daemon, grant := range caps {
+		cmd.Arg(daemon, grant)
+	}
+	var entries []authEntry
+	if err := c.ExecuteJSON(cmd, &entries); err != nil {
+		return nil, err
+	}
+	return firstAuthEntry(entries, entity)
+}
+
+// GetAuthKey returns the key of an auth entity.
+func (c *CephClient) GetAuthKey(entity string) (string, error) {
+	output, err := c.ReadText(NewCommand("ceph", "auth", "get-key").Arg(entity))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// ImportAuth creates or updates an auth entity with exactly the given caps
+// through `ceph auth import`. Caps travel in a keyring file, so grants such
+// as "profile rbd pool=x" keep their spaces. When exists is set the entity
+// keeps its current key, otherwise a new key is generated. The key is
+// returned either way.
+func (c *CephClient) ImportAuth(entity string, caps map[string]string, exists bool) (string, error) {
+	var key string
+	var err error
+	if exists {
+		key, err = c.GetAuthKey(entity)
+	} else {
+		key, err = generateCephKey(rand.Reader, time.Now())
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.ImportKeyring(renderKeyring(entity, key, caps)); err != nil {
+		return "", fmt.Errorf("failed to import %s: %w", entity, err)
+	}
+	return key, nil
+}
+
+// ImportKeyring creates or updates every entity of a keyring with `ceph
+// auth import`, setting each one's key and replacing its caps. The keyring
+// only passes through a temp file on the Terraform host.
+func (c *CephClient) ImportKeyring(keyring string) error {
+	dir, err := os.MkdirTemp("", "ceph-auth")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "keyring")
+	if err := os.WriteFile(file, []byte(keyring), 0600); err != nil {
+		return fmt.Errorf("failed to write keyring: %w", err)
+	}
+	_, err = c.ExecuteCommand(NewCommand("ceph", "auth", "import").Option("-i", file))
+	return err
+}
+
+func (c *CephClient) DeleteAuth(entity string) error {
+	_, err := c.ExecuteCommand(NewCommand("ceph", "auth", "del").Arg(entity))
+	return err
+}
+
+// normalizeCaps rewrites a cap string into a canonical form so that
+// spellings Ceph treats the same compare equal: grant order, spacing
+// around '=', quoted values, permission letter order, "allow profile x"
+// versus "profile x", and "all" versus "*".
+func normalizeCaps(caps string) string {
+	var grants []string
+	seen := make(map[string]bool)
+	for _, grant := range strings.FieldsFunc(caps, func(r rune) bool { return r == ',' || r == ';' }) {
+		grant = strings.Join(strings.Fields(grant), " ")
+		grant = strings.ReplaceAll(grant, " = ", "=")
+		grant = strings.ReplaceAll(grant, " =", "=")
+		grant = strings.ReplaceAll(grant, "= ", "=")
+
+		fields := strings.Fields(grant)
+		if len(fields) >= 2 && fields[0] == "allow" && fields[1] == "profile" {
+			fields = fields[1:]
+		}
+		for i, field := range fields {
+			if key, value, ok := strings.Cut(field, "="); ok {
+				fields[i] = key + "=" + strings.Trim(value, `"'`)
+				continue
+			}
+			if i == 1 && fields[0] == "allow" {
+				fields[i] = normalizeCapPerms(field)
+			}
+		}
+
+		grant = strings.Join(fields, " ")
+		if grant != "" && !seen[grant] {
+			seen[grant] = true
+			grants = append(grants, grant)
+		}
+	}
+	sort.Strings(grants)
+	return strings.Join(grants, ", ")
+}
+
+// normalizeCapPerms sorts permission letters such as "wr" into "rw".
+// Anything that is not made of r, w and x (e.g. class-read) is kept as is.
+func normalizeCapPerms(perms string) string {
+	if perms == "all" || perms == "*" {
+		return "*"
+	}
+	if strings.Trim(perms, "rwx") != "" {
+		return perms
+	}
+	var out []byte
+	for _, p := range "rwx" {
+		if strings.ContainsRune(perms, p) {
+			out = append(out, byte(p))
+		}
+	}
+	return string(out)
+}
+
+// reconcileCaps returns the caps read from the cluster, keeping the known
+// spelling of any daemon's caps that grant the same access.
+func reconcileCaps(known, cluster map[string]string) map[string]string {
+	caps := make(map[string]string, len(cluster))
+	for daemon, value := range cluster {
+		if prev, ok := known[daemon]; ok && normalizeCaps(prev) == normalizeCaps(value) {
+			value = prev
+		}
+		caps[daemon] = value
+	}
+	return caps
+}
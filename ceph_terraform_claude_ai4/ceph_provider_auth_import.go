@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// parseKeyring returns the entities of a keyring file in file order. Caps
+// values may be quoted, as `ceph auth get` prints them, or bare. Settings
+// other than key and caps, such as auid, are ignored.
+func parseKeyring(keyring string) ([]authEntry, error) {
+	var entries []authEntry
+	seen := make(map[string]bool)
+	for i, line := range strings.Split(keyring, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			entity := strings.TrimSpace(line[1 : len(line)-1])
+			if seen[entity] {
+				return nil, fmt.Errorf("line %d: %s appears twice", i+1, entity)
+			}
+			seen[entity] = true
+			entries = append(entries, authEntry{Entity: entity, Caps: make(map[string]string)})
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a setting such as key = ..., got %q", i+1, line)
+		}
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("line %d: setting outside an [entity] section", i+1)
+		}
+		entry := &entries[len(entries)-1]
+		name, value = strings.Join(strings.Fields(name), " "), strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value %s", i+1, value)
+			}
+			value = unquoted
+		}
+		switch {
+		case name == "key":
+			entry.Key = value
+		case strings.HasPrefix(name, "caps "):
+			entry.Caps[strings.TrimPrefix(name, "caps ")] = value
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("keyring has no [entity] sections")
+	}
+	for _, entry := range entries {
+		if entry.Key == "" {
+			return nil, fmt.Errorf("%s has no key", entry.Entity)
+		}
+	}
+	return entries, nil
+}
+
+// authFingerprint identifies an entity's key and caps without revealing
+// the key. Caps are normalized, so spellings Ceph treats the same match.
+func authFingerprint(entry *authEntry) string {
+	daemons := make([]string, 0, len(entry.Caps))
+	for daemon := range entry.Caps {
+		daemons = append(daemons, daemon)
+	}
+	sort.Strings(daemons)
+
+	var b strings.Builder
+	b.WriteString(entry.Key)
+	for _, daemon := range daemons {
+		fmt.Fprintf(&b, "\n%s=%s", daemon, normalizeCaps(entry.Caps[daemon]))
+	}
+	return sha256Hex([]byte(b.String()))[:16]
+}
+
+// Auth Import Resource
+//
+// Imports every entity of a keyring file in one `ceph auth import`, for
+// bringing legacy client credentials under management without a resource
+// per client. Each entity's key and caps are tracked as a fingerprint, so
+// a plan shows which entities were changed or deleted outside Terraform.
+// Entities removed from the keyring, and all of them on destroy, are left
+// in the cluster.
+type authImportResource struct {
+	client *CephClient
+}
+
+type authImportResourceModel struct {
+	ID       types.String `tfsdk:"id"`
+	Keyring  types.String `tfsdk:"keyring"`
+	Entities types.Map    `tfsdk:"entities"`
+}
+
+func NewAuthImportResource() resource.Resource {
+	return &authImportResource{}
+}
+
+func (r *authImportResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_auth_import"
+}
+
+func (r *authImportResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Imports all entities of a keyring file with `ceph auth import` and detects drift per entity",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Imported entities, sorted and comma separated"),
+			"keyring": schema.StringAttribute{
+				Description: "Keyring file contents with one [entity] section per credential, e.g. from file() or `ceph auth export`",
+				Required:    true,
+				Sensitive:   true,
+			},
+			"entities": schema.MapAttribute{
+				Description: "Fingerprint of each entity's key and caps, keyed by entity name; a change means the entity differs from the keyring",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (r *authImportResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *authImportResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config authImportResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || config.Keyring.IsUnknown() {
+		return
+	}
+	if _, err := parseKeyring(config.Keyring.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("keyring"), "Invalid keyring", err.Error())
+	}
+}
+
+// ModifyPlan plans the fingerprints of the keyring's entities, so the plan
+// shows each entity that the cluster no longer matches.
+func (r *authImportResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.client.planResourceCommand(ctx, req, &resp.Diagnostics, "ceph_auth_import", "ceph auth import", "ceph auth import", "")
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan authImportResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || plan.Keyring.IsUnknown() {
+		return
+	}
+
+	entries, err := parseKeyring(plan.Keyring.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("keyring"), "Invalid keyring", err.Error())
+		return
+	}
+	fingerprints := make(map[string]string, len(entries))
+	for i := range entries {
+		fingerprints[entries[i].Entity] = authFingerprint(&entries[i])
+	}
+	entities, diags := types.MapValueFrom(ctx, types.StringType, fingerprints)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("entities"), entities)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("id"), authImportID(entries))...)
+}
+
+// authImportID names the imported entities, sorted.
+func authImportID(entries []authEntry) string {
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Entity)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (r *authImportResource) importKeyring(ctx context.Context, plan *authImportResourceModel) error {
+	entries, err := parseKeyring(plan.Keyring.ValueString())
+	if err != nil {
+		return err
+	}
+	if err := r.client.ImportKeyring(plan.Keyring.ValueString()); err != nil {
+		return err
+	}
+	plan.ID = types.StringValue(authImportID(entries))
+	tflog.Info(ctx, "Imported Ceph keyring", map[string]interface{}{
+		"entities": plan.ID.ValueString(),
+	})
+	return nil
+}
+
+func (r *authImportResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan authImportResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.importKeyring(ctx, &plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to import keyring", err)
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *authImportResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state authImportResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	entries, err := parseKeyring(state.Keyring.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse keyring in state", err)
+		return
+	}
+
+	// Entities deleted outside Terraform are left out, so the plan adds
+	// them back.
+	fingerprints := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		current, err := r.client.GetAuth(entry.Entity)
+		if err != nil {
+			if isAuthNotFound(err) {
+				continue
+			}
+			addCommandError(&resp.Diagnostics, "Failed to read "+entry.Entity, err)
+			return
+		}
+		fingerprints[entry.Entity] = authFingerprint(current)
+	}
+	state.Entities, diags = types.MapValueFrom(ctx, types.StringType, fingerprints)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *authImportResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan authImportResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Importing the whole keyring again is idempotent and restores every
+	// entity that drifted.
+	if err := r.importKeyring(ctx, &plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to import keyring", err)
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *authImportResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Info(ctx, "Removed Ceph keyring import from state; the entities are kept")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// Bootstrap configuration. With bootstrap_keyring set, the provider needs
+// nothing under /etc/ceph: each command gets a minimal ceph.conf naming
+// the monitors and a keyring holding the inline key, both written next to
+// each other in a private temp dir and removed once the command returns.
+// This suits containers, where mounting the cluster's config is awkward.
+
+// configureBootstrap checks that bootstrap_keyring has what it needs. It
+// runs after configureInlineKey, which sets the key.
+func configureBootstrap(config *cephProviderModel, client *CephClient) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !config.BootstrapKeyring.ValueBool() {
+		return diags
+	}
+
+	switch {
+	case client.ConfigFile != "":
+		diags.AddAttributeError(path.Root("config_file"), "Conflicting configuration",
+			"bootstrap_keyring generates the Ceph config itself; remove config_file")
+		return diags
+	case client.Key == "":
+		diags.AddAttributeError(path.Root("bootstrap_keyring"), "Missing key",
+			"bootstrap_keyring needs the user's key in key or key_secret")
+		return diags
+	case len(client.MonHosts) == 0:
+		diags.AddAttributeError(path.Root("mon_hosts"), "Missing monitors",
+			"bootstrap_keyring needs mon_hosts, as there is no ceph.conf to find the monitors in")
+		return diags
+	}
+
+	client.Bootstrap = true
+	if _, err := client.BootstrapKeyring(); err != nil {
+		diags.AddAttributeError(path.Root("key"), "Invalid key", err.Error())
+	}
+	return diags
+}
+
+// validateCephxKey checks that key is a cephx secret as printed by
+// `ceph auth get-key`: base64 of a 12-byte header (type, creation time,
+// secret length) followed by the secret.
+func validateCephxKey(key string) error {
+	data, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("key is not valid base64: %w", err)
+	}
+	if len(data) < 12 || int(binary.LittleEndian.Uint16(data[10:12])) != len(data)-12 {
+		return fmt.Errorf("key is not a cephx secret; expected the output of `ceph auth get-key`")
+	}
+	return nil
+}
+
+// BootstrapKeyring returns a minimal keyring holding the inline key for the
+// configured entity, without caps, which the monitors keep.
+func (c *CephClient) BootstrapKeyring() (string, error) {
+	if err := validateCephxKey(c.Key); err != nil {
+		return "", err
+	}
+	return renderKeyring(c.entity(), c.Key, nil), nil
+}
+
+// bootstrapConfig returns a minimal ceph.conf naming the monitors. The
+// keyring is passed with --keyring rather than named here, so the pair
+// still works when shipped to a remote admin node under other paths.
+func (c *CephClient) bootstrapConfig() string {
+	var b strings.Builder
+	b.WriteString("[global]\n")
+	if c.FSID != "" {
+		fmt.Fprintf(&b, "\tfsid = %s\n", c.FSID)
+	}
+	fmt.Fprintf(&b, "\tmon_host = %s\n", strings.Join(c.MonHosts, ","))
+	return b.String()
+}
+
+// writeInlineKeyring writes the client's inline key to a keyring file for
+// one command, and with bootstrap_keyring also a ceph.conf. It returns the
+// arguments that point the command at them; the returned func removes
+// them.
+func (c *CephClient) writeInlineKeyring() ([]string, func(), error) {
+	dir, err := os.MkdirTemp("", "ceph-key")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	keyring := filepath.Join(dir, "keyring")
+	if err := os.WriteFile(keyring, []byte(renderKeyring(c.entity(), c.Key, nil)), 0600); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write keyring: %w", err)
+	}
+	if !c.Bootstrap {
+		return []string{"--keyring", keyring}, cleanup, nil
+	}
+
+	conf := filepath.Join(dir, "ceph.conf")
+	if err := os.WriteFile(conf, []byte(c.bootstrapConfig()), 0600); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write ceph.conf: %w", err)
+	}
+	return []string{"--conf", conf, "--keyring", keyring}, cleanup, nil
+}
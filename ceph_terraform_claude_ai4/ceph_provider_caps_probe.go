@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Capability probing. A provider user with partial caps, e.g. a read-only
+// monitoring user or one without mgr caps, otherwise finds out from an
+// EACCES halfway through an apply. The provider reads its user's caps
+// once at Configure and checks them before running a command, so the
+// command fails with the caps it needs without reaching the cluster, and
+// data sources and refreshes fail at plan time. When the caps cannot be
+// read, e.g. because the user lacks `ceph auth get` access, nothing is
+// checked and the cluster has the last word.
+
+// probeCaps reads the caps of the configured user for later checks.
+func (c *CephClient) probeCaps() bool {
+	c.caps = c.grantedCaps()
+	return c.caps != nil
+}
+
+// capsLack reports whether granted clearly does not cover needed. Unlike
+// capsSatisfy it gives the benefit of the doubt: profiles grant different
+// access per daemon, so a cap with a profile is never judged lacking, a
+// needed profile is taken as covered by any read access and a needed
+// `allow *` by any write access.
+func capsLack(granted, needed string) bool {
+	if strings.TrimSpace(granted) == "" {
+		return true
+	}
+	if capsSatisfy(granted, needed) {
+		return false
+	}
+	havePerms, haveProfiles, _ := capGrants(granted)
+	if len(haveProfiles) > 0 {
+		return false
+	}
+	needPerms, needProfiles, needAll := capGrants(needed)
+	if needAll {
+		return !strings.ContainsRune(havePerms, 'w')
+	}
+	for _, p := range needPerms {
+		if !strings.ContainsRune(havePerms, p) {
+			return true
+		}
+	}
+	return len(needProfiles) > 0 && !strings.ContainsRune(havePerms, 'r')
+}
+
+// checkCaps fails a command that the probed caps clearly do not allow,
+// with the same error a cluster EACCES gets.
+func (c *CephClient) checkCaps(args []string) error {
+	if c.caps == nil {
+		return nil
+	}
+	operation, required, ok := requiredCaps(strings.Join(args, " "))
+	if !ok {
+		return nil
+	}
+	for daemon, need := range required {
+		if capsLack(c.caps[daemon], need) {
+			return &cephAccessError{
+				Kind:      failureAuth,
+				Entity:    c.entity(),
+				Operation: operation,
+				Required:  required,
+				Granted:   c.caps,
+				Probed:    true,
+			}
+		}
+	}
+	return nil
+}
+
+// planResourceCommand takes the command that creates, updates or destroys
+// a resource, whichever the plan does; "" for an action that runs no
+// command. It records the command for record_commands_file and reports at
+// plan time that the provider user lacks the caps for it. Unchanged
+// resources are not checked, so a read-only user can still plan a
+// configuration with nothing to do.
+func (c *CephClient) planResourceCommand(ctx context.Context, req resource.ModifyPlanRequest, diags *diag.Diagnostics, resourceType, create, update, destroy string) {
+	if c == nil {
+		return
+	}
+	var cmd, action string
+	var name types.String
+	switch {
+	case req.Plan.Raw.IsNull():
+		cmd, action = destroy, "Destroying"
+		req.State.GetAttribute(ctx, path.Root("name"), &name)
+	case req.State.Raw.IsNull():
+		cmd, action = create, "Creating"
+		req.Plan.GetAttribute(ctx, path.Root("name"), &name)
+	case !req.Plan.Raw.Equal(req.State.Raw):
+		cmd, action = update, "Updating"
+		req.State.GetAttribute(ctx, path.Root("name"), &name)
+	default:
+		return
+	}
+	if cmd == "" {
+		return
+	}
+	if c.recorder != nil {
+		if err := c.recorder.RecordPlanned(strings.Fields(cmd), resourceType, name.ValueString()); err != nil {
+			tflog.Warn(ctx, "Could not update record_commands_file", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+	if c.caps == nil {
+		return
+	}
+	var accessErr *cephAccessError
+	if err := c.checkCaps(strings.Fields(cmd)); errors.As(err, &accessErr) {
+		diags.AddError(accessErr.Summary(),
+			fmt.Sprintf("%s %s would fail.\n\n%s", action, resourceType, accessErr.Detail()))
+	}
+}
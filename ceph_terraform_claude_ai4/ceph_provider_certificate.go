@@ -0,0 +1,67 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// PEM handling shared by the certificate resources.
+
+// splitPEMBundle separates the certificates and the private key in PEM
+// text, re-encoding them so formatting differences do not count as
+// changes.
+func splitPEMBundle(data string) (certs, key string) {
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return certs, key
+		}
+		switch {
+		case block.Type == "CERTIFICATE":
+			certs += string(pem.EncodeToMemory(block))
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			key = string(pem.EncodeToMemory(block))
+		}
+	}
+}
+
+// certificateNotAfter returns the expiry of the first certificate in PEM
+// text, the server certificate in a chain.
+func certificateNotAfter(certPEM string) (time.Time, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return time.Time{}, fmt.Errorf("no PEM certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return cert.NotAfter, nil
+}
+
+// validateCertificatePair checks that the key belongs to the certificate,
+// which the gateway would otherwise only find out when it restarts.
+func validateCertificatePair(certPEM, keyPEM string) error {
+	if _, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM)); err != nil {
+		return fmt.Errorf("certificate and private_key do not form a valid pair: %w", err)
+	}
+	return nil
+}
+
+// notAfterValue is the expiry of the certificate as reported in state, or
+// null if it cannot be parsed.
+func notAfterValue(certPEM string) types.String {
+	notAfter, err := certificateNotAfter(certPEM)
+	if err != nil {
+		return types.StringNull()
+	}
+	return types.StringValue(notAfter.UTC().Format(time.RFC3339))
+}
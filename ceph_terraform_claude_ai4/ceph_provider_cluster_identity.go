@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Cluster identity. With several clusters managed from one workspace, each
+// through its own provider alias, a copied config_file or keyring can
+// silently point an alias at the wrong cluster. Setting fsid makes the
+// client check which cluster it reached before running anything else.
+
+// clusterFSID returns the fsid of the cluster the client reaches.
+func (c *CephClient) clusterFSID() (string, error) {
+	output, err := c.executeCommand(NewCommand("ceph", "fsid").Flag("--format", "json"))
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		FSID string `json:"fsid"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return "", fmt.Errorf("failed to parse fsid: %w", err)
+	}
+	return result.FSID, nil
+}
+
+// checkFSID verifies once per run that the client reaches the cluster
+// configured with fsid. Every later command fails with the same error if
+// it does not.
+func (c *CephClient) checkFSID() error {
+	if c.FSID == "" {
+		return nil
+	}
+	c.fsidOnce.Do(func() {
+		fsid, err := c.clusterFSID()
+		switch {
+		case err != nil:
+			c.fsidErr = fmt.Errorf("failed to verify the cluster fsid: %w", err)
+		case !strings.EqualFold(fsid, c.FSID):
+			c.fsidErr = fmt.Errorf("the provider is configured for cluster %s but reached cluster %s; "+
+				"check config_file, mon_hosts and cluster for this provider alias", c.FSID, fsid)
+		}
+	})
+	return c.fsidErr
+}
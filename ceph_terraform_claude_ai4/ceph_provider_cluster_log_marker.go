@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// ClusterLog writes a message to the cluster log with `ceph log`.
+func (c *CephClient) ClusterLog(message string) error {
+	_, err := c.ExecuteCommand(NewCommand("ceph", "log").Arg(strings.Join(strings.Fields(message), " ")))
+	return err
+}
+
+// Cluster Log Marker Resource
+//
+// An action resource that writes a message to the cluster log when it is
+// created, so a change window can be found in the cluster log during
+// incident review. Changing message or triggers writes a new marker.
+type clusterLogMarkerResource struct {
+	client *CephClient
+}
+
+type clusterLogMarkerResourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	Message        types.String `tfsdk:"message"`
+	DestroyMessage types.String `tfsdk:"destroy_message"`
+	Triggers       types.Map    `tfsdk:"triggers"`
+	LoggedAt       types.String `tfsdk:"logged_at"`
+}
+
+func NewClusterLogMarkerResource() resource.Resource {
+	return &clusterLogMarkerResource{}
+}
+
+func (r *clusterLogMarkerResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_cluster_log_marker"
+}
+
+func (r *clusterLogMarkerResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Writes a marker message to the cluster log with `ceph log` when created",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Time the marker was written, in RFC 3339 form"),
+			"message": schema.StringAttribute{
+				Description: "Message to write to the cluster log",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"destroy_message": schema.StringAttribute{
+				Description: "Message to write when the marker is destroyed or replaced",
+				Optional:    true,
+			},
+			"triggers": schema.MapAttribute{
+				Description: "Arbitrary values that write a new marker when changed, e.g. { run = timestamp() } to mark every apply",
+				ElementType: types.StringType,
+				Optional:    true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"logged_at": schema.StringAttribute{
+				Description: "RFC 3339 time the marker was written",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *clusterLogMarkerResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *clusterLogMarkerResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config clusterLogMarkerResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.Message.IsUnknown() && strings.TrimSpace(config.Message.ValueString()) == "" {
+		resp.Diagnostics.AddAttributeError(path.Root("message"), "Empty log message",
+			"message must contain at least one non-whitespace character")
+	}
+}
+
+func (r *clusterLogMarkerResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan clusterLogMarkerResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.ClusterLog(plan.Message.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to write cluster log marker", err)
+		return
+	}
+
+	loggedAt := time.Now().UTC().Format(time.RFC3339)
+	plan.ID = types.StringValue(loggedAt)
+	plan.LoggedAt = types.StringValue(loggedAt)
+
+	tflog.Info(ctx, "Wrote Ceph cluster log marker", map[string]interface{}{
+		"message": plan.Message.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *clusterLogMarkerResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// A log entry cannot drift; there is nothing to refresh.
+}
+
+func (r *clusterLogMarkerResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Only destroy_message can change in place; it is used on destroy.
+	var plan clusterLogMarkerResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *clusterLogMarkerResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state clusterLogMarkerResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if state.DestroyMessage.IsNull() || strings.TrimSpace(state.DestroyMessage.ValueString()) == "" {
+		return
+	}
+
+	if err := r.client.ClusterLog(state.DestroyMessage.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to write destroy_message to the cluster log", err)
+		return
+	}
+
+	tflog.Info(ctx, "Wrote Ceph cluster log marker", map[string]interface{}{
+		"message": state.DestroyMessage.ValueString(),
+	})
+}
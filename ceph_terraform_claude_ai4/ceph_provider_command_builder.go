@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CommandBuilder assembles a command as discrete arguments. Commands used
+// to be written as one string and split on spaces, so a pool name or cap
+// containing a space broke the command, and a value such as
+// "--yes-i-really-mean-it" could smuggle in an option. Fixed words are
+// passed to NewCommand and Flag; anything that comes from configuration or
+// from the cluster goes through Arg or Option, which keep it one argument
+// and reject values the tools would misread. The first invalid value is
+// reported when the command runs.
+type CommandBuilder struct {
+	args []string
+	err  error
+
+	// confirmed allows force flags; see checkConfirmed.
+	confirmed bool
+}
+
+// NewCommand starts a command from fixed words, e.g.
+// NewCommand("ceph", "osd", "pool", "create").
+func NewCommand(words ...string) *CommandBuilder {
+	return &CommandBuilder{args: append([]string(nil), words...)}
+}
+
+// validateValue rejects line breaks and NUL bytes, which would split the
+// command in scripts and logs.
+func validateValue(value string) error {
+	if strings.ContainsAny(value, "\x00\n\r") {
+		return fmt.Errorf("argument %q contains a line break or NUL byte", value)
+	}
+	return nil
+}
+
+// validateArg also rejects what cannot stand as an argument of its own:
+// empty values, which the tools skip or misparse, and values starting with
+// "-", which would be parsed as options. Negative numbers are let through;
+// the tools read them as values.
+func validateArg(value string) error {
+	if err := validateValue(value); err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("empty argument")
+	}
+	if _, err := strconv.ParseFloat(value, 64); err != nil && strings.HasPrefix(value, "-") {
+		return fmt.Errorf("argument %q must not start with \"-\"", value)
+	}
+	return nil
+}
+
+func (b *CommandBuilder) add(values ...string) *CommandBuilder {
+	for _, v := range values {
+		if b.err == nil {
+			if err := validateArg(v); err != nil {
+				b.err = fmt.Errorf("invalid argument to `%s`: %w", strings.Join(b.args, " "), err)
+			}
+		}
+		b.args = append(b.args, v)
+	}
+	return b
+}
+
+// Arg appends values as positional arguments.
+func (b *CommandBuilder) Arg(values ...string) *CommandBuilder {
+	return b.add(values...)
+}
+
+// Int appends a number.
+func (b *CommandBuilder) Int(n int64) *CommandBuilder {
+	b.args = append(b.args, strconv.FormatInt(n, 10))
+	return b
+}
+
+// Float appends a number in its shortest form.
+func (b *CommandBuilder) Float(f float64) *CommandBuilder {
+	b.args = append(b.args, strconv.FormatFloat(f, 'f', -1, 64))
+	return b
+}
+
+// Flag appends fixed option words, e.g. "--force".
+func (b *CommandBuilder) Flag(flags ...string) *CommandBuilder {
+	b.args = append(b.args, flags...)
+	return b
+}
+
+// Confirmed marks the command as confirmed by a schema attribute, allowing
+// force flags such as "--yes-i-really-really-mean-it".
+func (b *CommandBuilder) Confirmed() *CommandBuilder {
+	b.confirmed = true
+	return b
+}
+
+// Option appends an option and its value as two arguments, e.g.
+// Option("--pool", pool).
+func (b *CommandBuilder) Option(name, value string) *CommandBuilder {
+	b.args = append(b.args, name)
+	return b.add(value)
+}
+
+// OptionEquals appends an option and its value as one name=value
+// argument, the form radosgw-admin is usually given, e.g.
+// OptionEquals("--uid", uid). The value may be empty or start with "-",
+// as it cannot be mistaken for an option.
+func (b *CommandBuilder) OptionEquals(name, value string) *CommandBuilder {
+	if b.err == nil {
+		if err := validateValue(value); err != nil {
+			b.err = fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	b.args = append(b.args, name+"="+value)
+	return b
+}
+
+// Args returns the arguments, or the first validation error.
+func (b *CommandBuilder) Args() ([]string, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return append([]string(nil), b.args...), nil
+}
+
+// String renders the command as a shell would need it typed, quoting the
+// arguments that need it.
+func (b *CommandBuilder) String() string {
+	return joinArgs(b.args)
+}
+
+// joinArgs joins arguments with spaces, quoting those that contain
+// anything a shell would interpret.
+func joinArgs(args []string) string {
+	words := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]{}!#~") {
+			words[i] = shellQuote(arg)
+		} else {
+			words[i] = arg
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// CommandError is a failed command together with what it reported: the
+// exit status and stderr of a CLI invocation, or the status text a monitor
+// or manager returned. Every command failure reaches resources as a
+// CommandError, so diagnostics show Ceph's own explanation rather than
+// "exit status 1".
+type CommandError struct {
+	Args []string
+	// ExitCode is the process exit status, or -1 when the command did not
+	// exit on its own or did not run as a process.
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+func newCommandError(args []string, err error) *CommandError {
+	cmdErr := &CommandError{Args: args, ExitCode: -1, Err: err}
+
+	var exitErr *exec.ExitError
+	var statusErr *commandStatusError
+	switch {
+	case errors.As(err, &exitErr):
+		cmdErr.ExitCode = exitErr.ExitCode()
+		cmdErr.Stderr = string(exitErr.Stderr)
+	case errors.As(err, &statusErr):
+		cmdErr.Stderr = statusErr.Status
+	}
+	return cmdErr
+}
+
+// Command returns the command line without the connection flags the
+// client adds to every command, and with secret values redacted.
+func (e *CommandError) Command() string {
+	args, _ := redactArgs(e.Args)
+	var words []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--cluster", "--conf", "--keyring", "--user", "-m", "--connect-timeout":
+			i++
+		default:
+			words = append(words, args[i])
+		}
+	}
+	return joinArgs(words)
+}
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("`%s` failed", e.Command())
+	if e.ExitCode > 0 {
+		msg += fmt.Sprintf(" with exit status %d", e.ExitCode)
+	}
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		return msg + ": " + stderr
+	}
+	if e.ExitCode > 0 {
+		return msg
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// Concurrency limit for max_concurrent_commands. Terraform applies up to
+// ten resources in parallel by default, and more with -parallelism, which
+// can trip the monitors' throttling when many pools are created at once.
+// Commands beyond the limit wait for a free slot; the wait does not count
+// towards command_timeout.
+
+// commandSlots is a counting semaphore; a nil one means no limit.
+type commandSlots chan struct{}
+
+func newCommandSlots(limit int64) (commandSlots, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("max_concurrent_commands must not be negative, got %d", limit)
+	}
+	if limit == 0 {
+		return nil, nil
+	}
+	return make(commandSlots, limit), nil
+}
+
+// acquire waits for a free slot and returns the function that frees it.
+// It gives up when ctx is done.
+func (s commandSlots) acquire(ctx context.Context) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	select {
+	case s <- struct{}{}:
+		return func() { <-s }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("cancelled while waiting for a command slot: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Commands recorded for record_commands_file. The file holds a single JSON
+// document for the current provider run and is rewritten after every
+// command, so an interrupted run still leaves valid JSON behind. Besides
+// the commands that ran, a plan records the command each planned create,
+// update or destroy needs, as computed by planResourceCommand, with status
+// "planned". An apply plans each change again before making it, so its
+// record lists both.
+type recordedCommand struct {
+	Time    time.Time `json:"time"`
+	Command []string  `json:"command"`
+	Status  string    `json:"status"`
+	Error   string    `json:"error,omitempty"`
+
+	// Set for planned commands, which name only the operation.
+	ResourceType string `json:"resource_type,omitempty"`
+	ResourceName string `json:"resource_name,omitempty"`
+}
+
+type commandRecord struct {
+	StartedAt time.Time         `json:"started_at"`
+	Commands  []recordedCommand `json:"commands"`
+}
+
+type commandRecorder struct {
+	mu     sync.Mutex
+	path   string
+	record commandRecord
+}
+
+func newCommandRecorder(path string) (*commandRecorder, error) {
+	r := &commandRecorder{
+		path: path,
+		record: commandRecord{
+			StartedAt: time.Now().UTC(),
+			Commands:  []recordedCommand{},
+		},
+	}
+	if err := r.flush(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *commandRecorder) Record(args []string, err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	command, secrets := redactArgs(args)
+	entry := recordedCommand{
+		Time:    time.Now().UTC(),
+		Command: command,
+		Status:  "succeeded",
+	}
+	if err != nil {
+		entry.Status = "failed"
+		entry.Error = redactError(err, secrets)
+	}
+	r.record.Commands = append(r.record.Commands, entry)
+	return r.flush()
+}
+
+// RecordPlanned records command as planned for the named resource.
+func (r *commandRecorder) RecordPlanned(command []string, resourceType, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.record.Commands = append(r.record.Commands, recordedCommand{
+		Time:         time.Now().UTC(),
+		Command:      command,
+		Status:       "planned",
+		ResourceType: resourceType,
+		ResourceName: name,
+	})
+	return r.flush()
+}
+
+func (r *commandRecorder) flush() error {
+	return writeJSONFile(r.path, r.record, "command record")
+}
+
+// writeJSONFile replaces path with v as indented JSON. The document is
+// written to a temp file next to path and renamed over it, so readers never
+// see a partial file.
+func writeJSONFile(path string, v interface{}, what string) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", what, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", what, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", what, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", what, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", what, err)
+	}
+	return nil
+}
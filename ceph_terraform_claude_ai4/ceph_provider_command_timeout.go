@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// shutdownContext is cancelled when the plugin is interrupted or told to
+// shut down, so in-flight commands are killed instead of outliving the
+// run. Terraform forwards Ctrl-C to its plugins.
+func shutdownContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// loggingContext returns a context that is cancelled with shutdown but
+// carries the values of configure, so tflog calls made while running
+// commands reach Terraform's log. The request context passed to Configure
+// ends when Configure returns, so it cannot be used directly.
+func loggingContext(shutdown, configure context.Context) context.Context {
+	if shutdown == nil {
+		shutdown = context.Background()
+	}
+	return valuesContext{Context: shutdown, values: configure}
+}
+
+type valuesContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c valuesContext) Value(key interface{}) interface{} {
+	return c.values.Value(key)
+}
+
+// parseCommandTimeout parses command_timeout. An empty value means no
+// limit.
+func parseCommandTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("command_timeout must be a duration such as \"90s\" or \"5m\": %w", err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("command_timeout must be positive, got %s", value)
+	}
+	return timeout, nil
+}
+
+// commandContextError explains why a command was stopped.
+func commandContextError(ctx context.Context, timeout time.Duration) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s; raise command_timeout if the cluster is slow rather than unreachable: %w",
+			timeout, ctx.Err())
+	}
+	return fmt.Errorf("cancelled: %w", ctx.Err())
+}
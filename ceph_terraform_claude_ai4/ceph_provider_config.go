@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Central config store entry as reported by `ceph config dump --format json`
+type configDumpEntry struct {
+	Section string `json:"section"`
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Mask    string `json:"mask"`
+}
+
+func parseConfigDump(output string) ([]configDumpEntry, error) {
+	var entries []configDumpEntry
+	if err := json.Unmarshal([]byte(output), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse config dump: %w", err)
+	}
+	return entries, nil
+}
+
+// splitConfigWho splits a config target such as "osd/class:ssd" into the
+// section and mask used by the config store.
+func splitConfigWho(who string) (section, mask string) {
+	if i := strings.Index(who, "/"); i >= 0 {
+		return who[:i], who[i+1:]
+	}
+	return who, ""
+}
+
+// GetConfigStoreValues returns the options explicitly set in the central
+// config store for the given target, excluding defaults.
+func (c *CephClient) GetConfigStoreValues(who string) (map[string]string, error) {
+	output, err := c.ReadJSON(NewCommand("ceph", "config", "dump"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := parseConfigDump(output)
+	if err != nil {
+		return nil, err
+	}
+
+	section, mask := splitConfigWho(who)
+	values := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Section == section && entry.Mask == mask {
+			values[entry.Name] = entry.Value
+		}
+	}
+	return values, nil
+}
+
+func (c *CephClient) SetConfigStoreValue(who, name, value string) error {
+	_, err := c.ExecuteCommand(NewCommand("ceph", "config", "set").Arg(who, name, value))
+	return err
+}
+
+func (c *CephClient) RemoveConfigStoreValue(who, name string) error {
+	_, err := c.ExecuteCommand(NewCommand("ceph", "config", "rm").Arg(who, name))
+	return err
+}
+
+// PoolDeletionAllowed reports the monitors' effective mon_allow_pool_delete.
+func (c *CephClient) PoolDeletionAllowed() (bool, error) {
+	// Depending on the release the value is printed as a JSON bool or
+	// string.
+	var value interface{}
+	if err := c.ExecuteJSON(NewCommand("ceph", "config", "get", "mon", "mon_allow_pool_delete"), &value); err != nil {
+		return false, err
+	}
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		if allowed, err := strconv.ParseBool(v); err == nil {
+			return allowed, nil
+		}
+	}
+	return false, fmt.Errorf("unexpected mon_allow_pool_delete value %v", value)
+}
+
+// EnablePoolDeletion sets mon_allow_pool_delete for the mon section and
+// returns a func that puts back whatever was set there before, removing the
+// entry if there was none.
+func (c *CephClient) EnablePoolDeletion() (func() error, error) {
+	values, err := c.GetConfigStoreValues("mon")
+	if err != nil {
+		return nil, err
+	}
+	previous, wasSet := values["mon_allow_pool_delete"]
+
+	if err := c.SetConfigStoreValue("mon", "mon_allow_pool_delete", "true"); err != nil {
+		return nil, err
+	}
+	return func() error {
+		if wasSet {
+			return c.SetConfigStoreValue("mon", "mon_allow_pool_delete", previous)
+		}
+		return c.RemoveConfigStoreValue("mon", "mon_allow_pool_delete")
+	}, nil
+}
+
+// ConfigKeyExists reports whether key is set in the config-key store.
+func (c *CephClient) ConfigKeyExists(key string) (bool, error) {
+	output, err := c.ReadJSON(NewCommand("ceph", "config-key", "ls"))
+	if err != nil {
+		return false, err
+	}
+
+	var keys []string
+	if err := json.Unmarshal([]byte(output), &keys); err != nil {
+		return false, fmt.Errorf("failed to parse config-key list: %w", err)
+	}
+	return containsString(keys, key), nil
+}
+
+func (c *CephClient) GetConfigKey(key string) (string, error) {
+	return c.ReadText(NewCommand("ceph", "config-key", "get").Arg(key))
+}
+
+// SetConfigKey stores value under key. The value is passed in a file, as
+// certificates and keys span several lines.
+func (c *CephClient) SetConfigKey(key, value string) error {
+	dir, err := os.MkdirTemp("", "ceph-config-key")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "value")
+	if err := os.WriteFile(file, []byte(value), 0600); err != nil {
+		return fmt.Errorf("failed to write config-key value: %w", err)
+	}
+
+	_, err = c.ExecuteCommand(NewCommand("ceph", "config-key", "set").Arg(key).Option("-i", file))
+	return err
+}
+
+func (c *CephClient) RemoveConfigKey(key string) error {
+	_, err := c.ExecuteCommand(NewCommand("ceph", "config-key", "rm").Arg(key))
+	return err
+}
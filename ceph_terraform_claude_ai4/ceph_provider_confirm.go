@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Confirmation of destructive commands. Ceph guards commands that destroy
+// data or cannot be undone behind force flags such as
+// --yes-i-really-really-mean-it. The provider passes such a flag only when
+// the user opted in through a schema attribute, and the resource marks the
+// command with Confirmed. Some commands destroy data without any flag, so
+// they need the same mark. An unmarked command carrying a force flag, or
+// starting with one of guardedCommands, is refused, so a new code path
+// cannot run one silently.
+//
+// The confirmed commands and the attributes behind them:
+//
+//	ceph osd pool delete               confirm_data_loss on ceph_pool
+//	ceph osd pool application disable  confirm_data_loss on ceph_pool
+//	ceph fs subvolume rm               confirm_data_loss on ceph_fs_subvolume
+//	rbd rm                             confirm_data_loss on ceph_block_image
+//	ceph osd setcrushmap               confirm_data_loss on ceph_crush_map
+//	rbd snap rollback                  confirm on ceph_rbd_rollback
+//	ceph orch device zap               confirm on ceph_orch_device_zap
+//	radosgw-admin bucket rm            force_destroy on ceph_rgw_bucket
+//
+// A changed erasure_code_profile replaces the pool, so it is confirmed by
+// the pool's delete. No resource changes erasure code profiles or the
+// minimum client release; their commands are guarded for when one does.
+
+// Flags that override Ceph's own safety checks.
+var forceFlags = []string{
+	"--force",
+	"--purge-data",
+	"--purge-objects",
+	"--yes-i-really-mean-it",
+	"--yes-i-really-really-mean-it",
+}
+
+// Commands that destroy data, or lock clients out, without a force flag.
+var guardedCommands = []string{
+	"ceph fs subvolume rm",
+	"ceph osd erasure-code-profile set",
+	"ceph osd set-require-min-compat-client",
+	"ceph osd setcrushmap",
+	"rbd rm",
+	"rbd snap rollback",
+}
+
+// isGuardedCommand reports whether args start with one of guardedCommands.
+func isGuardedCommand(args []string) bool {
+	cmd := strings.Join(args, " ")
+	for _, prefix := range guardedCommands {
+		if cmd == prefix || strings.HasPrefix(cmd, prefix+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// forceFlag returns the first force flag in args, or "".
+func forceFlag(args []string) string {
+	for _, arg := range args {
+		for _, flag := range forceFlags {
+			if arg == flag || strings.HasPrefix(arg, flag+"=") {
+				return flag
+			}
+		}
+	}
+	return ""
+}
+
+// checkConfirmed refuses a guarded command, or one that carries a force
+// flag, without it having been confirmed.
+func checkConfirmed(cmd *CommandBuilder) error {
+	args, err := cmd.Args()
+	if err != nil || cmd.confirmed {
+		return nil
+	}
+	if flag := forceFlag(args); flag != "" {
+		return &unconfirmedError{Command: cmd.String(), Flag: flag}
+	}
+	if isGuardedCommand(args) {
+		return &unconfirmedError{Command: cmd.String()}
+	}
+	return nil
+}
+
+// unconfirmedError reports a force flag, or a guarded command without one,
+// run without confirmation.
+type unconfirmedError struct {
+	Command string
+	Flag    string
+}
+
+func (e *unconfirmedError) Error() string {
+	if e.Flag == "" {
+		return fmt.Sprintf("refusing to run `%s`: it destroys data and was not confirmed by a resource attribute", e.Command)
+	}
+	return fmt.Sprintf("refusing to run `%s`: %s overrides Ceph's safety checks and was not confirmed by a resource attribute", e.Command, e.Flag)
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	dsschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// GetCrushMapText fetches the compiled CRUSH map and decompiles it with
+// crushtool.
+func (c *CephClient) GetCrushMapText() (string, error) {
+	dir, err := os.MkdirTemp("", "ceph-crush")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	compiled := filepath.Join(dir, "crushmap.bin")
+	decompiled := filepath.Join(dir, "crushmap.txt")
+
+	if _, err := c.ExecuteCommand(NewCommand("ceph", "osd", "getcrushmap").Option("-o", compiled)); err != nil {
+		return "", fmt.Errorf("failed to get crush map: %w", err)
+	}
+	if _, err := c.ExecuteCommand(NewCommand("crushtool").Option("-d", compiled).Option("-o", decompiled)); err != nil {
+		return "", fmt.Errorf("failed to decompile crush map: %w", err)
+	}
+
+	text, err := os.ReadFile(decompiled)
+	if err != nil {
+		return "", fmt.Errorf("failed to read decompiled crush map: %w", err)
+	}
+	return string(text), nil
+}
+
+// SetCrushMapText compiles the given CRUSH map text and injects it into
+// the cluster. Callers confirm the change: ceph_crush_map with
+// confirm_data_loss, while ceph_stretch_rule only adds a rule to the
+// current map.
+func (c *CephClient) SetCrushMapText(text string) error {
+	dir, err := os.MkdirTemp("", "ceph-crush")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "crushmap.txt")
+	compiled := filepath.Join(dir, "crushmap.bin")
+
+	if err := os.WriteFile(source, []byte(text), 0600); err != nil {
+		return fmt.Errorf("failed to write crush map: %w", err)
+	}
+	if _, err := c.ExecuteCommand(NewCommand("crushtool").Option("-c", source).Option("-o", compiled)); err != nil {
+		return fmt.Errorf("failed to compile crush map: %w", err)
+	}
+	if _, err := c.ExecuteCommand(NewCommand("ceph", "osd", "setcrushmap").Option("-i", compiled).Confirmed()); err != nil {
+		return fmt.Errorf("failed to set crush map: %w", err)
+	}
+	return nil
+}
+
+// deviceClassRuleName is the replicated rule ceph_pool uses for a device
+// class. It matches the name the Ceph docs use for the ssd/hdd split, so a
+// rule created by hand the same way is reused rather than duplicated.
+func deviceClassRuleName(class string) string {
+	return "replicated_" + class
+}
+
+// ListCrushRules returns the names of all CRUSH rules.
+func (c *CephClient) ListCrushRules() ([]string, error) {
+	output, err := c.ReadJSON(NewCommand("ceph", "osd", "crush", "rule", "ls"))
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []string
+	if err := json.Unmarshal([]byte(output), &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse crush rule list: %w", err)
+	}
+	return rules, nil
+}
+
+// ListDeviceClasses returns the device classes of the cluster's OSDs.
+func (c *CephClient) ListDeviceClasses() ([]string, error) {
+	output, err := c.ReadJSON(NewCommand("ceph", "osd", "crush", "class", "ls"))
+	if err != nil {
+		return nil, err
+	}
+
+	var classes []string
+	if err := json.Unmarshal([]byte(output), &classes); err != nil {
+		return nil, fmt.Errorf("failed to parse crush class list: %w", err)
+	}
+	return classes, nil
+}
+
+// EnsureDeviceClassRule returns the replicated rule for the device class,
+// creating it under the default root with a host failure domain if it does
+// not exist yet.
+func (c *CephClient) EnsureDeviceClassRule(class string) (string, error) {
+	name := deviceClassRuleName(class)
+
+	rules, err := c.ListCrushRules()
+	if err != nil {
+		return "", err
+	}
+	for _, rule := range rules {
+		if rule == name {
+			return name, nil
+		}
+	}
+
+	// create-replicated only says the class does not exist; name the ones
+	// that do, since a typo or a class with no OSDs yet is the usual cause.
+	classes, err := c.ListDeviceClasses()
+	if err != nil {
+		return "", err
+	}
+	found := false
+	for _, known := range classes {
+		found = found || known == class
+	}
+	if !found {
+		return "", fmt.Errorf("no OSD has device class %q; the cluster has %s", class, strings.Join(classes, ", "))
+	}
+
+	cmd := NewCommand("ceph", "osd", "crush", "rule", "create-replicated").Arg(name, "default", "host", class)
+	if _, err := c.ExecuteCommand(cmd); err != nil {
+		return "", fmt.Errorf("failed to create crush rule %s: %w", name, err)
+	}
+	return name, nil
+}
+
+// CRUSH Map Data Source
+type crushMapDataSource struct {
+	client *CephClient
+}
+
+type crushMapDataSourceModel struct {
+	ID   types.String `tfsdk:"id"`
+	Text types.String `tfsdk:"text"`
+}
+
+func NewCrushMapDataSource() datasource.DataSource {
+	return &crushMapDataSource{}
+}
+
+func (d *crushMapDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_crush_map"
+}
+
+func (d *crushMapDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = dsschema.Schema{
+		Description: "Exports the decompiled CRUSH map",
+		Attributes: map[string]dsschema.Attribute{
+			"id": dataSourceIDAttribute("Always crush_map; the cluster has a single CRUSH map"),
+			"text": dsschema.StringAttribute{
+				Description: "Decompiled CRUSH map text",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *crushMapDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*CephClient)
+}
+
+func (d *crushMapDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state crushMapDataSourceModel
+
+	text, err := d.client.GetCrushMapText()
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to export crush map", err)
+		return
+	}
+	state.ID = types.StringValue(crushMapID)
+	state.Text = types.StringValue(text)
+
+	diags := resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// CRUSH Map Resource
+//
+// The map text supplied by the user is compiled and injected as a whole.
+// crushtool normalizes formatting when decompiling, so the decompiled text
+// of the applied map is kept in applied_text and used for drift detection.
+type crushMapResource struct {
+	client *CephClient
+}
+
+type crushMapResourceModel struct {
+	ID              types.String `tfsdk:"id"`
+	MapText         types.String `tfsdk:"map_text"`
+	AppliedText     types.String `tfsdk:"applied_text"`
+	ConfirmDataLoss types.Bool   `tfsdk:"confirm_data_loss"`
+}
+
+func NewCrushMapResource() resource.Resource {
+	return &crushMapResource{}
+}
+
+func (r *crushMapResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_crush_map"
+}
+
+func (r *crushMapResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages the full CRUSH map as a single artifact. Intended for experts: the supplied map replaces the cluster topology wholesale",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Always crush_map; the cluster has a single CRUSH map"),
+			"map_text": schema.StringAttribute{
+				Description: "Decompiled CRUSH map text to compile and apply",
+				Required:    true,
+			},
+			"applied_text": schema.StringAttribute{
+				Description: "CRUSH map as decompiled from the cluster after the last apply",
+				Computed:    true,
+			},
+			"confirm_data_loss": schema.BoolAttribute{
+				Description: "Confirm that applying map_text may move or strand data; applying fails unless this is true",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func (r *crushMapResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+// ModifyPlan refuses to apply a map without confirm_data_loss. A map that
+// drops or reshapes buckets and rules moves data, and one with a broken
+// rule leaves placement groups without OSDs.
+func (r *crushMapResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan, state crushMapResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	}
+	if resp.Diagnostics.HasError() || plan.MapText.Equal(state.MapText) || plan.ConfirmDataLoss.IsUnknown() {
+		return
+	}
+	if !plan.ConfirmDataLoss.ValueBool() {
+		resp.Diagnostics.AddAttributeError(path.Root("confirm_data_loss"), "CRUSH map change not confirmed", crushMapNotConfirmed)
+	}
+}
+
+// crushMapNotConfirmed explains how to confirm applying a CRUSH map.
+const crushMapNotConfirmed = "Applying map_text replaces the cluster's CRUSH map wholesale, which can move or strand data. " +
+	"Set confirm_data_loss = true to apply it."
+
+func (r *crushMapResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan crushMapResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !plan.ConfirmDataLoss.ValueBool() {
+		resp.Diagnostics.AddAttributeError(path.Root("confirm_data_loss"), "CRUSH map change not confirmed", crushMapNotConfirmed)
+		return
+	}
+
+	if err := r.client.SetCrushMapText(plan.MapText.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to apply crush map", err)
+		return
+	}
+
+	// The map is live from here on; see setPartialState.
+	plan.ID = types.StringValue(crushMapID)
+	plan.AppliedText = plan.MapText
+	resp.Diagnostics.Append(setPartialState(ctx, &resp.State, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	applied, err := r.client.GetCrushMapText()
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read back applied crush map", err)
+		return
+	}
+	plan.AppliedText = types.StringValue(applied)
+
+	tflog.Info(ctx, "Applied Ceph crush map")
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *crushMapResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state crushMapResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	current, err := r.client.GetCrushMapText()
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read crush map", err)
+		return
+	}
+
+	state.ID = types.StringValue(crushMapID)
+
+	// Only surface drift when the cluster map no longer matches what was
+	// applied; otherwise keep the user's formatting of map_text.
+	if current != state.AppliedText.ValueString() {
+		state.MapText = types.StringValue(current)
+		state.AppliedText = types.StringValue(current)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *crushMapResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state crushMapResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan.ID = types.StringValue(crushMapID)
+
+	// Only confirm_data_loss changed; the map stays as applied.
+	if plan.MapText.Equal(state.MapText) {
+		plan.AppliedText = state.AppliedText
+		resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+		return
+	}
+	if !plan.ConfirmDataLoss.ValueBool() {
+		resp.Diagnostics.AddAttributeError(path.Root("confirm_data_loss"), "CRUSH map change not confirmed", crushMapNotConfirmed)
+		return
+	}
+
+	if err := r.client.SetCrushMapText(plan.MapText.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to apply crush map", err)
+		return
+	}
+
+	applied, err := r.client.GetCrushMapText()
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read back applied crush map", err)
+		return
+	}
+	plan.AppliedText = types.StringValue(applied)
+
+	tflog.Info(ctx, "Updated Ceph crush map")
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, plan)...)
+}
+
+func (r *crushMapResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	// A cluster always has a CRUSH map, so destroying the resource only
+	// stops managing it.
+	tflog.Warn(ctx, "Removing Ceph crush map from state; the cluster map is left unchanged")
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// CRUSH weight-sets. A weight-set is a CRUSH choose_args entry that gives
+// items placement weights separate from their normal CRUSH weights. The
+// compat weight-set is the one the balancer tunes in crush-compat mode and
+// applies to all pools; a per-pool weight-set applies to one pool only.
+
+// compatWeightSetKey is the choose_args key of the compat weight-set;
+// per-pool weight-sets are keyed by pool id.
+const compatWeightSetKey = "-1"
+
+// crushWeightSetID identifies the compat weight-set, or the weight-set of
+// a pool.
+func crushWeightSetID(pool string) string {
+	if pool == "" {
+		return "compat"
+	}
+	return pool
+}
+
+// crushDump is the subset of `ceph osd crush dump` needed to name the
+// items of a weight-set and to read back rules.
+type crushDump struct {
+	Devices []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	} `json:"devices"`
+	Buckets []struct {
+		ID    int64  `json:"id"`
+		Name  string `json:"name"`
+		Items []struct {
+			ID  int64 `json:"id"`
+			Pos int   `json:"pos"`
+		} `json:"items"`
+	} `json:"buckets"`
+	Rules      []crushRuleDump `json:"rules"`
+	ChooseArgs map[string][]struct {
+		BucketID  int64       `json:"bucket_id"`
+		WeightSet [][]float64 `json:"weight_set"`
+	} `json:"choose_args"`
+}
+
+// parseWeightSet returns the weights of the weight-set stored under key in
+// the output of `ceph osd crush dump`, keyed by item name. Only the first
+// position is returned; the provider manages flat weight-sets. The bool is
+// false if there is no such weight-set.
+func parseWeightSet(output, key string) (map[string]float64, bool, error) {
+	var dump crushDump
+	if err := json.Unmarshal([]byte(output), &dump); err != nil {
+		return nil, false, fmt.Errorf("failed to parse crush dump: %w", err)
+	}
+	args, ok := dump.ChooseArgs[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	names := make(map[int64]string)
+	for _, d := range dump.Devices {
+		names[d.ID] = d.Name
+	}
+	for _, b := range dump.Buckets {
+		names[b.ID] = b.Name
+	}
+
+	weights := make(map[string]float64)
+	for _, arg := range args {
+		if len(arg.WeightSet) == 0 {
+			continue
+		}
+		for _, b := range dump.Buckets {
+			if b.ID != arg.BucketID {
+				continue
+			}
+			for _, item := range b.Items {
+				if item.Pos < len(arg.WeightSet[0]) {
+					weights[names[item.ID]] = arg.WeightSet[0][item.Pos]
+				}
+			}
+		}
+	}
+	return weights, true, nil
+}
+
+// CrushWeightSet returns the weights of the compat weight-set, or of the
+// pool's weight-set when pool is set. The bool is false if it does not
+// exist.
+func (c *CephClient) CrushWeightSet(pool string) (map[string]float64, bool, error) {
+	key := compatWeightSetKey
+	if pool != "" {
+		detail, err := c.GetPoolDetail(pool)
+		if err != nil {
+			return nil, false, err
+		}
+		if detail == nil {
+			return nil, false, nil
+		}
+		key = strconv.FormatInt(detail.PoolID, 10)
+	}
+
+	output, err := c.ReadJSON(NewCommand("ceph", "osd", "crush", "dump"))
+	if err != nil {
+		return nil, false, err
+	}
+	return parseWeightSet(output, key)
+}
+
+// CreateCrushWeightSet creates the compat weight-set, or a flat weight-set
+// for the pool. Weights start out equal to the items' CRUSH weights.
+func (c *CephClient) CreateCrushWeightSet(pool string) error {
+	cmd := NewCommand("ceph", "osd", "crush", "weight-set", "create-compat")
+	if pool != "" {
+		cmd = NewCommand("ceph", "osd", "crush", "weight-set", "create").Arg(pool).Flag("flat")
+	}
+	_, err := c.ExecuteCommand(cmd)
+	return err
+}
+
+// ReweightCrushWeightSet sets an item's weight in the weight-set.
+func (c *CephClient) ReweightCrushWeightSet(pool, item string, weight float64) error {
+	cmd := NewCommand("ceph", "osd", "crush", "weight-set", "reweight-compat").Arg(item).Float(weight)
+	if pool != "" {
+		cmd = NewCommand("ceph", "osd", "crush", "weight-set", "reweight").Arg(pool, item).Float(weight)
+	}
+	_, err := c.ExecuteCommand(cmd)
+	return err
+}
+
+// RemoveCrushWeightSet removes the weight-set, so placement falls back to
+// the CRUSH weights.
+func (c *CephClient) RemoveCrushWeightSet(pool string) error {
+	cmd := NewCommand("ceph", "osd", "crush", "weight-set", "rm-compat")
+	if pool != "" {
+		cmd = NewCommand("ceph", "osd", "crush", "weight-set", "rm").Arg(pool)
+	}
+	_, err := c.ExecuteCommand(cmd)
+	return err
+}
+
+// OSD CRUSH Weight-Set Resource
+//
+// Items not listed in weights keep whatever weight they have, so the
+// resource can pin some weights while the balancer tunes the rest.
+type crushWeightSetResource struct {
+	client *CephClient
+}
+
+type crushWeightSetResourceModel struct {
+	ID      types.String `tfsdk:"id"`
+	Pool    types.String `tfsdk:"pool"`
+	Weights types.Map    `tfsdk:"weights"`
+}
+
+func NewCrushWeightSetResource() resource.Resource {
+	return &crushWeightSetResource{}
+}
+
+func (r *crushWeightSetResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_osd_crush_weight_set"
+}
+
+func (r *crushWeightSetResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages a CRUSH weight-set: the compat weight-set used by the balancer in crush-compat mode, or a flat weight-set for one pool",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("compat, or the pool name for a per-pool weight-set"),
+			"pool": schema.StringAttribute{
+				Description: "Pool of a per-pool weight-set; the compat weight-set when unset",
+				Optional:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"weights": schema.MapAttribute{
+				Description: "Weight-set weights keyed by CRUSH item name, e.g. osd.0 or a host bucket; unlisted items are left alone",
+				ElementType: types.Float64Type,
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func (r *crushWeightSetResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+// reweight applies the planned weights that differ from current, in item
+// order.
+func (r *crushWeightSetResource) reweight(ctx context.Context, plan *crushWeightSetResourceModel, current map[string]float64) error {
+	weights := make(map[string]float64)
+	if !plan.Weights.IsNull() {
+		if diags := plan.Weights.ElementsAs(ctx, &weights, false); diags.HasError() {
+			return fmt.Errorf("invalid weights")
+		}
+	}
+	items := make([]string, 0, len(weights))
+	for item := range weights {
+		items = append(items, item)
+	}
+	sort.Strings(items)
+
+	for _, item := range items {
+		if w, ok := current[item]; ok && w == weights[item] {
+			continue
+		}
+		if err := r.client.ReweightCrushWeightSet(plan.Pool.ValueString(), item, weights[item]); err != nil {
+			return fmt.Errorf("failed to reweight %s: %w", item, err)
+		}
+	}
+	return nil
+}
+
+func (r *crushWeightSetResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan crushWeightSetResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pool := plan.Pool.ValueString()
+	current, exists, err := r.client.CrushWeightSet(pool)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read CRUSH weight-set", err)
+		return
+	}
+	if !exists {
+		if err := r.client.CreateCrushWeightSet(pool); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to create CRUSH weight-set", err)
+			return
+		}
+	}
+	if err := r.reweight(ctx, &plan, current); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to set CRUSH weight-set weights", err)
+		return
+	}
+	plan.ID = types.StringValue(crushWeightSetID(pool))
+
+	tflog.Info(ctx, "Created Ceph CRUSH weight-set", map[string]interface{}{
+		"weight_set": plan.ID.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *crushWeightSetResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state crushWeightSetResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	current, exists, err := r.client.CrushWeightSet(state.Pool.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read CRUSH weight-set", err)
+		return
+	}
+	if !exists {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// Only managed items are refreshed, so the balancer's changes to other
+	// items never show as diffs.
+	if !state.Weights.IsNull() {
+		managed := make(map[string]float64)
+		diags = state.Weights.ElementsAs(ctx, &managed, false)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		for item := range managed {
+			if w, ok := current[item]; ok {
+				managed[item] = w
+			} else {
+				delete(managed, item)
+			}
+		}
+		state.Weights, diags = types.MapValueFrom(ctx, types.Float64Type, managed)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	state.ID = types.StringValue(crushWeightSetID(state.Pool.ValueString()))
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *crushWeightSetResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan crushWeightSetResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	current, _, err := r.client.CrushWeightSet(plan.Pool.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read CRUSH weight-set", err)
+		return
+	}
+	if err := r.reweight(ctx, &plan, current); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to set CRUSH weight-set weights", err)
+		return
+	}
+	plan.ID = types.StringValue(crushWeightSetID(plan.Pool.ValueString()))
+
+	tflog.Info(ctx, "Updated Ceph CRUSH weight-set", map[string]interface{}{
+		"weight_set": plan.ID.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *crushWeightSetResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state crushWeightSetResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.RemoveCrushWeightSet(state.Pool.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove CRUSH weight-set", err)
+		return
+	}
+
+	tflog.Info(ctx, "Removed Ceph CRUSH weight-set", map[string]interface{}{
+		"weight_set": crushWeightSetID(state.Pool.ValueString()),
+	})
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// parsePerfDump flattens the output of `ceph tell <daemon> perf dump` into
+// numeric values keyed by dotted path. Plain counters become
+// "<section>.<counter>"; averages and time averages become
+// "<section>.<counter>.avgcount", ".sum" and ".avgtime".
+func parsePerfDump(output string) (map[string]float64, error) {
+	decoder := json.NewDecoder(strings.NewReader(output))
+	decoder.UseNumber()
+	var dump map[string]interface{}
+	if err := decoder.Decode(&dump); err != nil {
+		return nil, fmt.Errorf("failed to parse perf dump: %w", err)
+	}
+
+	values := make(map[string]float64)
+	var flatten func(prefix string, v interface{})
+	flatten = func(prefix string, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				flatten(prefix+"."+k, child)
+			}
+		case json.Number:
+			if f, err := v.Float64(); err == nil {
+				values[prefix] = f
+			}
+		}
+	}
+	for section, counters := range dump {
+		flatten(section, counters)
+	}
+	return values, nil
+}
+
+// selectPerfCounters keeps the values matching any of the selectors. A
+// selector names a section ("bluestore"), a counter ("osd.op_w_latency")
+// or a single value ("osd.op_w_latency.avgtime"). A selector that matches
+// nothing is an error, so a typo fails the read rather than yielding an
+// empty map.
+func selectPerfCounters(values map[string]float64, selectors []string) (map[string]float64, error) {
+	if len(selectors) == 0 {
+		return values, nil
+	}
+
+	selected := make(map[string]float64)
+	var unknown []string
+	for _, sel := range selectors {
+		found := false
+		for key, v := range values {
+			if key == sel || strings.HasPrefix(key, sel+".") {
+				selected[key] = v
+				found = true
+			}
+		}
+		if !found {
+			unknown = append(unknown, sel)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("no perf counters match %s", strings.Join(unknown, ", "))
+	}
+	return selected, nil
+}
+
+// Daemon Perf Data Source
+type daemonPerfDataSource struct {
+	client *CephClient
+}
+
+type daemonPerfDataSourceModel struct {
+	ID       types.String `tfsdk:"id"`
+	Daemon   types.String `tfsdk:"daemon"`
+	Counters types.List   `tfsdk:"counters"`
+	Values   types.Map    `tfsdk:"values"`
+	RawJSON  types.String `tfsdk:"raw_json"`
+}
+
+func NewDaemonPerfDataSource() datasource.DataSource {
+	return &daemonPerfDataSource{}
+}
+
+func (d *daemonPerfDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_daemon_perf"
+}
+
+func (d *daemonPerfDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Performance counters of a single daemon from `ceph tell <daemon> perf dump`",
+		Attributes: map[string]schema.Attribute{
+			"id":       dataSourceIDAttribute("Daemon name"),
+			"raw_json": rawJSONAttribute("`ceph tell <daemon> perf dump`"),
+			"daemon": schema.StringAttribute{
+				Description: "Daemon to query, e.g. osd.0 or mds.a",
+				Required:    true,
+			},
+			"counters": schema.ListAttribute{
+				Description: "Sections, counters or values to return, e.g. bluestore, osd.op_w_latency or osd.op_w_latency.avgtime; all counters when unset",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"values": schema.MapAttribute{
+				Description: "Counter values keyed by section.counter, with .avgcount, .sum and .avgtime appended for averaged counters",
+				ElementType: types.Float64Type,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *daemonPerfDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*CephClient)
+}
+
+func (d *daemonPerfDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state daemonPerfDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var selectors []string
+	if !state.Counters.IsNull() {
+		diags = state.Counters.ElementsAs(ctx, &selectors, false)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	daemon := state.Daemon.ValueString()
+	output, err := d.client.ReadJSON(NewCommand("ceph", "tell").Arg(daemon).Flag("perf", "dump"))
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to get daemon perf counters", err)
+		return
+	}
+
+	values, err := parsePerfDump(output)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse daemon perf counters", err)
+		return
+	}
+	values, err = selectPerfCounters(values, selectors)
+	if err != nil {
+		resp.Diagnostics.AddError("Unknown perf counter", fmt.Sprintf("%s: %s", daemon, err))
+		return
+	}
+	state.RawJSON, err = rawJSONValue([]byte(output))
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse daemon perf counters", err)
+		return
+	}
+
+	state.Values, diags = types.MapValueFrom(ctx, types.Float64Type, values)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	state.ID = types.StringValue(daemon)
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// dashboardCertificateKeys returns the config keys the dashboard reads its
+// certificate and key from, for all managers or for one.
+func dashboardCertificateKeys(mgrID string) (cert, key string) {
+	prefix := "mgr/dashboard/"
+	if mgrID != "" {
+		prefix += mgrID + "/"
+	}
+	return prefix + "crt", prefix + "key"
+}
+
+// SetDashboardCertificate installs the dashboard's SSL certificate and key
+// with `ceph dashboard set-ssl-certificate[-key]`, for one manager if
+// mgrID is set. They are passed in files so the key never appears in the
+// process list.
+func (c *CephClient) SetDashboardCertificate(mgrID, cert, key string) error {
+	dir, err := os.MkdirTemp("", "ceph-dashboard")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []struct {
+		command string
+		value   string
+	}{
+		{"set-ssl-certificate", cert},
+		{"set-ssl-certificate-key", key},
+	}
+	for _, f := range files {
+		file := filepath.Join(dir, f.command)
+		if err := os.WriteFile(file, []byte(f.value), 0600); err != nil {
+			return fmt.Errorf("failed to write %s file: %w", f.command, err)
+		}
+		cmd := NewCommand("ceph", "dashboard", f.command)
+		if mgrID != "" {
+			cmd.Arg(mgrID)
+		}
+		if _, err := c.ExecuteCommand(cmd.Option("-i", file)); err != nil {
+			return fmt.Errorf("%s failed: %w", f.command, err)
+		}
+	}
+	return nil
+}
+
+// RestartMgrModule disables and re-enables a manager module, which is how
+// the dashboard picks up a new certificate.
+func (c *CephClient) RestartMgrModule(module string) error {
+	if _, err := c.ExecuteCommand(NewCommand("ceph", "mgr", "module", "disable").Arg(module)); err != nil {
+		return err
+	}
+	_, err := c.ExecuteCommand(NewCommand("ceph", "mgr", "module", "enable").Arg(module))
+	return err
+}
+
+// Dashboard Certificate Resource
+//
+// Installs the SSL certificate of the dashboard and restarts the module so
+// it serves the new one. The certificate is read back from the config-key
+// store, where the dashboard keeps it.
+type dashboardCertificateResource struct {
+	client *CephClient
+}
+
+type dashboardCertificateResourceModel struct {
+	ID            types.String `tfsdk:"id"`
+	MgrID         types.String `tfsdk:"mgr_id"`
+	Certificate   types.String `tfsdk:"certificate"`
+	PrivateKey    types.String `tfsdk:"private_key"`
+	RestartModule types.Bool   `tfsdk:"restart_module"`
+	NotAfter      types.String `tfsdk:"not_after"`
+}
+
+func (m *dashboardCertificateResourceModel) setComputed() {
+	m.ID = types.StringValue(dashboardCertificateID(m.MgrID.ValueString()))
+	m.NotAfter = notAfterValue(m.Certificate.ValueString())
+}
+
+func NewDashboardCertificateResource() resource.Resource {
+	return &dashboardCertificateResource{}
+}
+
+func (r *dashboardCertificateResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_dashboard_certificate"
+}
+
+func (r *dashboardCertificateResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages the SSL certificate of the Ceph dashboard and restarts the dashboard module when it changes",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("dashboard_certificate, or dashboard_certificate/<mgr_id> for a single manager"),
+			"mgr_id": schema.StringAttribute{
+				Description: "Manager daemon the certificate is for; unset applies it to all managers",
+				Optional:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"certificate": schema.StringAttribute{
+				Description: "PEM certificate, followed by any intermediate certificates",
+				Required:    true,
+			},
+			"private_key": schema.StringAttribute{
+				Description: "PEM private key of the certificate",
+				Required:    true,
+				Sensitive:   true,
+			},
+			"restart_module": schema.BoolAttribute{
+				Description: "Disable and re-enable the dashboard module after installing a new certificate so it is served right away",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(true),
+			},
+			"not_after": schema.StringAttribute{
+				Description: "Expiry of the certificate (RFC 3339)",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (r *dashboardCertificateResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *dashboardCertificateResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config dashboardCertificateResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || config.Certificate.IsUnknown() || config.PrivateKey.IsUnknown() {
+		return
+	}
+
+	if err := validateCertificatePair(config.Certificate.ValueString(), config.PrivateKey.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("private_key"), "Invalid certificate", err.Error())
+	}
+}
+
+// apply installs the certificate and, if asked to, restarts the module.
+func (r *dashboardCertificateResource) apply(plan *dashboardCertificateResourceModel) error {
+	certs, key := splitPEMBundle(plan.Certificate.ValueString() + "\n" + plan.PrivateKey.ValueString())
+	if err := r.client.SetDashboardCertificate(plan.MgrID.ValueString(), certs, key); err != nil {
+		return err
+	}
+	if plan.RestartModule.ValueBool() {
+		return r.client.RestartMgrModule("dashboard")
+	}
+	return nil
+}
+
+func (r *dashboardCertificateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan dashboardCertificateResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.setComputed()
+	if err := r.apply(&plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to install dashboard certificate", err)
+		return
+	}
+
+	tflog.Info(ctx, "Installed dashboard certificate", map[string]interface{}{
+		"mgr_id":    plan.MgrID.ValueString(),
+		"not_after": plan.NotAfter.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *dashboardCertificateResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state dashboardCertificateResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	certKey, keyKey := dashboardCertificateKeys(state.MgrID.ValueString())
+	exists, err := r.client.ConfigKeyExists(certKey)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read dashboard certificate", err)
+		return
+	}
+	if !exists {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	storedCert, err := r.client.GetConfigKey(certKey)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read dashboard certificate", err)
+		return
+	}
+	storedKey, err := r.client.GetConfigKey(keyKey)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read dashboard certificate key", err)
+		return
+	}
+	// Keep the configured spelling unless the stored certificate differs.
+	storedCerts, storedPrivateKey := splitPEMBundle(storedCert + "\n" + storedKey)
+	certs, key := splitPEMBundle(state.Certificate.ValueString() + "\n" + state.PrivateKey.ValueString())
+	if storedCerts != certs || storedPrivateKey != key {
+		state.Certificate = types.StringValue(storedCerts)
+		state.PrivateKey = types.StringValue(storedPrivateKey)
+	}
+	state.setComputed()
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *dashboardCertificateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan dashboardCertificateResourceModel
+	var state dashboardCertificateResourceModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.setComputed()
+	// Toggling restart_module alone does not restart the dashboard.
+	if !plan.Certificate.Equal(state.Certificate) || !plan.PrivateKey.Equal(state.PrivateKey) {
+		if err := r.apply(&plan); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update dashboard certificate", err)
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Updated dashboard certificate", map[string]interface{}{
+		"mgr_id":    plan.MgrID.ValueString(),
+		"not_after": plan.NotAfter.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *dashboardCertificateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state dashboardCertificateResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The dashboard keeps serving the loaded certificate until the module
+	// restarts.
+	certKey, keyKey := dashboardCertificateKeys(state.MgrID.ValueString())
+	for _, key := range []string{certKey, keyKey} {
+		if err := r.client.RemoveConfigKey(key); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to remove dashboard certificate", err)
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Removed dashboard certificate", map[string]interface{}{
+		"mgr_id": state.MgrID.ValueString(),
+	})
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// Caps each operation needs, keyed by the leading words of the command.
+// The longest matching key wins, so "rbd info" can be looser than "rbd".
+// Grants are the minimum documented for the operation;
+// a restricted grant (pool=, namespace=) covering the object also works.
+var capRequirements = map[string]map[string]string{
+	"ceph status":                           {"mon": "allow r"},
+	"ceph df":                               {"mon": "allow r"},
+	"ceph fsid":                             {"mon": "allow r"},
+	"ceph version":                          {"mon": "allow r"},
+	"ceph versions":                         {"mon": "allow r"},
+	"ceph quorum_status":                    {"mon": "allow r"},
+	"ceph time-sync-status":                 {"mon": "allow r"},
+	"ceph osd dump":                         {"mon": "allow r"},
+	"ceph osd tree":                         {"mon": "allow r"},
+	"ceph osd pool ls":                      {"mon": "allow r"},
+	"ceph osd pool get":                     {"mon": "allow r"},
+	"ceph osd pool create":                  {"mon": "allow rw"},
+	"ceph osd pool application":             {"mon": "allow rw"},
+	"ceph osd pool set":                     {"mon": "allow rw"},
+	"ceph osd pool set-quota":               {"mon": "allow rw"},
+	"ceph osd pool delete":                  {"mon": "allow rw"},
+	"ceph osd pool rename":                  {"mon": "allow rw"},
+	"ceph osd pool mksnap":                  {"mon": "allow rw"},
+	"ceph osd pool rmsnap":                  {"mon": "allow rw"},
+	"ceph pg ls-by-pool":                    {"mon": "allow r", "mgr": "allow r"},
+	"ceph osd tier":                         {"mon": "allow rw"},
+	"ceph osd getcrushmap":                  {"mon": "allow r"},
+	"ceph osd setcrushmap":                  {"mon": "allow rw"},
+	"ceph osd crush rule ls":                {"mon": "allow r"},
+	"ceph osd crush class ls":               {"mon": "allow r"},
+	"ceph osd crush rule create-replicated": {"mon": "allow rw"},
+	"ceph osd crush rule rm":                {"mon": "allow rw"},
+	"ceph osd crush dump":                   {"mon": "allow r"},
+	"ceph osd crush weight-set":             {"mon": "allow rw"},
+	"ceph osd set-nearfull-ratio":           {"mon": "allow rw"},
+	"ceph osd set-backfillfull-ratio":       {"mon": "allow rw"},
+	"ceph osd set-full-ratio":               {"mon": "allow rw"},
+	"ceph config get":                       {"mon": "allow r"},
+	"ceph config dump":                      {"mon": "allow r"},
+	"ceph config set":                       {"mon": "allow rw"},
+	"ceph config rm":                        {"mon": "allow rw"},
+	"ceph config-key ls":                    {"mon": "allow r"},
+	"ceph config-key dump":                  {"mon": "allow r"},
+	"ceph config-key get":                   {"mon": "allow r"},
+	"ceph config-key":                       {"mon": "allow rw"},
+	"ceph tell":                             {"mon": "allow r", "osd": "allow *", "mgr": "allow *"},
+	"ceph log":                              {"mon": "allow rw"},
+	"ceph auth ls":                          {"mon": "allow r"},
+	"ceph auth get":                         {"mon": "allow r"},
+	"ceph auth get-key":                     {"mon": "allow r"},
+	"ceph auth get-or-create":               {"mon": "allow *"},
+	"ceph auth caps":                        {"mon": "allow *"},
+	"ceph auth import":                      {"mon": "allow *"},
+	"ceph auth del":                         {"mon": "allow *"},
+	"ceph mgr module":                       {"mon": "allow rw"},
+	"ceph fs subvolume":                     {"mon": "allow r", "mgr": "allow rw"},
+	"ceph fs snap-schedule":                 {"mon": "allow r", "mgr": "allow rw"},
+	"ceph dashboard":                        {"mon": "allow r", "mgr": "allow *"},
+	"ceph smb":                              {"mon": "allow r", "mgr": "allow *"},
+	"ceph orch ls":                          {"mon": "allow r", "mgr": "allow r"},
+	"ceph orch":                             {"mon": "allow r", "mgr": "allow *"},
+	"rbd ls":                                {"mon": "profile rbd", "osd": "profile rbd-read-only"},
+	"rbd info":                              {"mon": "profile rbd", "osd": "profile rbd-read-only"},
+	"rbd image-meta list":                   {"mon": "profile rbd", "osd": "profile rbd-read-only"},
+	"rbd namespace ls":                      {"mon": "profile rbd", "osd": "profile rbd-read-only"},
+	"rbd trash ls":                          {"mon": "profile rbd", "osd": "profile rbd-read-only"},
+	"rbd":                                   {"mon": "profile rbd", "osd": "profile rbd"},
+	"radosgw-admin":                         {"mon": "allow rw", "osd": "allow rwx"},
+	"rados":                                 {"mon": "allow r", "osd": "allow rwx"},
+}
+
+// requiredCaps returns the operation name and caps needed to run cmd.
+func requiredCaps(cmd string) (string, map[string]string, bool) {
+	best := ""
+	for prefix := range capRequirements {
+		if (cmd == prefix || strings.HasPrefix(cmd, prefix+" ")) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return "", nil, false
+	}
+	return strings.TrimPrefix(best, "ceph "), capRequirements[best], true
+}
+
+// capGrants returns the permission letters and profiles granted by a cap
+// string, e.g. "allow r, profile rbd pool=x" gives "r" and ["rbd"].
+func capGrants(caps string) (perms string, profiles []string, all bool) {
+	for _, grant := range strings.Split(caps, ",") {
+		fields := strings.Fields(grant)
+		for i := 0; i+1 < len(fields); i++ {
+			switch fields[i] {
+			case "allow":
+				if fields[i+1] == "*" || fields[i+1] == "all" {
+					all = true
+				} else {
+					perms += fields[i+1]
+				}
+			case "profile":
+				profiles = append(profiles, fields[i+1])
+				if fields[i+1] == "admin" {
+					all = true
+				}
+			}
+		}
+	}
+	return perms, profiles, all
+}
+
+// capsSatisfy reports whether granted covers needed. It is a conservative
+// approximation of Ceph's cap matching that ignores pool and namespace
+// restrictions.
+func capsSatisfy(granted, needed string) bool {
+	havePerms, haveProfiles, haveAll := capGrants(granted)
+	if haveAll {
+		return true
+	}
+	needPerms, needProfiles, needAll := capGrants(needed)
+	if needAll {
+		return false
+	}
+	for _, p := range needPerms {
+		if !strings.ContainsRune(havePerms, p) {
+			return false
+		}
+	}
+	for _, need := range needProfiles {
+		found := false
+		for _, have := range haveProfiles {
+			// The full rbd profile also covers read-only use.
+			if have == need || (have == "rbd" && need == "rbd-read-only") {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Failure classes worth a dedicated diagnostic
+const (
+	failureAuth         = "auth"
+	failureConnectivity = "connectivity"
+)
+
+func classifyFailure(stderr string) string {
+	s := strings.ToLower(stderr)
+	for _, marker := range []string{"eacces", "permission denied", "access denied", "errno 13", "eperm",
+		"operation not permitted", "handle_auth_bad_method", "unable to find a keyring"} {
+		if strings.Contains(s, marker) {
+			return failureAuth
+		}
+	}
+	for _, marker := range []string{"econnrefused", "connection refused", "etimedout", "timed out", "errno 110",
+		"errno 111", "no route to host", "network is unreachable", "unable to get monitor info",
+		"error connecting to the cluster"} {
+		if strings.Contains(s, marker) {
+			return failureConnectivity
+		}
+	}
+	return ""
+}
+
+// cephAccessError describes a command that failed because the configured
+// user lacks caps or the cluster could not be reached.
+type cephAccessError struct {
+	Kind      string
+	Entity    string
+	Operation string
+	Required  map[string]string
+	Granted   map[string]string
+	Stderr    string
+	Err       error
+
+	// Probed is set when the caps probed at Configure ruled the command
+	// out and it was never run.
+	Probed bool
+}
+
+func (e *cephAccessError) Error() string {
+	if e.Probed {
+		return e.Summary()
+	}
+	return fmt.Sprintf("%s: %s", e.Summary(), strings.TrimSpace(e.Stderr))
+}
+
+func (e *cephAccessError) Unwrap() error {
+	return e.Err
+}
+
+// missing lists the required daemon caps the entity is known not to have.
+func (e *cephAccessError) missing() []string {
+	var daemons []string
+	for daemon, need := range e.Required {
+		if e.Granted == nil || !capsSatisfy(e.Granted[daemon], need) {
+			daemons = append(daemons, daemon)
+		}
+	}
+	sort.Strings(daemons)
+	return daemons
+}
+
+func (e *cephAccessError) Summary() string {
+	if e.Kind == failureConnectivity {
+		return "Cannot reach the Ceph cluster"
+	}
+	if e.Operation != "" {
+		return fmt.Sprintf("%s lacks required caps for %s", e.Entity, e.Operation)
+	}
+	return fmt.Sprintf("Ceph authentication failed for %s", e.Entity)
+}
+
+func (e *cephAccessError) Detail() string {
+	var b strings.Builder
+	if e.Kind == failureConnectivity {
+		b.WriteString("No monitor answered. Check mon_hosts or the mon_host setting in the config file, ")
+		b.WriteString("network access to the monitors (ports 3300/6789) and that a quorum is up.")
+	} else {
+		if e.Operation != "" {
+			fmt.Fprintf(&b, "%s requires:", e.Operation)
+			for _, daemon := range sortedKeys(e.Required) {
+				fmt.Fprintf(&b, "\n  %s '%s'", daemon, e.Required[daemon])
+			}
+			if e.Granted != nil {
+				fmt.Fprintf(&b, "\n%s has:", e.Entity)
+				for _, daemon := range sortedKeys(e.Granted) {
+					fmt.Fprintf(&b, "\n  %s '%s'", daemon, e.Granted[daemon])
+				}
+				if missing := e.missing(); len(missing) > 0 {
+					fmt.Fprintf(&b, "\nMissing or insufficient: %s", strings.Join(missing, ", "))
+				}
+			}
+			b.WriteString("\n")
+		}
+		if e.Probed {
+			b.WriteString("The command was not run; the provider read these caps when it was configured.\n")
+		}
+		b.WriteString("Check that the keyring matches the configured user and grant caps with `ceph auth caps`.")
+	}
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		fmt.Fprintf(&b, "\n\nCommand output: %s", stderr)
+	}
+	return b.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// entity returns the auth entity the provider runs commands as.
+func (c *CephClient) entity() string {
+	switch {
+	case c.User == "":
+		return "client.admin"
+	case strings.Contains(c.User, "."):
+		return c.User
+	default:
+		return "client." + c.User
+	}
+}
+
+// grantedCaps looks up the caps of the configured user. It is best
+// effort: a user without auth read access gets nil back.
+func (c *CephClient) grantedCaps() map[string]string {
+	args, err := NewCommand("ceph", "auth", "get").Arg(c.entity()).Flag("--format", "json").Args()
+	if err != nil {
+		return nil
+	}
+	output, err := c.execute(c.buildCmdArgs(args))
+	if err != nil {
+		return nil
+	}
+
+	var entries []struct {
+		Caps map[string]string `json:"caps"`
+	}
+	if err := json.Unmarshal([]byte(output), &entries); err != nil || len(entries) == 0 {
+		return nil
+	}
+	return entries[0].Caps
+}
+
+// diagnose turns auth and connectivity failures of cmd into a
+// cephAccessError; other failures are returned unchanged.
+func (c *CephClient) diagnose(cmd string, err error) error {
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		return err
+	}
+	stderr := cmdErr.Stderr
+
+	kind := classifyFailure(stderr)
+	if kind == "" {
+		return err
+	}
+
+	accessErr := &cephAccessError{
+		Kind:   kind,
+		Entity: c.entity(),
+		Stderr: stderr,
+		Err:    err,
+	}
+	if kind == failureAuth {
+		if operation, caps, ok := requiredCaps(cmd); ok {
+			accessErr.Operation = operation
+			accessErr.Required = caps
+			accessErr.Granted = c.caps
+			if accessErr.Granted == nil {
+				accessErr.Granted = c.grantedCaps()
+			}
+		}
+	}
+	return accessErr
+}
+
+// addCommandError reports a failed operation. Auth and connectivity
+// failures get their own summary so they are not mistaken for a problem
+// with the resource itself; summary is kept as context in the detail.
+func addCommandError(diags *diag.Diagnostics, summary string, err error) {
+	var accessErr *cephAccessError
+	if errors.As(err, &accessErr) {
+		diags.AddError(accessErr.Summary(), summary+".\n\n"+accessErr.Detail())
+		return
+	}
+	var readOnlyErr *readOnlyError
+	if errors.As(err, &readOnlyErr) {
+		diags.AddError("Provider is read-only", summary+": "+readOnlyErr.Error()+". Unset read_only on the provider to apply changes")
+		return
+	}
+	var releaseErr *unsupportedReleaseError
+	if errors.As(err, &releaseErr) {
+		diags.AddError("Unsupported Ceph release", summary+": "+releaseErr.Error()+". Upgrade the cluster or remove the resource")
+		return
+	}
+	diags.AddError(summary, err.Error())
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Exec wrappers run the Ceph CLI inside a container instead of on the
+// Terraform host: `cephadm shell` on a cephadm host, or `kubectl exec` into
+// the Rook toolbox. Commands go through the same shell script as SSH, so
+// the provider's temp files reach the container. The script is fed to
+// `sh -s` on stdin rather than passed as an argument: it carries inline
+// keyrings and other secret temp files, and arguments show up in ps.
+
+type execWrapperConfig struct {
+	Type      string
+	Namespace string
+	Pod       string
+	Container string
+	Command   []string
+}
+
+// Rook's default toolbox deployment.
+const (
+	defaultRookNamespace = "rook-ceph"
+	defaultRookToolbox   = "deploy/rook-ceph-tools"
+)
+
+// execWrapperPrefix returns the command that the script is appended to.
+func execWrapperPrefix(cfg execWrapperConfig) ([]string, error) {
+	switch cfg.Type {
+	case "cephadm":
+		return []string{"cephadm", "shell", "--"}, nil
+	case "kubectl":
+		namespace, pod := cfg.Namespace, cfg.Pod
+		if namespace == "" {
+			namespace = defaultRookNamespace
+		}
+		if pod == "" {
+			pod = defaultRookToolbox
+		}
+		prefix := []string{"kubectl", "--namespace", namespace, "exec", "-i", pod}
+		if cfg.Container != "" {
+			prefix = append(prefix, "--container", cfg.Container)
+		}
+		return append(prefix, "--"), nil
+	case "custom":
+		if len(cfg.Command) == 0 {
+			return nil, fmt.Errorf("exec_wrapper type \"custom\" needs command")
+		}
+		return cfg.Command, nil
+	default:
+		return nil, fmt.Errorf("exec_wrapper type must be \"cephadm\", \"kubectl\" or \"custom\", got %q", cfg.Type)
+	}
+}
+
+type execWrapperRunner struct {
+	prefix []string
+}
+
+func newExecWrapperRunner(prefix []string) commandRunner {
+	r := &execWrapperRunner{prefix: prefix}
+	return r.run
+}
+
+func (r *execWrapperRunner) run(ctx context.Context, args []string) (string, error) {
+	script, outputs, err := remoteScript(args)
+	if err != nil {
+		return "", err
+	}
+
+	words := append(append([]string{}, r.prefix[1:]...), "sh", "-s")
+	cmd := exec.CommandContext(ctx, r.prefix[0], words...)
+	cmd.Stdin = strings.NewReader(script)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return splitRemoteOutput(string(out), outputs)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Reads. The text output of the Ceph tools changes between releases and
+// with the locale, so state is only ever read from JSON: every read goes
+// through ReadJSON, which selects JSON output and checks that the command
+// printed JSON, or ExecuteJSON, which also decodes it. A command that
+// ignores --format fails here instead of being parsed by guesswork.
+//
+// ReadText is the one fallback, for the few reads that have no JSON
+// output: `ceph auth get-key`, `ceph config-key get`, `radosgw-admin sync
+// status` and `ceph tell <daemons> config get` with a wildcard target.
+
+// ReadJSON runs a read command with --format json and returns its output,
+// which is valid JSON.
+func (c *CephClient) ReadJSON(cmd *CommandBuilder) (string, error) {
+	if !cmd.hasFormat() {
+		cmd.Flag("--format", "json")
+	}
+	output, err := c.ExecuteCommand(cmd)
+	if err != nil {
+		return "", err
+	}
+	if !json.Valid([]byte(output)) {
+		return "", fmt.Errorf("`%s` did not print JSON: %q", cmd, truncateOutput(output))
+	}
+	return output, nil
+}
+
+// ExecuteJSON runs a read command with --format json and decodes its output
+// into out.
+func (c *CephClient) ExecuteJSON(cmd *CommandBuilder, out interface{}) error {
+	output, err := c.ReadJSON(cmd)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(output), out); err != nil {
+		return fmt.Errorf("failed to parse output of `%s`: %w", cmd, err)
+	}
+	return nil
+}
+
+// ReadText runs a read command that has no JSON output and returns its
+// output as printed.
+func (c *CephClient) ReadText(cmd *CommandBuilder) (string, error) {
+	return c.ExecuteCommand(cmd)
+}
+
+// truncateOutput shortens command output quoted in an error.
+func truncateOutput(output string) string {
+	const max = 200
+	output = strings.TrimSpace(output)
+	if len(output) > max {
+		return output[:max] + "..."
+	}
+	return output
+}
+
+// hasFormat reports whether the command already selects an output format.
+func (b *CommandBuilder) hasFormat() bool {
+	for i, arg := range b.args {
+		if (arg == "--format" || arg == "-f") && i+1 < len(b.args) || strings.HasPrefix(arg, "--format=") {
+			return true
+		}
+	}
+	return false
+}
+
+// Pool settings as reported by `ceph osd pool get <pool> all --format json`.
+// Settings that do not apply to the pool are left out of the output.
+type poolSettings struct {
+	Pool      string `json:"pool"`
+	PoolID    int64  `json:"pool_id"`
+	Size      int64  `json:"size"`
+	MinSize   int64  `json:"min_size"`
+	PgNum     int64  `json:"pg_num"`
+	PgpNum    int64  `json:"pgp_num"`
+	CrushRule string `json:"crush_rule"`
+
+	PgAutoscaleMode string `json:"pg_autoscale_mode"`
+	// Targets are only reported once set.
+	TargetSizeBytes int64   `json:"target_size_bytes"`
+	TargetSizeRatio float64 `json:"target_size_ratio"`
+
+	Bulk bool `json:"bulk"`
+	// Intervals are in seconds and, like recovery_priority, only reported
+	// once set.
+	ScrubMinInterval  float64 `json:"scrub_min_interval"`
+	ScrubMaxInterval  float64 `json:"scrub_max_interval"`
+	DeepScrubInterval float64 `json:"deep_scrub_interval"`
+	RecoveryPriority  int64   `json:"recovery_priority"`
+
+	// Only erasure-coded pools have these.
+	ErasureCodeProfile string `json:"erasure_code_profile"`
+	AllowECOverwrites  bool   `json:"allow_ec_overwrites"`
+
+	NoDelete bool `json:"nodelete"`
+}
+
+// TypeName returns the pool type as the pool resource names it.
+func (s *poolSettings) TypeName() string {
+	if s.ErasureCodeProfile != "" {
+		return "erasure"
+	}
+	return "replicated"
+}
+
+// SetPoolNoDelete sets or clears the pool's nodelete flag, with which the
+// monitors refuse to delete the pool whatever mon_allow_pool_delete says.
+func (c *CephClient) SetPoolNoDelete(name string, noDelete bool) error {
+	_, err := c.ExecuteCommand(NewCommand("ceph", "osd", "pool", "set").Arg(name, "nodelete", strconv.FormatBool(noDelete)))
+	return err
+}
+
+// AllowECOverwrites enables partial writes to an erasure-coded pool. Ceph
+// cannot disable them again.
+func (c *CephClient) AllowECOverwrites(name string) error {
+	_, err := c.ExecuteCommand(NewCommand("ceph", "osd", "pool", "set").Arg(name, "allow_ec_overwrites", "true"))
+	return err
+}
+
+// GetPoolSettings returns the settings of the named pool.
+func (c *CephClient) GetPoolSettings(name string) (*poolSettings, error) {
+	var settings poolSettings
+	if err := c.ExecuteJSON(NewCommand("ceph", "osd", "pool", "get").Arg(name, "all"), &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
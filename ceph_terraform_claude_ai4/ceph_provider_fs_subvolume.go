@@ -0,0 +1,540 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// CephFS subvolumes, the unit of a share for Manila and the CSI driver.
+// Each client of a share gets its own cephx user, limited to the one
+// subvolume with r or rw access, through `ceph fs subvolume authorize`.
+
+// subvolumeAuth identifies a subvolume and, optionally, its group.
+type subvolumeAuth struct {
+	Volume    string
+	Subvolume string
+	Group     string
+}
+
+func (s subvolumeAuth) command(words ...string) *CommandBuilder {
+	return NewCommand(append([]string{"ceph", "fs", "subvolume"}, words...)...).Arg(s.Volume, s.Subvolume)
+}
+
+func (s subvolumeAuth) withGroup(cmd *CommandBuilder) *CommandBuilder {
+	if s.Group != "" {
+		cmd.Option("--group_name", s.Group)
+	}
+	return cmd
+}
+
+// String returns the subvolume as volume/group/name, or volume/name
+// outside a group.
+func (s subvolumeAuth) String() string {
+	if s.Group == "" {
+		return s.Volume + "/" + s.Subvolume
+	}
+	return s.Volume + "/" + s.Group + "/" + s.Subvolume
+}
+
+// CreateSubvolume creates the subvolume with a quota of size bytes, or
+// none when size is 0.
+func (c *CephClient) CreateSubvolume(sub subvolumeAuth, size int64) error {
+	cmd := NewCommand("ceph", "fs", "subvolume", "create").Arg(sub.Volume, sub.Subvolume)
+	if size > 0 {
+		cmd.Option("--size", strconv.FormatInt(size, 10))
+	}
+	_, err := c.ExecuteCommand(sub.withGroup(cmd))
+	return err
+}
+
+// ResizeSubvolume sets the subvolume's quota to size bytes, or removes it
+// when size is 0.
+func (c *CephClient) ResizeSubvolume(sub subvolumeAuth, size int64) error {
+	newSize := "inf"
+	if size > 0 {
+		newSize = strconv.FormatInt(size, 10)
+	}
+	_, err := c.ExecuteCommand(sub.withGroup(sub.command("resize").Arg(newSize)))
+	return err
+}
+
+// RemoveSubvolume removes the subvolume and its data.
+func (c *CephClient) RemoveSubvolume(sub subvolumeAuth) error {
+	_, err := c.ExecuteCommand(sub.withGroup(sub.command("rm")).Confirmed())
+	return err
+}
+
+// subvolumeInfo is the part of `ceph fs subvolume info` the provider uses.
+// bytes_quota is a number, or "infinite" without a quota.
+type subvolumeInfo struct {
+	Path       string          `json:"path"`
+	BytesQuota json.RawMessage `json:"bytes_quota"`
+}
+
+// Quota returns the subvolume's quota in bytes, 0 for none.
+func (i *subvolumeInfo) Quota() int64 {
+	n, err := strconv.ParseInt(string(i.BytesQuota), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// SubvolumeInfo returns the subvolume's path and quota, or nil when the
+// subvolume does not exist.
+func (c *CephClient) SubvolumeInfo(sub subvolumeAuth) (*subvolumeInfo, error) {
+	var names []struct {
+		Name string `json:"name"`
+	}
+	if err := c.ExecuteJSON(sub.withGroup(NewCommand("ceph", "fs", "subvolume", "ls").Arg(sub.Volume)), &names); err != nil {
+		return nil, err
+	}
+	found := false
+	for _, n := range names {
+		found = found || n.Name == sub.Subvolume
+	}
+	if !found {
+		return nil, nil
+	}
+
+	var info subvolumeInfo
+	if err := c.ExecuteJSON(sub.withGroup(sub.command("info")), &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// AuthorizeSubvolume grants authID access to the subvolume and returns the
+// user's key. accessLevel is "r" or "rw".
+func (c *CephClient) AuthorizeSubvolume(sub subvolumeAuth, authID, accessLevel string) (string, error) {
+	cmd := sub.withGroup(sub.command("authorize").Arg(authID)).Option("--access_level", accessLevel)
+	output, err := c.ExecuteCommand(cmd)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// DeauthorizeSubvolume revokes authID's access to the subvolume.
+func (c *CephClient) DeauthorizeSubvolume(sub subvolumeAuth, authID string) error {
+	_, err := c.ExecuteCommand(sub.withGroup(sub.command("deauthorize").Arg(authID)))
+	return err
+}
+
+// SubvolumeAuthorizedClients returns the access level of each client
+// authorized on the subvolume, keyed by auth id.
+func (c *CephClient) SubvolumeAuthorizedClients(sub subvolumeAuth) (map[string]string, error) {
+	// The list holds one single-key object per client, e.g. [{"alice": "rw"}].
+	var entries []map[string]string
+	if err := c.ExecuteJSON(sub.withGroup(sub.command("authorized_list")), &entries); err != nil {
+		return nil, err
+	}
+	clients := make(map[string]string)
+	for _, entry := range entries {
+		for id, level := range entry {
+			clients[id] = level
+		}
+	}
+	return clients, nil
+}
+
+// CephFS Subvolume Resource
+type fsSubvolumeResource struct {
+	client *CephClient
+}
+
+type fsSubvolumeResourceModel struct {
+	ID                types.String                    `tfsdk:"id"`
+	Volume            types.String                    `tfsdk:"volume"`
+	Name              types.String                    `tfsdk:"name"`
+	Group             types.String                    `tfsdk:"group"`
+	Size              sizeValue                       `tfsdk:"size"`
+	Path              types.String                    `tfsdk:"path"`
+	AuthorizedClients map[string]subvolumeClientModel `tfsdk:"authorized_clients"`
+	ConfirmDataLoss   types.Bool                      `tfsdk:"confirm_data_loss"`
+}
+
+type subvolumeClientModel struct {
+	AccessLevel types.String `tfsdk:"access_level"`
+	Key         types.String `tfsdk:"key"`
+}
+
+func (m *fsSubvolumeResourceModel) subvolume() subvolumeAuth {
+	return subvolumeAuth{Volume: m.Volume.ValueString(), Subvolume: m.Name.ValueString(), Group: m.Group.ValueString()}
+}
+
+func NewFSSubvolumeResource() resource.Resource {
+	return &fsSubvolumeResource{}
+}
+
+func (r *fsSubvolumeResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_fs_subvolume"
+}
+
+func (r *fsSubvolumeResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages a CephFS subvolume and the clients authorized to mount it",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Subvolume in volume/group/name form, or volume/name outside a group"),
+			"volume": schema.StringAttribute{
+				Description: "CephFS volume (file system) name",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Description: "Subvolume name",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"group": schema.StringAttribute{
+				Description: "Subvolume group (default: the volume's default group)",
+				Optional:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"size": schema.StringAttribute{
+				Description: "Quota, in bytes or with a unit such as 100G (unlimited when unset)",
+				Optional:    true,
+				CustomType:  sizeType{},
+			},
+			"confirm_data_loss": schema.BoolAttribute{
+				Description: "Confirm that destroying the subvolume deletes its data; destroy fails unless this is true in state",
+				Optional:    true,
+			},
+			"path": schema.StringAttribute{
+				Description: "Path of the subvolume inside the volume, for mounting",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"authorized_clients": schema.MapNestedAttribute{
+				Description: "Clients allowed to mount the subvolume, keyed by auth id (the entity name without client.). Each gets a cephx user limited to this subvolume",
+				Optional:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"access_level": schema.StringAttribute{
+							Description: "\"r\" or \"rw\" (default: rw)",
+							Optional:    true,
+							Computed:    true,
+							Default:     stringdefault.StaticString("rw"),
+						},
+						"key": schema.StringAttribute{
+							Description: "The client's cephx key",
+							Computed:    true,
+							Sensitive:   true,
+							PlanModifiers: []planmodifier.String{
+								stringplanmodifier.UseStateForUnknown(),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *fsSubvolumeResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+// ModifyPlan refuses an unconfirmed destroy, and plans a new key for
+// clients whose access_level changes. The level is changed by revoking and
+// granting the client again, and revoking removes the cephx user when it
+// has no other subvolume, so the key may change.
+func (r *fsSubvolumeResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		var state fsSubvolumeResourceModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if !resp.Diagnostics.HasError() && !state.ConfirmDataLoss.ValueBool() {
+			resp.Diagnostics.AddError("Subvolume deletion not confirmed", subvolumeDeletionNotConfirmed(state.subvolume()))
+		}
+		return
+	}
+	if req.State.Raw.IsNull() {
+		return
+	}
+	var plan, state fsSubvolumeResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for id, client := range plan.AuthorizedClients {
+		prev, ok := state.AuthorizedClients[id]
+		if !ok || prev.AccessLevel.Equal(client.AccessLevel) {
+			continue
+		}
+		keyPath := path.Root("authorized_clients").AtMapKey(id).AtName("key")
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, keyPath, types.StringUnknown())...)
+	}
+}
+
+func (r *fsSubvolumeResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config fsSubvolumeResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for id, client := range config.AuthorizedClients {
+		if strings.HasPrefix(id, "client.") || strings.Contains(id, ".") {
+			resp.Diagnostics.AddAttributeError(path.Root("authorized_clients").AtMapKey(id), "Invalid auth id",
+				fmt.Sprintf("Auth ids are entity names without the client. prefix and must not contain '.', got %q", id))
+		}
+		level := client.AccessLevel.ValueString()
+		if !client.AccessLevel.IsNull() && !client.AccessLevel.IsUnknown() && level != "r" && level != "rw" {
+			resp.Diagnostics.AddAttributeError(path.Root("authorized_clients").AtMapKey(id).AtName("access_level"), "Invalid access level",
+				fmt.Sprintf("access_level must be \"r\" or \"rw\", got %q", level))
+		}
+	}
+}
+
+// authorize grants each planned client its access and records its key.
+// Clients whose access level changed are deauthorized first, as authorize
+// does not change the level of an existing grant.
+func (r *fsSubvolumeResource) authorize(plan *fsSubvolumeResourceModel, current map[string]subvolumeClientModel) error {
+	sub := plan.subvolume()
+	ids := make([]string, 0, len(plan.AuthorizedClients))
+	for id := range plan.AuthorizedClients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		client := plan.AuthorizedClients[id]
+		prev, ok := current[id]
+		if ok && prev.AccessLevel.Equal(client.AccessLevel) && !prev.Key.IsNull() {
+			client.Key = prev.Key
+			plan.AuthorizedClients[id] = client
+			continue
+		}
+		if ok {
+			if err := r.client.DeauthorizeSubvolume(sub, id); err != nil {
+				return err
+			}
+		}
+		key, err := r.client.AuthorizeSubvolume(sub, id, client.AccessLevel.ValueString())
+		if err != nil {
+			return err
+		}
+		client.Key = types.StringValue(key)
+		plan.AuthorizedClients[id] = client
+	}
+	return nil
+}
+
+// deauthorize revokes the clients that are no longer planned.
+func (r *fsSubvolumeResource) deauthorize(sub subvolumeAuth, planned, current map[string]subvolumeClientModel) error {
+	ids := make([]string, 0, len(current))
+	for id := range current {
+		if _, ok := planned[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := r.client.DeauthorizeSubvolume(sub, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fsSubvolumeResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan fsSubvolumeResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sub := plan.subvolume()
+	if err := r.client.CreateSubvolume(sub, optionalSizeQuota(plan.Size)); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create subvolume", err)
+		return
+	}
+	info, err := r.client.SubvolumeInfo(sub)
+	if err == nil && info == nil {
+		err = fmt.Errorf("subvolume %s not found after creation", sub)
+	}
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read created subvolume", err)
+		return
+	}
+	plan.ID = types.StringValue(sub.String())
+	plan.Path = types.StringValue(info.Path)
+
+	if len(plan.AuthorizedClients) > 0 {
+		// Record the subvolume before authorizing clients; see
+		// setPartialState.
+		pending := plan
+		pending.AuthorizedClients = nil
+		resp.Diagnostics.Append(setPartialState(ctx, &resp.State, pending)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		if err := r.authorize(&plan, nil); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to authorize subvolume client", err)
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Created CephFS subvolume", map[string]interface{}{
+		"subvolume": plan.ID.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *fsSubvolumeResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state fsSubvolumeResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sub := state.subvolume()
+	info, err := r.client.SubvolumeInfo(sub)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read subvolume", err)
+		return
+	}
+	if info == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	state.ID = types.StringValue(sub.String())
+	state.Path = types.StringValue(info.Path)
+	if quota := info.Quota(); quota > 0 {
+		state.Size = sizeBytes(quota)
+	} else {
+		state.Size = sizeNull()
+	}
+
+	// Only refreshed when set, so clients authorized outside Terraform,
+	// e.g. by Manila, are left alone on unmanaged subvolumes.
+	if state.AuthorizedClients != nil {
+		levels, err := r.client.SubvolumeAuthorizedClients(sub)
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to read subvolume clients", err)
+			return
+		}
+		clients := make(map[string]subvolumeClientModel, len(levels))
+		for id, level := range levels {
+			client := state.AuthorizedClients[id]
+			client.AccessLevel = types.StringValue(level)
+			key, err := r.client.GetAuthKey("client." + id)
+			if err != nil {
+				addCommandError(&resp.Diagnostics, "Failed to read subvolume client key", err)
+				return
+			}
+			client.Key = types.StringValue(key)
+			clients[id] = client
+		}
+		state.AuthorizedClients = clients
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *fsSubvolumeResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan fsSubvolumeResourceModel
+	var state fsSubvolumeResourceModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sub := plan.subvolume()
+	if optionalSizeQuota(plan.Size) != optionalSizeQuota(state.Size) {
+		if err := r.client.ResizeSubvolume(sub, optionalSizeQuota(plan.Size)); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to resize subvolume", err)
+			return
+		}
+	}
+
+	if err := r.deauthorize(sub, plan.AuthorizedClients, state.AuthorizedClients); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to deauthorize subvolume client", err)
+		return
+	}
+	if err := r.authorize(&plan, state.AuthorizedClients); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to authorize subvolume client", err)
+		return
+	}
+
+	plan.ID = types.StringValue(sub.String())
+	plan.Path = state.Path
+
+	tflog.Info(ctx, "Updated CephFS subvolume", map[string]interface{}{
+		"subvolume": plan.ID.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *fsSubvolumeResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state fsSubvolumeResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Checked again here as a replacement plans no destroy of its own.
+	sub := state.subvolume()
+	if !state.ConfirmDataLoss.ValueBool() {
+		resp.Diagnostics.AddError("Subvolume deletion not confirmed", subvolumeDeletionNotConfirmed(sub))
+		return
+	}
+	if err := r.deauthorize(sub, nil, state.AuthorizedClients); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to deauthorize subvolume client", err)
+		return
+	}
+	if err := r.client.RemoveSubvolume(sub); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove subvolume", err)
+		return
+	}
+
+	tflog.Info(ctx, "Removed CephFS subvolume", map[string]interface{}{
+		"subvolume": sub.String(),
+	})
+}
+
+// subvolumeDeletionNotConfirmed explains how to confirm destroying a
+// subvolume.
+func subvolumeDeletionNotConfirmed(sub subvolumeAuth) string {
+	return fmt.Sprintf("Destroying subvolume %s deletes all of its data. Set confirm_data_loss = true on the subvolume "+
+		"and apply it before destroying the subvolume.", sub)
+}
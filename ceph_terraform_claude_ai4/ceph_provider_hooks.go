@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Resource hooks: post_create_commands and pre_destroy_commands. Each
+// command is a shell script run with `sh -c` through the provider's
+// transport, so with an ssh block it runs on the admin node, and it is
+// subject to command_timeout, the command record and the audit log like
+// any other command. The provider's connection options (--conf, --keyring,
+// --user, ...) are the script's arguments, so `rbd bench "$@" ...` talks
+// to the same cluster as the provider. Hooks change things, so read-only
+// mode refuses them.
+
+// hookAttributes returns the hook attributes, added to the schema of each
+// resource that supports hooks.
+func hookAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"post_create_commands": schema.ListAttribute{
+			Description: "Shell commands run in order after the resource is created, e.g. a smoke test; \"$@\" holds the provider's connection options",
+			ElementType: types.StringType,
+			Optional:    true,
+		},
+		"pre_destroy_commands": schema.ListAttribute{
+			Description: "Shell commands run in order before the resource is destroyed; a failure stops the destroy",
+			ElementType: types.StringType,
+			Optional:    true,
+		},
+		"post_create_output": schema.ListAttribute{
+			Description: "Output of each post_create_commands entry",
+			ElementType: types.StringType,
+			Computed:    true,
+			PlanModifiers: []planmodifier.List{
+				listplanmodifier.UseStateForUnknown(),
+			},
+		},
+	}
+}
+
+// withHookAttributes adds the hook attributes to a resource's attributes.
+func withHookAttributes(attributes map[string]schema.Attribute) map[string]schema.Attribute {
+	for name, attribute := range hookAttributes() {
+		attributes[name] = attribute
+	}
+	return attributes
+}
+
+// RunHook runs a hook script through the provider's transport.
+func (c *CephClient) RunHook(script string) (string, error) {
+	if strings.ContainsRune(script, 0) {
+		return "", fmt.Errorf("hook command %q contains a NUL byte", script)
+	}
+	// "ceph-hook" becomes $0; the connection options follow as $1...
+	cmd := NewCommand("sh", "-c", script, "ceph-hook")
+	if err := c.checkReadOnly(cmd); err != nil {
+		return "", err
+	}
+	if err := c.checkFSID(); err != nil {
+		return "", err
+	}
+	args, err := cmd.Args()
+	if err != nil {
+		return "", err
+	}
+	return c.execute(c.buildCmdArgs(args))
+}
+
+// runHooks runs the hook scripts in order and returns their output. It
+// stops at the first failure.
+func (c *CephClient) runHooks(ctx context.Context, scripts types.List, kind string) (types.List, error) {
+	var commands []string
+	if !scripts.IsNull() && !scripts.IsUnknown() {
+		if diags := scripts.ElementsAs(ctx, &commands, false); diags.HasError() {
+			return types.ListNull(types.StringType), fmt.Errorf("invalid %s", kind)
+		}
+	}
+	if len(commands) == 0 {
+		return types.ListNull(types.StringType), nil
+	}
+
+	outputs := make([]string, 0, len(commands))
+	for i, script := range commands {
+		output, err := c.RunHook(script)
+		if err != nil {
+			return types.ListNull(types.StringType), fmt.Errorf("%s[%d] failed: %w", kind, i, err)
+		}
+		outputs = append(outputs, strings.TrimRight(output, "\n"))
+	}
+	list, diags := types.ListValueFrom(ctx, types.StringType, outputs)
+	if diags.HasError() {
+		return types.ListNull(types.StringType), fmt.Errorf("failed to record %s output", kind)
+	}
+	return list, nil
+}
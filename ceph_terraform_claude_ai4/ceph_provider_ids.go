@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+
+	dsschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+)
+
+// Every resource and data source exposes a computed `id` so modules can
+// reference objects by a single canonical string. IDs are derived from the
+// object's identifying attributes, never from cluster-assigned values, so
+// they are known as soon as the object is created and stay stable across
+// refreshes.
+
+// IDs of cluster-wide singletons
+const (
+	crushMapID      = "crush_map"
+	osdFullRatiosID = "osd_full_ratios"
+)
+
+func resourceIDAttribute(description string) schema.StringAttribute {
+	return schema.StringAttribute{
+		Description: description,
+		Computed:    true,
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.UseStateForUnknown(),
+		},
+	}
+}
+
+func dataSourceIDAttribute(description string) dsschema.StringAttribute {
+	return dsschema.StringAttribute{
+		Description: description,
+		Computed:    true,
+	}
+}
+
+// blockImageID returns the pool/image spec used by rbd.
+func blockImageID(pool, image string) string {
+	return pool + "/" + image
+}
+
+// dashboardCertificateID identifies the dashboard certificate of all
+// managers, or of one manager.
+func dashboardCertificateID(mgrID string) string {
+	if mgrID == "" {
+		return "dashboard_certificate"
+	}
+	return "dashboard_certificate/" + mgrID
+}
+
+// configOptionID identifies an option set for a config target or daemon.
+func configOptionID(target, name string) string {
+	return target + "/" + name
+}
+
+// listID identifies a plural data source by kind and the scope it lists,
+// e.g. block_images/rbd. Empty scope parts are skipped.
+func listID(kind string, scope ...string) string {
+	parts := []string{kind}
+	for _, s := range scope {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// Inline credentials. The key set with `key` or `key_secret` is never put
+// on a command line, where other users could see it in the process list;
+// each command gets a keyring file readable only by the provider, removed
+// once the command returns. Being a temp file, it is also shipped along
+// with commands run over SSH or through an exec wrapper.
+
+// configureInlineKey sets the client's key from `key` or `key_secret`.
+func configureInlineKey(config *cephProviderModel, client *CephClient) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	key := config.Key.ValueString()
+	keySecret := config.KeySecret.ValueString()
+	switch {
+	case key != "" && keySecret != "":
+		diags.AddAttributeError(path.Root("key_secret"), "Conflicting credentials", "Set either key or key_secret, not both")
+		return diags
+	case (key != "" || keySecret != "") && client.Keyring != "":
+		diags.AddAttributeError(path.Root("keyring"), "Conflicting credentials",
+			"keyring cannot be combined with key or key_secret; the inline key replaces the keyring file")
+		return diags
+	case keySecret != "":
+		var err error
+		key, err = keyFromKeyring(keySecret, client.entity())
+		if err != nil {
+			diags.AddAttributeError(path.Root("key_secret"), "Invalid key_secret", err.Error())
+			return diags
+		}
+	}
+	client.Key = key
+	return diags
+}
+
+// keyFromKeyring returns the key of entity from keyring text, as printed
+// by `ceph auth get`.
+func keyFromKeyring(keyring, entity string) (string, error) {
+	var section string
+	var entities []string
+	for _, line := range strings.Split(keyring, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			entities = append(entities, section)
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if ok && section == entity && strings.TrimSpace(name) == "key" {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	if len(entities) == 0 {
+		return "", fmt.Errorf("key_secret is not a keyring; expected a [%s] section with a key", entity)
+	}
+	return "", fmt.Errorf("key_secret has no key for %s; it contains %s", entity, strings.Join(entities, ", "))
+}
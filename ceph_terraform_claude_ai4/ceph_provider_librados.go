@@ -0,0 +1,213 @@
+//go:build librados
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ceph/go-ceph/rados"
+)
+
+// libradosRunner executes `ceph` commands through librados instead of the
+// CLI. It connects on first use, like the CLI would, so a plan that never
+// touches the cluster does not need it reachable. A failed connect is
+// retried by the next command, as a fresh CLI process would; once
+// connected, the connection is kept for the rest of the run.
+type libradosRunner struct {
+	cluster    string
+	configFile string
+	keyring    string
+	key        string
+	bootstrap  bool
+	user       string
+	monHosts   []string
+	timeout    time.Duration
+
+	mu   sync.Mutex
+	conn *rados.Conn
+	sigs []monCommandSig
+}
+
+func newLibradosRunner(client *CephClient) (commandRunner, error) {
+	r := &libradosRunner{
+		cluster:    client.Cluster,
+		configFile: client.ConfigFile,
+		keyring:    client.Keyring,
+		key:        client.Key,
+		bootstrap:  client.Bootstrap,
+		user:       client.User,
+		monHosts:   client.MonHosts,
+		timeout:    client.Timeout,
+	}
+	return r.run, nil
+}
+
+// connection returns the cluster connection, connecting if there is none
+// yet.
+func (r *libradosRunner) connection() (*rados.Conn, []monCommandSig, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		if err := r.connect(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return r.conn, r.sigs, nil
+}
+
+// connect opens a connection and loads the command descriptions. r.conn is
+// only set once both succeeded, so a failure leaves the runner to try again.
+func (r *libradosRunner) connect() error {
+	var conn *rados.Conn
+	var err error
+	user := strings.TrimPrefix(r.user, "client.")
+	switch {
+	case r.cluster != "":
+		if user == "" {
+			user = "admin"
+		}
+		conn, err = rados.NewConnWithClusterAndUser(r.cluster, "client."+user)
+	case user != "":
+		conn, err = rados.NewConnWithUser(user)
+	default:
+		conn, err = rados.NewConn()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create librados connection: %w", err)
+	}
+
+	// With bootstrap_keyring there is no config file; mon_host and key
+	// below are all librados needs.
+	switch {
+	case r.bootstrap:
+	case r.configFile != "":
+		err = conn.ReadConfigFile(r.configFile)
+	default:
+		err = conn.ReadDefaultConfigFile()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read Ceph config: %w", err)
+	}
+	if r.keyring != "" {
+		if err := conn.SetConfigOption("keyring", r.keyring); err != nil {
+			return fmt.Errorf("failed to set keyring: %w", err)
+		}
+	}
+	if r.key != "" {
+		if err := conn.SetConfigOption("key", r.key); err != nil {
+			return fmt.Errorf("failed to set key: %w", err)
+		}
+	}
+	// librados fails over between the listed monitors itself.
+	if len(r.monHosts) > 0 {
+		if err := conn.SetConfigOption("mon_host", strings.Join(r.monHosts, ",")); err != nil {
+			return fmt.Errorf("failed to set mon_host: %w", err)
+		}
+	}
+	// librados calls cannot be interrupted, so command_timeout is passed
+	// on as its operation timeouts instead.
+	if r.timeout > 0 {
+		seconds := fmt.Sprint(int64(math.Ceil(r.timeout.Seconds())))
+		for _, option := range []string{"client_mount_timeout", "rados_mon_op_timeout", "rados_osd_op_timeout"} {
+			if err := conn.SetConfigOption(option, seconds); err != nil {
+				return fmt.Errorf("failed to set %s: %w", option, err)
+			}
+		}
+	}
+	if err := conn.Connect(); err != nil {
+		return &commandStatusError{Status: "error connecting to the cluster", Err: err}
+	}
+
+	sigs, err := loadCommandDescriptions(conn)
+	if err != nil {
+		conn.Shutdown()
+		return err
+	}
+	r.conn, r.sigs = conn, sigs
+	return nil
+}
+
+// loadCommandDescriptions asks the monitors and the manager which commands
+// they accept.
+func loadCommandDescriptions(conn *rados.Conn) ([]monCommandSig, error) {
+	describe := []byte(`{"prefix": "get_command_descriptions"}`)
+	monOut, status, err := conn.MonCommand(describe)
+	if err != nil {
+		return nil, &commandStatusError{Status: status, Err: err}
+	}
+	monSigs, err := parseCommandDescriptions(string(monOut), false)
+	if err != nil {
+		return nil, err
+	}
+	mgrOut, status, err := conn.MgrCommand([][]byte{describe})
+	if err != nil {
+		return nil, &commandStatusError{Status: status, Err: err}
+	}
+	mgrSigs, err := parseCommandDescriptions(string(mgrOut), true)
+	if err != nil {
+		return nil, err
+	}
+	return append(monSigs, mgrSigs...), nil
+}
+
+func (r *libradosRunner) run(ctx context.Context, args []string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	inv := splitCLIInvocation(args)
+	if len(inv.Words) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+	if inv.Words[0] != "ceph" {
+		return "", fmt.Errorf("%s is not available with connection_mode = \"librados\"; install the Ceph CLI and use connection_mode = \"cli\"",
+			inv.Words[0])
+	}
+
+	conn, sigs, err := r.connection()
+	if err != nil {
+		return "", err
+	}
+
+	cmd, mgr, err := buildMonCommand(sigs, inv.Words[1:])
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode command: %w", err)
+	}
+
+	var input []byte
+	if inv.InputFile != "" {
+		if input, err = os.ReadFile(inv.InputFile); err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", inv.InputFile, err)
+		}
+	}
+
+	var out []byte
+	var status string
+	if mgr {
+		out, status, err = conn.MgrCommandWithInputBuffer([][]byte{body}, input)
+	} else {
+		out, status, err = conn.MonCommandWithInputBuffer(body, input)
+	}
+	if err != nil {
+		return "", &commandStatusError{Status: status, Err: err}
+	}
+
+	if inv.OutputFile != "" {
+		if err := os.WriteFile(inv.OutputFile, out, 0600); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", inv.OutputFile, err)
+		}
+		return "", nil
+	}
+	return string(out), nil
+}
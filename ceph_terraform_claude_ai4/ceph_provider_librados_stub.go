@@ -0,0 +1,12 @@
+//go:build !librados
+
+package main
+
+import "fmt"
+
+// newLibradosRunner is replaced by the librados build; the default build
+// has no cgo dependency on the Ceph client libraries.
+func newLibradosRunner(client *CephClient) (commandRunner, error) {
+	return nil, fmt.Errorf("this provider was built without librados support; rebuild it with `go build -tags librados` " +
+		"on a host with the librados development headers, or use connection_mode = \"cli\"")
+}
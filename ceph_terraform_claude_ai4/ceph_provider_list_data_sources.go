@@ -0,0 +1,722 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// List filtering shared by the plural data sources. Results are always
+// sorted so that for_each over them is stable between plans.
+func listFilterAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"id": dataSourceIDAttribute("Kind and scope of the listing, e.g. block_images/rbd"),
+		"name_regex": schema.StringAttribute{
+			Description: "Only return names matching this regular expression",
+			Optional:    true,
+		},
+		"limit": schema.Int64Attribute{
+			Description: "Maximum number of names to return after sorting and filtering",
+			Optional:    true,
+		},
+	}
+}
+
+func filterNames(names []string, nameRegex types.String, limit types.Int64) ([]string, error) {
+	var re *regexp.Regexp
+	if !nameRegex.IsNull() && nameRegex.ValueString() != "" {
+		var err error
+		re, err = regexp.Compile(nameRegex.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("invalid name_regex: %w", err)
+		}
+	}
+
+	if !limit.IsNull() && limit.ValueInt64() < 0 {
+		return nil, fmt.Errorf("limit must not be negative, got %d", limit.ValueInt64())
+	}
+
+	filtered := make([]string, 0, len(names))
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if re != nil && !re.MatchString(name) {
+			continue
+		}
+		filtered = append(filtered, name)
+	}
+	sort.Strings(filtered)
+
+	if !limit.IsNull() && int64(len(filtered)) > limit.ValueInt64() {
+		filtered = filtered[:limit.ValueInt64()]
+	}
+	return filtered, nil
+}
+
+func withListFilterAttributes(attrs map[string]schema.Attribute) map[string]schema.Attribute {
+	for name, attr := range listFilterAttributes() {
+		attrs[name] = attr
+	}
+	return attrs
+}
+
+// withDetailsAttributes adds with_details and the computed details list.
+// With details requested, a data source switches to a listing command that
+// returns every object's attributes in one call, so modules that need them
+// for every name avoid one singular data source (and one command) per
+// object.
+func withDetailsAttributes(attrs map[string]schema.Attribute, description string, detail map[string]schema.Attribute) map[string]schema.Attribute {
+	attrs["with_details"] = schema.BoolAttribute{
+		Description: "Also populate details, using a single detailed listing command",
+		Optional:    true,
+	}
+	attrs["details"] = schema.ListNestedAttribute{
+		Description: description + ", in the same order as names; only set when with_details is true",
+		Computed:    true,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: detail,
+		},
+	}
+	return attrs
+}
+
+// Pools Data Source
+type poolsDataSource struct {
+	client *CephClient
+}
+
+type poolsDataSourceModel struct {
+	ID          types.String       `tfsdk:"id"`
+	NameRegex   types.String       `tfsdk:"name_regex"`
+	Limit       types.Int64        `tfsdk:"limit"`
+	Names       types.List         `tfsdk:"names"`
+	WithDetails types.Bool         `tfsdk:"with_details"`
+	Details     []poolsDetailModel `tfsdk:"details"`
+}
+
+type poolsDetailModel struct {
+	Name               types.String `tfsdk:"name"`
+	PoolID             types.Int64  `tfsdk:"pool_id"`
+	Type               types.String `tfsdk:"type"`
+	Size               types.Int64  `tfsdk:"size"`
+	MinSize            types.Int64  `tfsdk:"min_size"`
+	PgNum              types.Int64  `tfsdk:"pg_num"`
+	PgpNum             types.Int64  `tfsdk:"pgp_num"`
+	CrushRule          types.Int64  `tfsdk:"crush_rule"`
+	ErasureCodeProfile types.String `tfsdk:"erasure_code_profile"`
+}
+
+func NewPoolsDataSource() datasource.DataSource {
+	return &poolsDataSource{}
+}
+
+func (d *poolsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_pools"
+}
+
+func (d *poolsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists Ceph pools",
+		Attributes: withListFilterAttributes(withDetailsAttributes(map[string]schema.Attribute{
+			"names": schema.ListAttribute{
+				Description: "Sorted pool names",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		}, "Pool settings from `ceph osd pool ls detail`", map[string]schema.Attribute{
+			"name":                 schema.StringAttribute{Computed: true, Description: "Pool name"},
+			"pool_id":              schema.Int64Attribute{Computed: true, Description: "Numeric pool id"},
+			"type":                 schema.StringAttribute{Computed: true, Description: "Pool type: replicated or erasure"},
+			"size":                 schema.Int64Attribute{Computed: true, Description: "Replication size"},
+			"min_size":             schema.Int64Attribute{Computed: true, Description: "Minimum replication size"},
+			"pg_num":               schema.Int64Attribute{Computed: true, Description: "Number of placement groups"},
+			"pgp_num":              schema.Int64Attribute{Computed: true, Description: "Number of placement groups for placement"},
+			"crush_rule":           schema.Int64Attribute{Computed: true, Description: "CRUSH rule id"},
+			"erasure_code_profile": schema.StringAttribute{Computed: true, Description: "Erasure code profile; empty for replicated pools"},
+		})),
+	}
+}
+
+func (d *poolsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*CephClient)
+}
+
+func (d *poolsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state poolsDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var pools []string
+	byName := map[string]poolDetail{}
+	if state.WithDetails.ValueBool() {
+		output, err := d.client.ReadJSON(NewCommand("ceph", "osd", "pool", "ls", "detail"))
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to list pools", err)
+			return
+		}
+		details, err := parsePoolDetails(output)
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to parse pool list", err)
+			return
+		}
+		for _, detail := range details {
+			pools = append(pools, detail.PoolName)
+			byName[detail.PoolName] = detail
+		}
+	} else {
+		output, err := d.client.ReadJSON(NewCommand("ceph", "osd", "pool", "ls"))
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to list pools", err)
+			return
+		}
+		if err := json.Unmarshal([]byte(output), &pools); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to parse pool list", err)
+			return
+		}
+	}
+
+	state.ID = types.StringValue(listID("pools"))
+	names, err := filterNames(pools, state.NameRegex, state.Limit)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Invalid pool filter", err)
+		return
+	}
+
+	state.Details = nil
+	if state.WithDetails.ValueBool() {
+		state.Details = make([]poolsDetailModel, 0, len(names))
+		for _, name := range names {
+			detail := byName[name]
+			state.Details = append(state.Details, poolsDetailModel{
+				Name:               types.StringValue(detail.PoolName),
+				PoolID:             types.Int64Value(detail.PoolID),
+				Type:               types.StringValue(detail.TypeName()),
+				Size:               types.Int64Value(detail.Size),
+				MinSize:            types.Int64Value(detail.MinSize),
+				PgNum:              types.Int64Value(detail.PgNum),
+				PgpNum:             types.Int64Value(detail.PgpNum),
+				CrushRule:          types.Int64Value(detail.CrushRule),
+				ErasureCodeProfile: types.StringValue(detail.ECProfile),
+			})
+		}
+	}
+
+	state.Names, diags = types.ListValueFrom(ctx, types.StringType, names)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Block Images Data Source
+type blockImagesDataSource struct {
+	client *CephClient
+}
+
+type blockImagesDataSourceModel struct {
+	ID          types.String             `tfsdk:"id"`
+	Pool        types.String             `tfsdk:"pool"`
+	NameRegex   types.String             `tfsdk:"name_regex"`
+	Limit       types.Int64              `tfsdk:"limit"`
+	Names       types.List               `tfsdk:"names"`
+	WithDetails types.Bool               `tfsdk:"with_details"`
+	Details     []blockImagesDetailModel `tfsdk:"details"`
+}
+
+type blockImagesDetailModel struct {
+	Name      types.String `tfsdk:"name"`
+	SizeBytes types.Int64  `tfsdk:"size_bytes"`
+	Format    types.Int64  `tfsdk:"format"`
+}
+
+// rbdLongListEntry is one row of `rbd ls --long`. Snapshots are listed as
+// extra rows for their image with snapshot set.
+type rbdLongListEntry struct {
+	Image    string `json:"image"`
+	Snapshot string `json:"snapshot"`
+	Size     int64  `json:"size"`
+	Format   int64  `json:"format"`
+}
+
+func parseRBDLongList(output string) ([]rbdLongListEntry, error) {
+	var entries []rbdLongListEntry
+	if err := json.Unmarshal([]byte(output), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse rbd ls --long output: %w", err)
+	}
+
+	images := entries[:0]
+	for _, entry := range entries {
+		if entry.Snapshot == "" {
+			images = append(images, entry)
+		}
+	}
+	return images, nil
+}
+
+func NewBlockImagesDataSource() datasource.DataSource {
+	return &blockImagesDataSource{}
+}
+
+func (d *blockImagesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_block_images"
+}
+
+func (d *blockImagesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists RBD block images in a pool",
+		Attributes: withListFilterAttributes(withDetailsAttributes(map[string]schema.Attribute{
+			"pool": schema.StringAttribute{
+				Description: "Pool name",
+				Required:    true,
+			},
+			"names": schema.ListAttribute{
+				Description: "Sorted image names",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		}, "Image sizes and formats from `rbd ls --long`", map[string]schema.Attribute{
+			"name":       schema.StringAttribute{Computed: true, Description: "Image name"},
+			"size_bytes": schema.Int64Attribute{Computed: true, Description: "Provisioned size in bytes"},
+			"format":     schema.Int64Attribute{Computed: true, Description: "Image format"},
+		})),
+	}
+}
+
+func (d *blockImagesDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*CephClient)
+}
+
+func (d *blockImagesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state blockImagesDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var images []string
+	byName := map[string]rbdLongListEntry{}
+	if state.WithDetails.ValueBool() {
+		output, err := d.client.ReadJSON(NewCommand("rbd", "ls", "--long").Arg(state.Pool.ValueString()))
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to list block images", err)
+			return
+		}
+		entries, err := parseRBDLongList(output)
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to parse block image list", err)
+			return
+		}
+		for _, entry := range entries {
+			images = append(images, entry.Image)
+			byName[entry.Image] = entry
+		}
+	} else {
+		if err := d.client.ExecuteJSON(NewCommand("rbd", "ls").Arg(state.Pool.ValueString()), &images); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to list block images", err)
+			return
+		}
+	}
+
+	state.ID = types.StringValue(listID("block_images", state.Pool.ValueString()))
+	names, err := filterNames(images, state.NameRegex, state.Limit)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Invalid block image filter", err)
+		return
+	}
+
+	state.Details = nil
+	if state.WithDetails.ValueBool() {
+		state.Details = make([]blockImagesDetailModel, 0, len(names))
+		for _, name := range names {
+			entry := byName[name]
+			state.Details = append(state.Details, blockImagesDetailModel{
+				Name:      types.StringValue(entry.Image),
+				SizeBytes: types.Int64Value(entry.Size),
+				Format:    types.Int64Value(entry.Format),
+			})
+		}
+	}
+
+	state.Names, diags = types.ListValueFrom(ctx, types.StringType, names)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Users Data Source
+type usersDataSource struct {
+	client *CephClient
+}
+
+type usersDataSourceModel struct {
+	ID          types.String       `tfsdk:"id"`
+	NameRegex   types.String       `tfsdk:"name_regex"`
+	Limit       types.Int64        `tfsdk:"limit"`
+	Names       types.List         `tfsdk:"names"`
+	WithDetails types.Bool         `tfsdk:"with_details"`
+	Details     []usersDetailModel `tfsdk:"details"`
+}
+
+type usersDetailModel struct {
+	Name types.String `tfsdk:"name"`
+	Caps types.Map    `tfsdk:"caps"`
+}
+
+type authDump struct {
+	AuthDump []struct {
+		Entity string            `json:"entity"`
+		Key    string            `json:"key"`
+		Caps   map[string]string `json:"caps"`
+	} `json:"auth_dump"`
+}
+
+func NewUsersDataSource() datasource.DataSource {
+	return &usersDataSource{}
+}
+
+func (d *usersDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_users"
+}
+
+func (d *usersDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists Ceph authentication entities",
+		Attributes: withListFilterAttributes(withDetailsAttributes(map[string]schema.Attribute{
+			"names": schema.ListAttribute{
+				Description: "Sorted entity names (e.g. client.admin)",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		}, "Entity caps from `ceph auth ls`; keys are never exported", map[string]schema.Attribute{
+			"name": schema.StringAttribute{Computed: true, Description: "Entity name"},
+			"caps": schema.MapAttribute{Computed: true, ElementType: types.StringType, Description: "Caps by daemon type"},
+		})),
+	}
+}
+
+func (d *usersDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*CephClient)
+}
+
+func (d *usersDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state usersDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	output, err := d.client.ReadJSON(NewCommand("ceph", "auth", "ls"))
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to list users", err)
+		return
+	}
+
+	var dump authDump
+	if err := json.Unmarshal([]byte(output), &dump); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse user list", err)
+		return
+	}
+
+	entities := make([]string, 0, len(dump.AuthDump))
+	caps := make(map[string]map[string]string, len(dump.AuthDump))
+	for _, entry := range dump.AuthDump {
+		entities = append(entities, entry.Entity)
+		caps[entry.Entity] = entry.Caps
+	}
+
+	state.ID = types.StringValue(listID("users"))
+	names, err := filterNames(entities, state.NameRegex, state.Limit)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Invalid user filter", err)
+		return
+	}
+
+	// ceph auth ls already carries the caps, so details cost no extra
+	// command.
+	state.Details = nil
+	if state.WithDetails.ValueBool() {
+		state.Details = make([]usersDetailModel, 0, len(names))
+		for _, name := range names {
+			entityCaps, d := types.MapValueFrom(ctx, types.StringType, caps[name])
+			resp.Diagnostics.Append(d...)
+			state.Details = append(state.Details, usersDetailModel{
+				Name: types.StringValue(name),
+				Caps: entityCaps,
+			})
+		}
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	state.Names, diags = types.ListValueFrom(ctx, types.StringType, names)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// RGW Buckets Data Source
+type rgwBucketsDataSource struct {
+	client *CephClient
+}
+
+type rgwBucketsDataSourceModel struct {
+	ID          types.String            `tfsdk:"id"`
+	UID         types.String            `tfsdk:"uid"`
+	Tenant      types.String            `tfsdk:"tenant"`
+	NameRegex   types.String            `tfsdk:"name_regex"`
+	Limit       types.Int64             `tfsdk:"limit"`
+	Names       types.List              `tfsdk:"names"`
+	WithDetails types.Bool              `tfsdk:"with_details"`
+	Details     []rgwBucketsDetailModel `tfsdk:"details"`
+	RGWScope    *rgwScopeModel          `tfsdk:"rgw_scope"`
+}
+
+type rgwBucketsDetailModel struct {
+	Name       types.String `tfsdk:"name"`
+	BucketID   types.String `tfsdk:"bucket_id"`
+	Owner      types.String `tfsdk:"owner"`
+	Tenant     types.String `tfsdk:"tenant"`
+	SizeBytes  types.Int64  `tfsdk:"size_bytes"`
+	NumObjects types.Int64  `tfsdk:"num_objects"`
+}
+
+// rgwBucketUsage is the usage section of `radosgw-admin bucket stats`.
+// Object data is accounted under rgw.main.
+type rgwBucketUsage struct {
+	Main struct {
+		Size       int64 `json:"size"`
+		NumObjects int64 `json:"num_objects"`
+	} `json:"rgw.main"`
+}
+
+type rgwBucketListStats struct {
+	rgwBucketStats
+	Usage rgwBucketUsage `json:"usage"`
+}
+
+func NewRGWBucketsDataSource() datasource.DataSource {
+	return &rgwBucketsDataSource{}
+}
+
+func (d *rgwBucketsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rgw_buckets"
+}
+
+func (d *rgwBucketsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists RADOS Gateway buckets",
+		Attributes: withListFilterAttributes(withDetailsAttributes(map[string]schema.Attribute{
+			"uid": schema.StringAttribute{
+				Description: "Only list buckets owned by this RGW user",
+				Optional:    true,
+			},
+			"tenant": schema.StringAttribute{
+				Description: "RGW tenant of the uid filter",
+				Optional:    true,
+			},
+			"names": schema.ListAttribute{
+				Description: "Sorted bucket names",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"rgw_scope": rgwScopeDataSourceAttribute(),
+		}, "Bucket ownership and usage from `radosgw-admin bucket stats`", map[string]schema.Attribute{
+			"name":        schema.StringAttribute{Computed: true, Description: "Bucket name"},
+			"bucket_id":   schema.StringAttribute{Computed: true, Description: "Bucket instance id"},
+			"owner":       schema.StringAttribute{Computed: true, Description: "Owning RGW user"},
+			"tenant":      schema.StringAttribute{Computed: true, Description: "RGW tenant; empty when untenanted"},
+			"size_bytes":  schema.Int64Attribute{Computed: true, Description: "Bytes stored"},
+			"num_objects": schema.Int64Attribute{Computed: true, Description: "Number of objects"},
+		})),
+	}
+}
+
+func (d *rgwBucketsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*CephClient)
+}
+
+func (d *rgwBucketsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state rgwBucketsDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Without --bucket, bucket stats reports every bucket in one call.
+	cmd := rgwCommand(state.RGWScope.scope(), "bucket", "list")
+	if state.WithDetails.ValueBool() {
+		cmd = rgwCommand(state.RGWScope.scope(), "bucket", "stats")
+	}
+	state.ID = types.StringValue(listID("rgw_buckets"))
+	if !state.UID.IsNull() && state.UID.ValueString() != "" {
+		owner := rgwUserID(state.Tenant.ValueString(), state.UID.ValueString())
+		cmd.OptionEquals("--uid", owner)
+		state.ID = types.StringValue(listID("rgw_buckets", owner))
+	}
+	output, err := d.client.ReadJSON(cmd)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to list buckets", err)
+		return
+	}
+
+	var buckets []string
+	byName := map[string]rgwBucketListStats{}
+	if state.WithDetails.ValueBool() {
+		var stats []rgwBucketListStats
+		if err := json.Unmarshal([]byte(output), &stats); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to parse bucket stats", err)
+			return
+		}
+		for _, bucket := range stats {
+			buckets = append(buckets, bucket.Bucket)
+			byName[bucket.Bucket] = bucket
+		}
+	} else if err := json.Unmarshal([]byte(output), &buckets); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse bucket list", err)
+		return
+	}
+
+	names, err := filterNames(buckets, state.NameRegex, state.Limit)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Invalid bucket filter", err)
+		return
+	}
+
+	state.Details = nil
+	if state.WithDetails.ValueBool() {
+		state.Details = make([]rgwBucketsDetailModel, 0, len(names))
+		for _, name := range names {
+			bucket := byName[name]
+			state.Details = append(state.Details, rgwBucketsDetailModel{
+				Name:       types.StringValue(bucket.Bucket),
+				BucketID:   types.StringValue(bucket.ID),
+				Owner:      types.StringValue(bucket.Owner),
+				Tenant:     types.StringValue(bucket.Tenant),
+				SizeBytes:  types.Int64Value(bucket.Usage.Main.Size),
+				NumObjects: types.Int64Value(bucket.Usage.Main.NumObjects),
+			})
+		}
+	}
+
+	state.Names, diags = types.ListValueFrom(ctx, types.StringType, names)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Pool Namespaces Data Source
+type poolNamespacesDataSource struct {
+	client *CephClient
+}
+
+type poolNamespacesDataSourceModel struct {
+	ID        types.String `tfsdk:"id"`
+	Pool      types.String `tfsdk:"pool"`
+	NameRegex types.String `tfsdk:"name_regex"`
+	Limit     types.Int64  `tfsdk:"limit"`
+	Names     types.List   `tfsdk:"names"`
+}
+
+func NewPoolNamespacesDataSource() datasource.DataSource {
+	return &poolNamespacesDataSource{}
+}
+
+func (d *poolNamespacesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_pool_namespaces"
+}
+
+func (d *poolNamespacesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the RBD namespaces in a pool",
+		Attributes: withListFilterAttributes(map[string]schema.Attribute{
+			"pool": schema.StringAttribute{
+				Description: "Pool name",
+				Required:    true,
+			},
+			"names": schema.ListAttribute{
+				Description: "Sorted namespace names",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		}),
+	}
+}
+
+func (d *poolNamespacesDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*CephClient)
+}
+
+func (d *poolNamespacesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state poolNamespacesDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	namespaces, err := d.client.ListRBDNamespaces(state.Pool.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to list pool namespaces", err)
+		return
+	}
+
+	state.ID = types.StringValue(listID("pool_namespaces", state.Pool.ValueString()))
+	names, err := filterNames(namespaces, state.NameRegex, state.Limit)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Invalid pool namespace filter", err)
+		return
+	}
+
+	state.Names, diags = types.ListValueFrom(ctx, types.StringType, names)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
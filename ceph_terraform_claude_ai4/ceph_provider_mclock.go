@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var mclockProfiles = map[string]bool{
+	"high_client_ops":   true,
+	"high_recovery_ops": true,
+	"balanced":          true,
+	"custom":            true,
+}
+
+// Scheduler classes and the prefix of their osd_mclock_scheduler_* options
+var mclockClasses = []string{"client", "background_recovery", "background_best_effort"}
+
+// mclockManagedOptions lists every config option owned by the resource.
+func mclockManagedOptions() []string {
+	options := []string{"osd_mclock_profile"}
+	for _, class := range mclockClasses {
+		for _, suffix := range []string{"res", "wgt", "lim"} {
+			options = append(options, fmt.Sprintf("osd_mclock_scheduler_%s_%s", class, suffix))
+		}
+	}
+	return options
+}
+
+// mClock Profile Resource
+type mclockProfileResource struct {
+	client *CephClient
+}
+
+type mclockProfileResourceModel struct {
+	ID                   types.String      `tfsdk:"id"`
+	Target               types.String      `tfsdk:"target"`
+	Profile              types.String      `tfsdk:"profile"`
+	Client               *mclockClassModel `tfsdk:"client"`
+	BackgroundRecovery   *mclockClassModel `tfsdk:"background_recovery"`
+	BackgroundBestEffort *mclockClassModel `tfsdk:"background_best_effort"`
+}
+
+type mclockClassModel struct {
+	Reservation types.Float64 `tfsdk:"reservation"`
+	Weight      types.Int64   `tfsdk:"weight"`
+	Limit       types.Float64 `tfsdk:"limit"`
+}
+
+func (m *mclockProfileResourceModel) classes() map[string]*mclockClassModel {
+	return map[string]*mclockClassModel{
+		"client":                 m.Client,
+		"background_recovery":    m.BackgroundRecovery,
+		"background_best_effort": m.BackgroundBestEffort,
+	}
+}
+
+// mclockOptions translates the model into config store option values.
+func mclockOptions(m *mclockProfileResourceModel) map[string]string {
+	options := map[string]string{
+		"osd_mclock_profile": m.Profile.ValueString(),
+	}
+	for class, c := range m.classes() {
+		if c == nil {
+			continue
+		}
+		prefix := "osd_mclock_scheduler_" + class
+		if !c.Reservation.IsNull() {
+			options[prefix+"_res"] = strconv.FormatFloat(c.Reservation.ValueFloat64(), 'f', -1, 64)
+		}
+		if !c.Weight.IsNull() {
+			options[prefix+"_wgt"] = strconv.FormatInt(c.Weight.ValueInt64(), 10)
+		}
+		if !c.Limit.IsNull() {
+			options[prefix+"_lim"] = strconv.FormatFloat(c.Limit.ValueFloat64(), 'f', -1, 64)
+		}
+	}
+	return options
+}
+
+// mclockClassFromValues rebuilds a class block from config store values,
+// returning nil when none of its options are set.
+func mclockClassFromValues(values map[string]string, class string) (*mclockClassModel, error) {
+	prefix := "osd_mclock_scheduler_" + class
+	c := &mclockClassModel{
+		Reservation: types.Float64Null(),
+		Weight:      types.Int64Null(),
+		Limit:       types.Float64Null(),
+	}
+	found := false
+
+	if v, ok := values[prefix+"_res"]; ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s_res value %q: %w", prefix, v, err)
+		}
+		c.Reservation = types.Float64Value(f)
+		found = true
+	}
+	if v, ok := values[prefix+"_wgt"]; ok {
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s_wgt value %q: %w", prefix, v, err)
+		}
+		c.Weight = types.Int64Value(i)
+		found = true
+	}
+	if v, ok := values[prefix+"_lim"]; ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s_lim value %q: %w", prefix, v, err)
+		}
+		c.Limit = types.Float64Value(f)
+		found = true
+	}
+
+	if !found {
+		return nil, nil
+	}
+	return c, nil
+}
+
+func NewMclockProfileResource() resource.Resource {
+	return &mclockProfileResource{}
+}
+
+func (r *mclockProfileResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_mclock_profile"
+}
+
+func mclockClassAttribute(description string) schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Description: description + " (requires profile = \"custom\")",
+		Optional:    true,
+		Attributes: map[string]schema.Attribute{
+			"reservation": schema.Float64Attribute{
+				Description: "Minimum IO reserved for the class",
+				Optional:    true,
+			},
+			"weight": schema.Int64Attribute{
+				Description: "Proportional share of spare capacity",
+				Optional:    true,
+			},
+			"limit": schema.Float64Attribute{
+				Description: "Maximum IO allowed for the class",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func (r *mclockProfileResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages the OSD mClock scheduler profile and per-class QoS overrides in the config store",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Config target the profile is set for"),
+			"target": schema.StringAttribute{
+				Description: "Config target the options are set for, e.g. osd, osd.3 or osd/class:ssd",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("osd"),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"profile": schema.StringAttribute{
+				Description: "mClock profile: high_client_ops, high_recovery_ops, balanced or custom",
+				Required:    true,
+			},
+			"client":                 mclockClassAttribute("Client IO allocation"),
+			"background_recovery":    mclockClassAttribute("Background recovery allocation"),
+			"background_best_effort": mclockClassAttribute("Background best-effort allocation"),
+		},
+	}
+}
+
+func (r *mclockProfileResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *mclockProfileResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config mclockProfileResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || config.Profile.IsUnknown() || config.Profile.IsNull() {
+		return
+	}
+
+	profile := config.Profile.ValueString()
+	if !mclockProfiles[profile] {
+		resp.Diagnostics.AddAttributeError(path.Root("profile"), "Invalid mClock profile",
+			fmt.Sprintf("Profile must be one of high_client_ops, high_recovery_ops, balanced or custom, got %q", profile))
+		return
+	}
+
+	if profile != "custom" {
+		for class, c := range config.classes() {
+			if c != nil {
+				resp.Diagnostics.AddAttributeError(path.Root(class), "Overrides require the custom profile",
+					fmt.Sprintf("Ceph ignores %s reservations, weights and limits unless profile is \"custom\"", class))
+			}
+		}
+	}
+}
+
+// apply sets the desired options and removes managed options that are no
+// longer configured, so the target converges on exactly the planned block.
+func (r *mclockProfileResource) apply(plan *mclockProfileResourceModel) error {
+	if err := r.client.requireRelease(releasePacific, "mClock profiles"); err != nil {
+		return err
+	}
+	who := plan.Target.ValueString()
+	desired := mclockOptions(plan)
+
+	current, err := r.client.GetConfigStoreValues(who)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range mclockManagedOptions() {
+		value, wanted := desired[name]
+		existing, set := current[name]
+		switch {
+		case wanted && (!set || existing != value):
+			if err := r.client.SetConfigStoreValue(who, name, value); err != nil {
+				return fmt.Errorf("failed to set %s: %w", name, err)
+			}
+		case !wanted && set:
+			if err := r.client.RemoveConfigStoreValue(who, name); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *mclockProfileResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan mclockProfileResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.apply(&plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to apply mClock profile", err)
+		return
+	}
+	plan.ID = plan.Target
+
+	tflog.Info(ctx, "Applied Ceph mClock profile", map[string]interface{}{
+		"target":  plan.Target.ValueString(),
+		"profile": plan.Profile.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *mclockProfileResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state mclockProfileResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	values, err := r.client.GetConfigStoreValues(state.Target.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read mClock profile", err)
+		return
+	}
+
+	profile, ok := values["osd_mclock_profile"]
+	if !ok {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	state.ID = state.Target
+	state.Profile = types.StringValue(profile)
+
+	if state.Client, err = mclockClassFromValues(values, "client"); err == nil {
+		if state.BackgroundRecovery, err = mclockClassFromValues(values, "background_recovery"); err == nil {
+			state.BackgroundBestEffort, err = mclockClassFromValues(values, "background_best_effort")
+		}
+	}
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse mClock options", err)
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *mclockProfileResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan mclockProfileResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.apply(&plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to apply mClock profile", err)
+		return
+	}
+	plan.ID = plan.Target
+
+	tflog.Info(ctx, "Updated Ceph mClock profile", map[string]interface{}{
+		"target":  plan.Target.ValueString(),
+		"profile": plan.Profile.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *mclockProfileResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state mclockProfileResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	who := state.Target.ValueString()
+	current, err := r.client.GetConfigStoreValues(who)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read mClock profile", err)
+		return
+	}
+
+	for _, name := range mclockManagedOptions() {
+		if _, set := current[name]; !set {
+			continue
+		}
+		if err := r.client.RemoveConfigStoreValue(who, name); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to remove mClock option", err)
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Removed Ceph mClock profile", map[string]interface{}{
+		"target": who,
+	})
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Command metrics for statsd_address. Every command is reported to a
+// statsd server (or anything that speaks its line protocol, such as the
+// OpenTelemetry collector's statsd receiver) as a count, a failure count
+// and a timer, named after the operation, e.g.
+// terraform.ceph.command.osd_pool_create.duration. Slow applies can then be
+// traced to the operations that take the time. Metrics go over UDP and are
+// best effort: a missing server never fails a command.
+
+const defaultMetricsPrefix = "terraform.ceph"
+
+type commandMetrics struct {
+	conn   net.Conn
+	prefix string
+}
+
+func newCommandMetrics(address, prefix string) (*commandMetrics, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return nil, fmt.Errorf("statsd_address must be host:port, got %q", address)
+	}
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open statsd connection: %w", err)
+	}
+	if prefix == "" {
+		prefix = defaultMetricsPrefix
+	}
+	return &commandMetrics{conn: conn, prefix: strings.TrimSuffix(prefix, ".")}, nil
+}
+
+// commandOperation names the operation a command performs, as listed in
+// capRequirements, e.g. "osd_pool_create". Unlisted commands are named
+// after their first two words that are not options.
+func commandOperation(args []string) string {
+	op, _, ok := requiredCaps(strings.Join(args, " "))
+	if !ok {
+		var words []string
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "-") || len(words) == 2 {
+				break
+			}
+			words = append(words, arg)
+		}
+		op = strings.Join(words, " ")
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, op)
+}
+
+// statsdLines renders the metrics of one command.
+func (m *commandMetrics) statsdLines(args []string, duration time.Duration, err error) string {
+	name := m.prefix + ".command." + commandOperation(args)
+	lines := []string{
+		name + ".count:1|c",
+		fmt.Sprintf("%s.duration:%d|ms", name, duration.Milliseconds()),
+	}
+	if err != nil {
+		lines = append(lines, name+".failures:1|c")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Observe reports a command that ran for duration and ended with err.
+func (m *commandMetrics) Observe(args []string, duration time.Duration, err error) {
+	m.conn.Write([]byte(m.statsdLines(args, duration, err)))
+}
+
+// Command metrics for emit_metrics_file. The file holds one JSON document
+// for the current provider run with the count, failures and latency of
+// each operation, rewritten after every command like record_commands_file.
+// CI can archive it per apply to track control-plane responsiveness
+// without running a statsd server.
+type operationMetrics struct {
+	Count    int64 `json:"count"`
+	Failures int64 `json:"failures"`
+	TotalMS  int64 `json:"total_ms"`
+	MaxMS    int64 `json:"max_ms"`
+}
+
+type metricsReport struct {
+	StartedAt  time.Time                    `json:"started_at"`
+	Operations map[string]*operationMetrics `json:"operations"`
+}
+
+type metricsFile struct {
+	mu     sync.Mutex
+	path   string
+	report metricsReport
+}
+
+func newMetricsFile(path string) (*metricsFile, error) {
+	m := &metricsFile{
+		path: path,
+		report: metricsReport{
+			StartedAt:  time.Now().UTC(),
+			Operations: map[string]*operationMetrics{},
+		},
+	}
+	if err := m.flush(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Observe adds a command that ran for duration and ended with err to its
+// operation's totals and rewrites the file.
+func (m *metricsFile) Observe(args []string, duration time.Duration, err error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	op := commandOperation(args)
+	metrics := m.report.Operations[op]
+	if metrics == nil {
+		metrics = &operationMetrics{}
+		m.report.Operations[op] = metrics
+	}
+	ms := duration.Milliseconds()
+	metrics.Count++
+	metrics.TotalMS += ms
+	if ms > metrics.MaxMS {
+		metrics.MaxMS = ms
+	}
+	if err != nil {
+		metrics.Failures++
+	}
+	return m.flush()
+}
+
+func (m *metricsFile) flush() error {
+	return writeJSONFile(m.path, m.report, "metrics file")
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Mgr Resource
+//
+// Manages the HA properties of the mgr layer: where cephadm places the mgr
+// daemons, and whether standby mgrs run their modules. Placement goes
+// through the mgr service spec. count_per_host = 1 together with hosts or
+// a label is the anti-affinity hint that keeps the active mgr and its
+// standbys on different hosts, so one host failure cannot take out the
+// whole layer. The cluster always needs mgrs, so destroying the resource
+// leaves the service in place.
+type mgrResource struct {
+	client *CephClient
+}
+
+type mgrResourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	PlacementCount types.Int64  `tfsdk:"placement_count"`
+	PlacementHosts types.List   `tfsdk:"placement_hosts"`
+	PlacementLabel types.String `tfsdk:"placement_label"`
+	CountPerHost   types.Int64  `tfsdk:"count_per_host"`
+	StandbyModules types.Bool   `tfsdk:"standby_modules"`
+}
+
+const (
+	mgrResourceID           = "mgr"
+	mgrStandbyModulesOption = "mgr_standby_modules"
+)
+
+func (m *mgrResourceModel) placement(ctx context.Context) (*orchPlacement, error) {
+	placement := &orchPlacement{
+		Count:        m.PlacementCount.ValueInt64(),
+		CountPerHost: m.CountPerHost.ValueInt64(),
+		Label:        m.PlacementLabel.ValueString(),
+	}
+	if !m.PlacementHosts.IsNull() {
+		if diags := m.PlacementHosts.ElementsAs(ctx, &placement.Hosts, false); diags.HasError() {
+			return nil, fmt.Errorf("invalid placement_hosts")
+		}
+	}
+	if placement.Count == 0 && placement.CountPerHost == 0 && placement.Label == "" && len(placement.Hosts) == 0 {
+		return nil, nil
+	}
+	return placement, nil
+}
+
+func NewMgrResource() resource.Resource {
+	return &mgrResource{}
+}
+
+func (r *mgrResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_mgr"
+}
+
+func (r *mgrResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages mgr daemon placement through the orchestrator and whether standby mgrs run their modules. Destroying it leaves the mgr service in place",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Always mgr"),
+			"placement_count": schema.Int64Attribute{
+				Description: "Number of mgr daemons, the active one included; 2 or more for a standby",
+				Optional:    true,
+			},
+			"placement_hosts": schema.ListAttribute{
+				Description: "Hosts the orchestrator may place mgr daemons on",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"placement_label": schema.StringAttribute{
+				Description: "Host label the orchestrator places mgr daemons on, e.g. mgr",
+				Optional:    true,
+			},
+			"count_per_host": schema.Int64Attribute{
+				Description: "Maximum mgr daemons per host; 1 keeps the active mgr and its standbys on different hosts. Requires placement_hosts or placement_label",
+				Optional:    true,
+			},
+			"standby_modules": schema.BoolAttribute{
+				Description: "Whether standby mgrs run modules that support it, so e.g. the dashboard redirects to the active mgr (mgr_standby_modules); Ceph's default when unset",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func (r *mgrResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *mgrResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config mgrResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.CountPerHost.IsNull() && config.PlacementHosts.IsNull() && config.PlacementLabel.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("count_per_host"), "count_per_host without hosts",
+			"cephadm only accepts count_per_host together with placement_hosts or placement_label")
+	}
+	if !config.PlacementCount.IsNull() && !config.PlacementCount.IsUnknown() && config.PlacementCount.ValueInt64() < 2 {
+		resp.Diagnostics.AddAttributeWarning(path.Root("placement_count"), "No standby mgr",
+			"With a single mgr there is no standby to fail over to; orchestrator, dashboard and metrics stop while it is down")
+	}
+	if config.PlacementCount.IsUnknown() || config.CountPerHost.IsUnknown() || config.PlacementHosts.IsNull() || config.PlacementHosts.IsUnknown() {
+		return
+	}
+	perHost := config.CountPerHost.ValueInt64()
+	if perHost == 0 {
+		return
+	}
+	if count, hosts := config.PlacementCount.ValueInt64(), int64(len(config.PlacementHosts.Elements())); count > hosts*perHost {
+		resp.Diagnostics.AddAttributeError(path.Root("placement_count"), "Not enough hosts",
+			fmt.Sprintf("%d mgr daemons at most %d per host need more than the %d hosts in placement_hosts", count, perHost, hosts))
+	}
+}
+
+// apply writes the placement, if any, and the standby module setting.
+func (r *mgrResource) apply(ctx context.Context, plan, state *mgrResourceModel) error {
+	placement, err := plan.placement(ctx)
+	if err != nil {
+		return err
+	}
+	if placement != nil {
+		if err := r.client.OrchApply(orchServiceSpec{ServiceType: "mgr", Placement: placement}); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case !plan.StandbyModules.IsNull() && !plan.StandbyModules.Equal(state.StandbyModules):
+		return r.client.SetConfigStoreValue("mgr", mgrStandbyModulesOption, strconv.FormatBool(plan.StandbyModules.ValueBool()))
+	case plan.StandbyModules.IsNull() && !state.StandbyModules.IsNull():
+		return r.client.RemoveConfigStoreValue("mgr", mgrStandbyModulesOption)
+	}
+	return nil
+}
+
+func (r *mgrResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan mgrResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.apply(ctx, &plan, &mgrResourceModel{}); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to configure mgr", err)
+		return
+	}
+	plan.ID = types.StringValue(mgrResourceID)
+
+	tflog.Info(ctx, "Configured Ceph mgr", map[string]interface{}{
+		"placement_count": plan.PlacementCount.ValueInt64(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *mgrResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state mgrResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Placement is only refreshed when managed, so the orchestrator's
+	// default placement does not show as drift.
+	if placement, _ := state.placement(ctx); placement != nil {
+		spec, err := r.client.OrchServiceSpec("mgr")
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to read mgr service", err)
+			return
+		}
+		current := &orchPlacement{}
+		if spec != nil && spec.Placement != nil {
+			current = spec.Placement
+		}
+		state.PlacementCount = optionalInt64(current.Count)
+		state.CountPerHost = optionalInt64(current.CountPerHost)
+		state.PlacementLabel = types.StringNull()
+		if current.Label != "" {
+			state.PlacementLabel = types.StringValue(current.Label)
+		}
+		state.PlacementHosts = types.ListNull(types.StringType)
+		if len(current.Hosts) > 0 {
+			state.PlacementHosts, diags = types.ListValueFrom(ctx, types.StringType, current.Hosts)
+			resp.Diagnostics.Append(diags...)
+		}
+	}
+
+	values, err := r.client.GetConfigStoreValues("mgr")
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read mgr configuration", err)
+		return
+	}
+	state.StandbyModules = types.BoolNull()
+	if value, ok := values[mgrStandbyModulesOption]; ok {
+		state.StandbyModules = types.BoolValue(value == "true")
+	}
+	state.ID = types.StringValue(mgrResourceID)
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// optionalInt64 maps the zero value, which specs leave out, to null.
+func optionalInt64(v int64) types.Int64 {
+	if v == 0 {
+		return types.Int64Null()
+	}
+	return types.Int64Value(v)
+}
+
+func (r *mgrResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan mgrResourceModel
+	var state mgrResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.apply(ctx, &plan, &state); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update mgr", err)
+		return
+	}
+	plan.ID = types.StringValue(mgrResourceID)
+
+	tflog.Info(ctx, "Updated Ceph mgr", map[string]interface{}{
+		"placement_count": plan.PlacementCount.ValueInt64(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *mgrResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state mgrResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The mgr service stays; only the standby setting goes back to Ceph's
+	// default.
+	if !state.StandbyModules.IsNull() {
+		if err := r.client.RemoveConfigStoreValue("mgr", mgrStandbyModulesOption); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to reset mgr_standby_modules", err)
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Removed Ceph mgr from Terraform; the mgr service is left in place")
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mgrAPIRunner sends `ceph` commands to the manager's restful module
+// (`ceph mgr module enable restful`) over HTTPS, authenticating with a key
+// from `ceph restful create-key`. The restful module hands each command to
+// the monitors, which forward manager commands on, so one endpoint covers
+// both.
+type mgrAPIRunner struct {
+	endpoint string
+	user     string
+	password string
+	http     *http.Client
+
+	once    sync.Once
+	sigs    []monCommandSig
+	initErr error
+}
+
+// mgrAPITimeout bounds a single request; the restful module only answers
+// once the command has finished.
+const mgrAPITimeout = 5 * time.Minute
+
+func newMgrAPIRunner(endpoint, user, password, caFile string) (commandRunner, error) {
+	tlsConfig := &tls.Config{}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read api_ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("api_ca_file %s contains no PEM certificates", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	r := &mgrAPIRunner{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		user:     user,
+		password: password,
+		http: &http.Client{
+			Timeout:   mgrAPITimeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}
+	return r.run, nil
+}
+
+// mgrAPIResult is the reply to POST /request?wait=1.
+type mgrAPIResult struct {
+	HasFailed bool `json:"has_failed"`
+	Finished  []struct {
+		Outb string `json:"outb"`
+		Outs string `json:"outs"`
+	} `json:"finished"`
+	Failed []struct {
+		Outb string `json:"outb"`
+		Outs string `json:"outs"`
+	} `json:"failed"`
+}
+
+func (r *mgrAPIRunner) request(ctx context.Context, cmd interface{}) (string, error) {
+	body, err := json.Marshal(cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode command: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint+"/request?wait=1", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(r.user, r.password)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return "", &commandStatusError{Status: "error connecting to the cluster", Err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read manager API response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return "", &commandStatusError{Status: "access denied", Err: fmt.Errorf("manager API returned %s", resp.Status)}
+	case resp.StatusCode >= 300:
+		return "", fmt.Errorf("manager API returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var result mgrAPIResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to parse manager API response: %w", err)
+	}
+	if result.HasFailed || len(result.Failed) > 0 {
+		status := ""
+		if len(result.Failed) > 0 {
+			status = result.Failed[0].Outs
+		}
+		return "", &commandStatusError{Status: status, Err: fmt.Errorf("command failed")}
+	}
+	if len(result.Finished) == 0 {
+		return "", fmt.Errorf("manager API returned no result")
+	}
+	return result.Finished[0].Outb, nil
+}
+
+func (r *mgrAPIRunner) run(ctx context.Context, args []string) (string, error) {
+	inv := splitCLIInvocation(args)
+	if len(inv.Words) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+	if inv.Words[0] != "ceph" {
+		return "", fmt.Errorf("%s is not available with connection_mode = \"mgr_api\"; install the Ceph CLI and use connection_mode = \"cli\"",
+			inv.Words[0])
+	}
+	if inv.InputFile != "" {
+		return "", fmt.Errorf("`ceph %s -i` is not supported with connection_mode = \"mgr_api\"; the manager API takes no input file",
+			strings.Join(inv.Words[1:], " "))
+	}
+
+	r.once.Do(func() {
+		var output string
+		output, r.initErr = r.request(ctx, map[string]string{"prefix": "get_command_descriptions"})
+		if r.initErr == nil {
+			r.sigs, r.initErr = parseCommandDescriptions(output, false)
+		}
+	})
+	if r.initErr != nil {
+		return "", r.initErr
+	}
+
+	cmd, _, err := buildMonCommand(r.sigs, inv.Words[1:])
+	if err != nil {
+		return "", err
+	}
+	output, err := r.request(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+
+	if inv.OutputFile != "" {
+		if err := os.WriteFile(inv.OutputFile, []byte(output), 0600); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", inv.OutputFile, err)
+		}
+		return "", nil
+	}
+	return output, nil
+}
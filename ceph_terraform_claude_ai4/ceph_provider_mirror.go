@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cross-cluster replication helpers. Each runs against one CephClient; the
+// two-cluster operations take the client for each side, so a configuration
+// with one provider alias per cluster can drive both.
+
+// RBDMirrorPoolEnable enables mirroring on the pool in the given mode
+// ("image" or "pool") and names the local site.
+func (c *CephClient) RBDMirrorPoolEnable(pool, mode, siteName string) error {
+	_, err := c.ExecuteCommand(NewCommand("rbd", "mirror", "pool", "enable").Arg(pool, mode).Option("--site-name", siteName))
+	return err
+}
+
+// RBDMirrorBootstrapCreate returns a bootstrap token another cluster can
+// import to peer with the pool. The token embeds a key for the peer user.
+func (c *CephClient) RBDMirrorBootstrapCreate(pool, siteName string) (string, error) {
+	output, err := c.ExecuteCommand(NewCommand("rbd", "mirror", "pool", "peer", "bootstrap", "create").Option("--site-name", siteName).Arg(pool))
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(output)
+	if token == "" {
+		return "", fmt.Errorf("rbd returned an empty bootstrap token for pool %s", pool)
+	}
+	return token, nil
+}
+
+// RBDMirrorBootstrapImport imports a peer's bootstrap token. direction is
+// "rx-only" for one-way replication into this cluster or "rx-tx".
+func (c *CephClient) RBDMirrorBootstrapImport(pool, siteName, direction, token string) error {
+	dir, err := os.MkdirTemp("", "ceph-rbd-mirror")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "token")
+	if err := os.WriteFile(file, []byte(token), 0600); err != nil {
+		return fmt.Errorf("failed to write bootstrap token: %w", err)
+	}
+
+	_, err = c.ExecuteCommand(NewCommand("rbd", "mirror", "pool", "peer", "bootstrap", "import").
+		Option("--site-name", siteName).Option("--direction", direction).Arg(pool, file))
+	return err
+}
+
+type rbdMirrorPeer struct {
+	UUID       string `json:"uuid"`
+	Direction  string `json:"direction"`
+	SiteName   string `json:"site_name"`
+	MirrorUUID string `json:"mirror_uuid"`
+	ClientName string `json:"client_name"`
+}
+
+type rbdMirrorPoolInfo struct {
+	Mode     string          `json:"mode"`
+	SiteName string          `json:"site_name"`
+	Peers    []rbdMirrorPeer `json:"peers"`
+}
+
+// RBDMirrorPoolInfo returns the pool's mirroring mode and peers.
+func (c *CephClient) RBDMirrorPoolInfo(pool string) (*rbdMirrorPoolInfo, error) {
+	var info rbdMirrorPoolInfo
+	if err := c.ExecuteJSON(NewCommand("rbd", "mirror", "pool", "info").Arg(pool), &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ExchangeRBDMirrorPeers peers the pool on two clusters: a bootstrap token
+// is created on the primary and imported on the secondary. The token only
+// passes through a temp file on the Terraform host.
+func ExchangeRBDMirrorPeers(primary, secondary *CephClient, pool, primarySite, secondarySite, direction string) error {
+	token, err := primary.RBDMirrorBootstrapCreate(pool, primarySite)
+	if err != nil {
+		return fmt.Errorf("failed to create bootstrap token on %s: %w", primarySite, err)
+	}
+	if err := secondary.RBDMirrorBootstrapImport(pool, secondarySite, direction, token); err != nil {
+		return fmt.Errorf("failed to import bootstrap token on %s: %w", secondarySite, err)
+	}
+	return nil
+}
+
+// rgwPeriod is the subset of `radosgw-admin period get` needed to tell
+// whether two zones agree on the multisite configuration.
+type rgwPeriod struct {
+	ID              string `json:"id"`
+	Epoch           int64  `json:"epoch"`
+	RealmID         string `json:"realm_id"`
+	RealmName       string `json:"realm_name"`
+	MasterZonegroup string `json:"master_zonegroup"`
+	MasterZone      string `json:"master_zone"`
+}
+
+// RGWCurrentPeriod returns the period the zone in scope is on.
+func (c *CephClient) RGWCurrentPeriod(scope rgwScope) (*rgwPeriod, error) {
+	var period rgwPeriod
+	if err := c.ExecuteJSON(rgwCommand(scope, "period", "get"), &period); err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// RGWRealmPull fetches the realm and its current period from the master
+// zone's endpoint, the first step of adding a secondary zone.
+func (c *CephClient) RGWRealmPull(scope rgwScope, url, accessKey, secretKey string) error {
+	_, err := c.ExecuteCommand(rgwCommand(scope, "realm", "pull").
+		OptionEquals("--url", url).OptionEquals("--access-key", accessKey).OptionEquals("--secret", secretKey).Flag("--default"))
+	return err
+}
+
+// checkRGWPeriodsInSync reports why two zones' periods disagree, or nil if
+// they are on the same realm, period and epoch.
+func checkRGWPeriodsInSync(primary, secondary *rgwPeriod) error {
+	if primary.RealmID != secondary.RealmID {
+		return fmt.Errorf("zones belong to different realms (%s and %s)", primary.RealmName, secondary.RealmName)
+	}
+	if primary.ID != secondary.ID {
+		return fmt.Errorf("secondary is on period %s, master is on %s; run radosgw-admin period pull", secondary.ID, primary.ID)
+	}
+	if primary.Epoch != secondary.Epoch {
+		return fmt.Errorf("secondary is at period epoch %d, master is at %d; run radosgw-admin period pull", secondary.Epoch, primary.Epoch)
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// mirrorDaemonCaps are the caps the Ceph documentation gives each mirror
+// daemon's user.
+var mirrorDaemonCaps = map[string]map[string]string{
+	"rbd-mirror": {
+		"mon": "profile rbd-mirror",
+		"osd": "profile rbd",
+	},
+	"cephfs-mirror": {
+		"mon": "profile cephfs-mirror",
+		"mds": "allow r",
+		"osd": "allow rw tag cephfs metadata=*, allow r tag cephfs data=*",
+		"mgr": "allow r",
+	},
+}
+
+// mirrorDaemonEntity returns the auth entity for a mirror daemon, e.g.
+// client.rbd-mirror.site-a.
+func mirrorDaemonEntity(daemonType, daemonID string) string {
+	if daemonID == "" {
+		return "client." + daemonType
+	}
+	return "client." + daemonType + "." + daemonID
+}
+
+// Mirror Daemon Resource
+//
+// Creates the user an rbd-mirror or cephfs-mirror daemon runs as and,
+// optionally, has the orchestrator deploy the daemons. cephadm gives the
+// daemons it deploys their own keys, so create_user is for daemons run
+// outside the orchestrator and deploy for those run by it; most
+// configurations use one or the other.
+type mirrorDaemonResource struct {
+	client *CephClient
+}
+
+type mirrorDaemonResourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	Type           types.String `tfsdk:"type"`
+	DaemonID       types.String `tfsdk:"daemon_id"`
+	CreateUser     types.Bool   `tfsdk:"create_user"`
+	Caps           types.Map    `tfsdk:"caps"`
+	Key            types.String `tfsdk:"key"`
+	Deploy         types.Bool   `tfsdk:"deploy"`
+	PlacementCount types.Int64  `tfsdk:"placement_count"`
+	PlacementHosts types.List   `tfsdk:"placement_hosts"`
+}
+
+func (m *mirrorDaemonResourceModel) entity() string {
+	return mirrorDaemonEntity(m.Type.ValueString(), m.DaemonID.ValueString())
+}
+
+func (m *mirrorDaemonResourceModel) spec(ctx context.Context) (orchServiceSpec, error) {
+	spec := orchServiceSpec{ServiceType: m.Type.ValueString()}
+	placement := &orchPlacement{}
+	if !m.PlacementCount.IsNull() {
+		placement.Count = m.PlacementCount.ValueInt64()
+	}
+	if !m.PlacementHosts.IsNull() {
+		if diags := m.PlacementHosts.ElementsAs(ctx, &placement.Hosts, false); diags.HasError() {
+			return spec, fmt.Errorf("invalid placement_hosts")
+		}
+	}
+	if placement.Count > 0 || len(placement.Hosts) > 0 {
+		spec.Placement = placement
+	}
+	return spec, nil
+}
+
+func NewMirrorDaemonResource() resource.Resource {
+	return &mirrorDaemonResource{}
+}
+
+func (r *mirrorDaemonResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_mirror_daemon"
+}
+
+func (r *mirrorDaemonResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Creates the user for an rbd-mirror or cephfs-mirror daemon with the documented caps and optionally deploys the daemons with the orchestrator",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Auth entity of the daemon user, e.g. client.rbd-mirror.site-a"),
+			"type": schema.StringAttribute{
+				Description: "\"rbd-mirror\" or \"cephfs-mirror\"",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"daemon_id": schema.StringAttribute{
+				Description: "Unique daemon id appended to the user name; required for rbd-mirror",
+				Optional:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"create_user": schema.BoolAttribute{
+				Description: "Create the daemon user",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(true),
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"caps": schema.MapAttribute{
+				Description: "Caps granted to the daemon user",
+				ElementType: types.StringType,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"key": schema.StringAttribute{
+				Description: "Key of the daemon user",
+				Computed:    true,
+				Sensitive:   true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"deploy": schema.BoolAttribute{
+				Description: "Deploy the daemons with `ceph orch apply`",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"placement_count": schema.Int64Attribute{
+				Description: "Number of daemons the orchestrator deploys",
+				Optional:    true,
+			},
+			"placement_hosts": schema.ListAttribute{
+				Description: "Hosts the orchestrator deploys the daemons on",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func (r *mirrorDaemonResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *mirrorDaemonResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config mirrorDaemonResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || config.Type.IsUnknown() {
+		return
+	}
+
+	daemonType := config.Type.ValueString()
+	if _, ok := mirrorDaemonCaps[daemonType]; !ok {
+		resp.Diagnostics.AddAttributeError(path.Root("type"), "Invalid mirror daemon type",
+			fmt.Sprintf("type must be \"rbd-mirror\" or \"cephfs-mirror\", got %q", daemonType))
+		return
+	}
+	if daemonType == "rbd-mirror" && config.DaemonID.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("daemon_id"), "Missing daemon_id",
+			"rbd-mirror users are named client.rbd-mirror.<daemon_id>; set a unique daemon_id")
+	}
+
+	deploy := !config.Deploy.IsNull() && config.Deploy.ValueBool()
+	if !deploy && (!config.PlacementCount.IsNull() || !config.PlacementHosts.IsNull()) {
+		resp.Diagnostics.AddAttributeError(path.Root("deploy"), "Placement without deploy",
+			"placement_count and placement_hosts only apply when deploy is true")
+	}
+}
+
+func (r *mirrorDaemonResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan mirrorDaemonResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	caps := mirrorDaemonCaps[plan.Type.ValueString()]
+	plan.ID = types.StringValue(plan.entity())
+	plan.Caps, diags = types.MapValueFrom(ctx, types.StringType, caps)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.Key = types.StringNull()
+	if plan.CreateUser.ValueBool() {
+		key, err := r.client.ImportAuth(plan.entity(), caps, false)
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to create mirror daemon user", err)
+			return
+		}
+		plan.Key = types.StringValue(key)
+	}
+
+	if plan.Deploy.ValueBool() {
+		// Record the user before deploying; see setPartialState.
+		resp.Diagnostics.Append(setPartialState(ctx, &resp.State, plan)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		spec, err := plan.spec(ctx)
+		if err == nil {
+			err = r.client.OrchApply(spec)
+		}
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to deploy mirror daemons", err)
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Created Ceph mirror daemon", map[string]interface{}{
+		"entity": plan.ID.ValueString(),
+		"deploy": plan.Deploy.ValueBool(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *mirrorDaemonResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state mirrorDaemonResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.ID = types.StringValue(state.entity())
+
+	if state.CreateUser.ValueBool() {
+		key, err := r.client.GetAuthKey(state.entity())
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to read mirror daemon user", err)
+			return
+		}
+		state.Key = types.StringValue(key)
+	}
+
+	// A service removed out of band shows up as deploy = false, so the
+	// next apply redeploys it.
+	if state.Deploy.ValueBool() {
+		exists, err := r.client.OrchServiceExists(state.Type.ValueString())
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to read mirror daemon service", err)
+			return
+		}
+		state.Deploy = types.BoolValue(exists)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *mirrorDaemonResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan mirrorDaemonResourceModel
+	var state mirrorDaemonResourceModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only deployment and placement change in place.
+	switch {
+	case plan.Deploy.ValueBool():
+		spec, err := plan.spec(ctx)
+		if err == nil {
+			err = r.client.OrchApply(spec)
+		}
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to deploy mirror daemons", err)
+			return
+		}
+	case state.Deploy.ValueBool():
+		if err := r.client.OrchRemove(plan.Type.ValueString()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to remove mirror daemons", err)
+			return
+		}
+	}
+
+	plan.ID = types.StringValue(plan.entity())
+
+	tflog.Info(ctx, "Updated Ceph mirror daemon", map[string]interface{}{
+		"entity": plan.ID.ValueString(),
+		"deploy": plan.Deploy.ValueBool(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *mirrorDaemonResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state mirrorDaemonResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if state.Deploy.ValueBool() {
+		if err := r.client.OrchRemove(state.Type.ValueString()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to remove mirror daemons", err)
+			return
+		}
+	}
+
+	if state.CreateUser.ValueBool() {
+		if err := r.client.DeleteAuth(state.entity()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to delete mirror daemon user", err)
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Removed Ceph mirror daemon", map[string]interface{}{
+		"entity": state.ID.ValueString(),
+	})
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Translation of `ceph` CLI invocations into the JSON commands the monitors
+// and managers accept, for the librados and mgr_api connection modes. The
+// CLI does the same thing: it fetches the command signatures with
+// get_command_descriptions and binds its arguments to them.
+
+// monCommandSig is one command signature. Literal words are stored in
+// Prefix; the remaining elements are the named arguments in order.
+type monCommandSig struct {
+	Prefix []string
+	Params []monCommandParam
+	Mgr    bool
+}
+
+type monCommandParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	N    string `json:"n"`
+	Req  string `json:"req"`
+}
+
+func (p monCommandParam) required() bool { return p.Req != "false" }
+func (p monCommandParam) multiple() bool { return p.N == "N" }
+
+// parseCommandDescriptions parses get_command_descriptions output. Each
+// signature is a list whose plain strings are literal words and whose
+// objects describe arguments.
+func parseCommandDescriptions(output string, mgr bool) ([]monCommandSig, error) {
+	var descriptions map[string]struct {
+		Sig []json.RawMessage `json:"sig"`
+	}
+	if err := json.Unmarshal([]byte(output), &descriptions); err != nil {
+		return nil, fmt.Errorf("failed to parse command descriptions: %w", err)
+	}
+
+	keys := make([]string, 0, len(descriptions))
+	for key := range descriptions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sigs := make([]monCommandSig, 0, len(keys))
+	for _, key := range keys {
+		sig := monCommandSig{Mgr: mgr}
+		for _, raw := range descriptions[key].Sig {
+			var word string
+			if err := json.Unmarshal(raw, &word); err == nil {
+				sig.Prefix = append(sig.Prefix, word)
+				continue
+			}
+			var param monCommandParam
+			if err := json.Unmarshal(raw, &param); err != nil {
+				return nil, fmt.Errorf("failed to parse signature of %s: %w", key, err)
+			}
+			sig.Params = append(sig.Params, param)
+		}
+		sigs = append(sigs, sig)
+	}
+	return sigs, nil
+}
+
+// cliInvocation is a CLI argument list with the connection and file flags
+// split out: librados handles the connection itself, and -i/-o become the
+// command's input and output buffers.
+type cliInvocation struct {
+	Words      []string
+	InputFile  string
+	OutputFile string
+}
+
+func splitCLIInvocation(args []string) cliInvocation {
+	var inv cliInvocation
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--cluster", "--conf", "--keyring", "--user", "-m", "--connect-timeout":
+			i++
+		case "-i":
+			if i+1 < len(args) {
+				inv.InputFile = args[i+1]
+			}
+			i++
+		case "-o":
+			if i+1 < len(args) {
+				inv.OutputFile = args[i+1]
+			}
+			i++
+		default:
+			inv.Words = append(inv.Words, args[i])
+		}
+	}
+	return inv
+}
+
+// buildMonCommand binds the words following "ceph" to the best matching
+// signature, the one with the longest literal prefix, and returns the JSON
+// command along with whether it goes to the manager.
+func buildMonCommand(sigs []monCommandSig, words []string) (map[string]interface{}, bool, error) {
+	var best map[string]interface{}
+	bestMgr := false
+	bestLen := -1
+	for _, sig := range sigs {
+		if len(sig.Prefix) <= bestLen {
+			continue
+		}
+		if cmd, ok := bindMonCommand(sig, words); ok {
+			best, bestMgr, bestLen = cmd, sig.Mgr, len(sig.Prefix)
+		}
+	}
+	if best == nil {
+		return nil, false, fmt.Errorf("no command matches `ceph %s`", strings.Join(words, " "))
+	}
+	return best, bestMgr, nil
+}
+
+func bindMonCommand(sig monCommandSig, words []string) (map[string]interface{}, bool) {
+	if len(sig.Prefix) == 0 || len(words) < len(sig.Prefix) {
+		return nil, false
+	}
+	for i, word := range sig.Prefix {
+		if words[i] != word {
+			return nil, false
+		}
+	}
+
+	cmd := map[string]interface{}{"prefix": strings.Join(sig.Prefix, " ")}
+	next := 0
+	rest := words[len(sig.Prefix):]
+	for i := 0; i < len(rest); i++ {
+		word := rest[i]
+
+		if strings.HasPrefix(word, "--") && len(word) > 2 {
+			name, value, hasValue := strings.Cut(word[2:], "=")
+			name = strings.ReplaceAll(name, "-", "_")
+			param, ok := findMonCommandParam(sig, name)
+			if name == "format" {
+				param, ok = monCommandParam{Name: "format", Type: "CephString"}, true
+			}
+			if !ok {
+				return nil, false
+			}
+			if !hasValue {
+				if param.Type == "CephBool" {
+					value = "true"
+				} else if i+1 < len(rest) {
+					i++
+					value = rest[i]
+				} else {
+					return nil, false
+				}
+			}
+			if !setMonCommandArg(cmd, param, value) {
+				return nil, false
+			}
+			continue
+		}
+
+		for next < len(sig.Params) {
+			if _, set := cmd[sig.Params[next].Name]; set && !sig.Params[next].multiple() {
+				next++
+				continue
+			}
+			break
+		}
+		if next >= len(sig.Params) {
+			return nil, false
+		}
+		if !setMonCommandArg(cmd, sig.Params[next], word) {
+			return nil, false
+		}
+		if !sig.Params[next].multiple() {
+			next++
+		}
+	}
+
+	for _, param := range sig.Params {
+		if _, set := cmd[param.Name]; param.required() && !set {
+			return nil, false
+		}
+	}
+	return cmd, true
+}
+
+func findMonCommandParam(sig monCommandSig, name string) (monCommandParam, bool) {
+	for _, param := range sig.Params {
+		if param.Name == name {
+			return param, true
+		}
+	}
+	return monCommandParam{}, false
+}
+
+// setMonCommandArg converts the value to the argument's type. Arguments
+// taking several values collect them in a list.
+func setMonCommandArg(cmd map[string]interface{}, param monCommandParam, value string) bool {
+	var converted interface{} = value
+	switch param.Type {
+	case "CephInt":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		converted = n
+	case "CephFloat":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false
+		}
+		converted = f
+	case "CephBool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return false
+		}
+		converted = b
+	}
+
+	if param.multiple() {
+		list, _ := cmd[param.Name].([]interface{})
+		cmd[param.Name] = append(list, converted)
+		return true
+	}
+	cmd[param.Name] = converted
+	return true
+}
+
+// commandStatusError carries the status text a monitor or manager
+// returned with a failed command. It stands in for the CLI's stderr when
+// commands go through librados or the manager API.
+type commandStatusError struct {
+	Status string
+	Err    error
+}
+
+func (e *commandStatusError) Error() string {
+	if e.Status == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Err, e.Status)
+}
+
+func (e *commandStatusError) Unwrap() error {
+	return e.Err
+}
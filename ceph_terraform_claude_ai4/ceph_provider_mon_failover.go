@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Timeout in seconds for probing a single monitor with quorum_status.
+const monProbeTimeout = 10
+
+type quorumStatus struct {
+	QuorumNames  []string `json:"quorum_names"`
+	QuorumLeader string   `json:"quorum_leader_name"`
+}
+
+func parseQuorumStatus(output string) (*quorumStatus, error) {
+	var status quorumStatus
+	if err := json.Unmarshal([]byte(output), &status); err != nil {
+		return nil, fmt.Errorf("failed to parse quorum status: %w", err)
+	}
+	return &status, nil
+}
+
+func monArgs(args []string, mon string) []string {
+	return append(args, "-m", mon)
+}
+
+// probeMon reports whether the monitor answers quorum_status and is part of
+// a formed quorum.
+func (c *CephClient) probeMon(mon string) error {
+	args := c.buildCmdArgs([]string{"ceph", "quorum_status", "--format", "json"})
+	args = append(monArgs(args, mon), "--connect-timeout", fmt.Sprint(monProbeTimeout))
+
+	output, err := c.execute(args)
+	if err != nil {
+		return err
+	}
+
+	status, err := parseQuorumStatus(output)
+	if err != nil {
+		return err
+	}
+	if len(status.QuorumNames) == 0 {
+		return fmt.Errorf("monitor %s reports no quorum", mon)
+	}
+	return nil
+}
+
+// healthyMon returns the monitor remembered for this session, probing the
+// configured mon_hosts in order if none has been selected yet. Probes run
+// without holding monMu, so commands on other goroutines are not held up by
+// a slow or dead monitor; if several pick a monitor at once, the first one
+// stored wins.
+func (c *CephClient) healthyMon() (string, error) {
+	c.monMu.Lock()
+	mon := c.activeMon
+	c.monMu.Unlock()
+	if mon != "" {
+		return mon, nil
+	}
+
+	// The probe errors are kept wrapped, so an unreachable cluster is still
+	// diagnosed as a connection failure.
+	var failures []error
+	for _, mon := range c.MonHosts {
+		if err := c.probeMon(mon); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", mon, err))
+			continue
+		}
+		c.monMu.Lock()
+		if c.activeMon == "" {
+			c.activeMon = mon
+		}
+		mon = c.activeMon
+		c.monMu.Unlock()
+		return mon, nil
+	}
+	return "", fmt.Errorf("no reachable monitor in mon_hosts:\n%w", errors.Join(failures...))
+}
+
+func (c *CephClient) forgetMon(mon string) {
+	c.monMu.Lock()
+	defer c.monMu.Unlock()
+
+	if c.activeMon == mon {
+		c.activeMon = ""
+	}
+}
+
+// executeWithMonFailover runs the command against the remembered monitor.
+// If it fails and that monitor no longer answers quorum_status, another
+// monitor is selected for later commands; failures on a healthy monitor are
+// returned as-is. Only read-only commands are retried on the new monitor:
+// a change that failed on a dying monitor may still have been committed, so
+// running it twice is not safe in general.
+func (c *CephClient) executeWithMonFailover(args []string) (string, error) {
+	mon, err := c.healthyMon()
+	if err != nil {
+		return "", err
+	}
+
+	output, err := c.execute(monArgs(c.buildCmdArgs(args), mon))
+	if err == nil {
+		return output, nil
+	}
+	if c.probeMon(mon) == nil {
+		return "", err
+	}
+
+	c.forgetMon(mon)
+	next, monErr := c.healthyMon()
+	if monErr != nil {
+		return "", fmt.Errorf("%w (monitor %s became unreachable: %s)", err, mon, monErr)
+	}
+	if !isReadOnlyCommand(args) {
+		return "", fmt.Errorf("%w (monitor %s became unreachable; not retried on %s because the change may have been applied, "+
+			"check the cluster and re-run)", err, mon, next)
+	}
+	return c.execute(monArgs(c.buildCmdArgs(args), next))
+}
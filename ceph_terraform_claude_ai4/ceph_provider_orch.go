@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Orchestrator helpers. Service specs are written as JSON, which cephadm
+// accepts wherever it accepts YAML, and applied from a file because
+// placement strings contain spaces.
+
+type orchPlacement struct {
+	Count        int64    `json:"count,omitempty"`
+	CountPerHost int64    `json:"count_per_host,omitempty"`
+	Hosts        []string `json:"hosts,omitempty"`
+	Label        string   `json:"label,omitempty"`
+}
+
+// UnmarshalJSON accepts hosts both as names and, as `ceph orch ls
+// --export` writes hosts with a network or daemon name, as objects.
+func (p *orchPlacement) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Count        int64             `json:"count"`
+		CountPerHost int64             `json:"count_per_host"`
+		Hosts        []json.RawMessage `json:"hosts"`
+		Label        string            `json:"label"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*p = orchPlacement{Count: raw.Count, CountPerHost: raw.CountPerHost, Label: raw.Label}
+	for _, h := range raw.Hosts {
+		var host struct {
+			Hostname string `json:"hostname"`
+		}
+		if err := json.Unmarshal(h, &host.Hostname); err != nil {
+			if err := json.Unmarshal(h, &host); err != nil {
+				return fmt.Errorf("invalid placement host %s: %w", h, err)
+			}
+		}
+		p.Hosts = append(p.Hosts, host.Hostname)
+	}
+	return nil
+}
+
+type orchServiceSpec struct {
+	ServiceType string         `json:"service_type"`
+	ServiceID   string         `json:"service_id,omitempty"`
+	Placement   *orchPlacement `json:"placement,omitempty"`
+}
+
+// OrchApply applies a service spec with `ceph orch apply -i`.
+func (c *CephClient) OrchApply(spec orchServiceSpec) error {
+	body, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("failed to encode service spec: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "ceph-orch")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "spec.json")
+	if err := os.WriteFile(file, body, 0600); err != nil {
+		return fmt.Errorf("failed to write service spec: %w", err)
+	}
+
+	_, err = c.ExecuteCommand(NewCommand("ceph", "orch", "apply").Option("-i", file))
+	return err
+}
+
+// OrchServiceExists reports whether the orchestrator manages the service.
+func (c *CephClient) OrchServiceExists(serviceName string) (bool, error) {
+	var services []struct {
+		ServiceName string `json:"service_name"`
+	}
+	if err := c.ExecuteJSON(NewCommand("ceph", "orch", "ls").Option("--service_name", serviceName), &services); err != nil {
+		return false, err
+	}
+	for _, service := range services {
+		if service.ServiceName == serviceName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// OrchServiceSpec returns the spec the orchestrator holds for a service, or
+// nil if it does not manage the service.
+func (c *CephClient) OrchServiceSpec(serviceName string) (*orchServiceSpec, error) {
+	var specs []orchServiceSpec
+	if err := c.ExecuteJSON(NewCommand("ceph", "orch", "ls").Option("--service_name", serviceName).Flag("--export"), &specs); err != nil {
+		return nil, err
+	}
+	for i := range specs {
+		name := specs[i].ServiceType
+		if specs[i].ServiceID != "" {
+			name += "." + specs[i].ServiceID
+		}
+		if name == serviceName {
+			return &specs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// OrchRemove removes a service and its daemons.
+func (c *CephClient) OrchRemove(serviceName string) error {
+	_, err := c.ExecuteCommand(NewCommand("ceph", "orch", "rm").Arg(serviceName))
+	return err
+}
+
+// OrchDeviceZap wipes a device on a managed host so it can be reused for
+// a new OSD. cephadm refuses devices still in use by an OSD. The caller
+// has checked the resource's confirm attribute.
+func (c *CephClient) OrchDeviceZap(host, device string) error {
+	_, err := c.ExecuteCommand(NewCommand("ceph", "orch", "device", "zap").Arg(host, device).Flag("--force").Confirmed())
+	return err
+}
+
+// OrchRedeploy redeploys the daemons of a service, e.g. to pick up a new
+// certificate.
+func (c *CephClient) OrchRedeploy(serviceName string) error {
+	_, err := c.ExecuteCommand(NewCommand("ceph", "orch", "redeploy").Arg(serviceName))
+	return err
+}
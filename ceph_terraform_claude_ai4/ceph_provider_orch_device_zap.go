@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Orchestrator Device Zap Resource
+//
+// An action resource: creating it wipes the device with
+// `ceph orch device zap`. Every argument forces replacement, so changing
+// the device or the triggers zaps again. Destroying it only drops it from
+// state.
+type orchDeviceZapResource struct {
+	client *CephClient
+}
+
+type orchDeviceZapResourceModel struct {
+	ID       types.String `tfsdk:"id"`
+	Host     types.String `tfsdk:"host"`
+	Path     types.String `tfsdk:"path"`
+	Confirm  types.Bool   `tfsdk:"confirm"`
+	Triggers types.Map    `tfsdk:"triggers"`
+	ZappedAt types.String `tfsdk:"zapped_at"`
+}
+
+func NewOrchDeviceZapResource() resource.Resource {
+	return &orchDeviceZapResource{}
+}
+
+func (r *orchDeviceZapResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_orch_device_zap"
+}
+
+func (r *orchDeviceZapResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	requiresReplace := []planmodifier.String{
+		stringplanmodifier.RequiresReplace(),
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Wipes a device on a cephadm-managed host with `ceph orch device zap --force`, so it can be reused for a new OSD. All data on the device is destroyed",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Device in host:path form"),
+			"host": schema.StringAttribute{
+				Description:   "Host name as known to the orchestrator",
+				Required:      true,
+				PlanModifiers: requiresReplace,
+			},
+			"path": schema.StringAttribute{
+				Description:   "Device path on the host, e.g. /dev/sdb",
+				Required:      true,
+				PlanModifiers: requiresReplace,
+			},
+			"confirm": schema.BoolAttribute{
+				Description: "Must be true; acknowledges that everything on the device is destroyed",
+				Required:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"triggers": schema.MapAttribute{
+				Description: "Arbitrary values that zap the device again when changed",
+				ElementType: types.StringType,
+				Optional:    true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"zapped_at": schema.StringAttribute{
+				Description: "RFC 3339 time the device was zapped",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *orchDeviceZapResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *orchDeviceZapResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config orchDeviceZapResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.Path.IsUnknown() && !strings.HasPrefix(config.Path.ValueString(), "/dev/") {
+		resp.Diagnostics.AddAttributeError(path.Root("path"), "Invalid device path",
+			fmt.Sprintf("path must be a device under /dev, got %q", config.Path.ValueString()))
+	}
+	if !config.Confirm.IsUnknown() && !config.Confirm.ValueBool() {
+		resp.Diagnostics.AddAttributeError(path.Root("confirm"), "Device zap not confirmed",
+			"Zapping destroys all data and partitions on the device. Set confirm = true to proceed")
+	}
+}
+
+func (r *orchDeviceZapResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan orchDeviceZapResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.OrchDeviceZap(plan.Host.ValueString(), plan.Path.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to zap device", err)
+		return
+	}
+
+	plan.ID = types.StringValue(plan.Host.ValueString() + ":" + plan.Path.ValueString())
+	plan.ZappedAt = types.StringValue(time.Now().UTC().Format(time.RFC3339))
+
+	tflog.Info(ctx, "Zapped Ceph device", map[string]interface{}{
+		"host": plan.Host.ValueString(),
+		"path": plan.Path.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *orchDeviceZapResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// The zap is a one-off action; there is nothing to refresh.
+}
+
+func (r *orchDeviceZapResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Every argument forces replacement, so Update is never called with a
+	// change that needs applying.
+	var plan orchDeviceZapResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *orchDeviceZapResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Info(ctx, "Removed Ceph device zap from state; the device is unchanged")
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// OSD tree as reported by `ceph osd tree --format json`. OSDs that are not
+// under any host in the CRUSH map are listed in stray.
+type osdTree struct {
+	Nodes []osdTreeNode `json:"nodes"`
+	Stray []osdTreeNode `json:"stray"`
+}
+
+type osdTreeNode struct {
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	Type        string  `json:"type"`
+	Status      string  `json:"status"`
+	Reweight    float64 `json:"reweight"`
+	DeviceClass string  `json:"device_class"`
+	Children    []int64 `json:"children"`
+}
+
+// osdHealth is an OSD with the host it sits on in the CRUSH map.
+type osdHealth struct {
+	ID          int64
+	Name        string
+	Host        string
+	Up          bool
+	In          bool
+	DeviceClass string
+}
+
+// parseOSDTree returns every OSD in the tree, sorted by id. An out OSD has
+// a reweight of 0.
+func parseOSDTree(output string) ([]osdHealth, error) {
+	var tree osdTree
+	if err := json.Unmarshal([]byte(output), &tree); err != nil {
+		return nil, fmt.Errorf("failed to parse OSD tree: %w", err)
+	}
+
+	hosts := make(map[int64]string)
+	for _, node := range tree.Nodes {
+		if node.Type == "host" {
+			for _, child := range node.Children {
+				hosts[child] = node.Name
+			}
+		}
+	}
+
+	var osds []osdHealth
+	for _, node := range append(tree.Nodes, tree.Stray...) {
+		if node.Type != "osd" {
+			continue
+		}
+		osds = append(osds, osdHealth{
+			ID:          node.ID,
+			Name:        node.Name,
+			Host:        hosts[node.ID],
+			Up:          node.Status == "up",
+			In:          node.Reweight > 0,
+			DeviceClass: node.DeviceClass,
+		})
+	}
+	sort.Slice(osds, func(i, j int) bool { return osds[i].ID < osds[j].ID })
+	return osds, nil
+}
+
+// OSD Down Detection Data Source
+//
+// Lists the OSDs that are down, or out with include_out, together with
+// their hosts, so placement specs and CRUSH moves written in HCL can leave
+// unhealthy hardware out.
+type osdDownDetectionDataSource struct {
+	client *CephClient
+}
+
+type osdDownDetectionDataSourceModel struct {
+	ID           types.String   `tfsdk:"id"`
+	IncludeOut   types.Bool     `tfsdk:"include_out"`
+	OSDs         []osdDownModel `tfsdk:"osds"`
+	Hosts        types.List     `tfsdk:"hosts"`
+	HealthyHosts types.List     `tfsdk:"healthy_hosts"`
+	RawJSON      types.String   `tfsdk:"raw_json"`
+}
+
+type osdDownModel struct {
+	ID          types.Int64  `tfsdk:"id"`
+	Name        types.String `tfsdk:"name"`
+	Host        types.String `tfsdk:"host"`
+	Up          types.Bool   `tfsdk:"up"`
+	In          types.Bool   `tfsdk:"in"`
+	DeviceClass types.String `tfsdk:"device_class"`
+}
+
+func NewOSDDownDetectionDataSource() datasource.DataSource {
+	return &osdDownDetectionDataSource{}
+}
+
+func (d *osdDownDetectionDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_osd_down_detection"
+}
+
+func (d *osdDownDetectionDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "OSDs that are currently down or out, with their hosts, for excluding unhealthy hardware from placement decisions",
+		Attributes: map[string]schema.Attribute{
+			"id":       dataSourceIDAttribute("Always osd_down_detection"),
+			"raw_json": rawJSONAttribute("`ceph osd tree`"),
+			"include_out": schema.BoolAttribute{
+				Description: "Also report OSDs that are up but out (default true)",
+				Optional:    true,
+			},
+			"osds": schema.ListNestedAttribute{
+				Description: "Unhealthy OSDs, sorted by id",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.Int64Attribute{
+							Description: "OSD id",
+							Computed:    true,
+						},
+						"name": schema.StringAttribute{
+							Description: "OSD name, e.g. osd.3",
+							Computed:    true,
+						},
+						"host": schema.StringAttribute{
+							Description: "Host the OSD sits under in the CRUSH map; empty for stray OSDs",
+							Computed:    true,
+						},
+						"up": schema.BoolAttribute{
+							Description: "Whether the OSD is up",
+							Computed:    true,
+						},
+						"in": schema.BoolAttribute{
+							Description: "Whether the OSD is in",
+							Computed:    true,
+						},
+						"device_class": schema.StringAttribute{
+							Description: "Device class of the OSD",
+							Computed:    true,
+						},
+					},
+				},
+			},
+			"hosts": schema.ListAttribute{
+				Description: "Hosts with at least one unhealthy OSD, sorted",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"healthy_hosts": schema.ListAttribute{
+				Description: "Hosts whose OSDs are all healthy, sorted",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *osdDownDetectionDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*CephClient)
+}
+
+func (d *osdDownDetectionDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state osdDownDetectionDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	output, err := d.client.ReadJSON(NewCommand("ceph", "osd", "tree"))
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to get OSD tree", err)
+		return
+	}
+
+	osds, err := parseOSDTree(output)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse OSD tree", err)
+		return
+	}
+	state.RawJSON, err = rawJSONValue([]byte(output))
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse OSD tree", err)
+		return
+	}
+
+	includeOut := state.IncludeOut.IsNull() || state.IncludeOut.ValueBool()
+	unhealthy := make(map[string]bool)
+	allHosts := make(map[string]bool)
+	state.OSDs = make([]osdDownModel, 0)
+	for _, osd := range osds {
+		if osd.Host != "" {
+			allHosts[osd.Host] = true
+		}
+		if osd.Up && (osd.In || !includeOut) {
+			continue
+		}
+		if osd.Host != "" {
+			unhealthy[osd.Host] = true
+		}
+		state.OSDs = append(state.OSDs, osdDownModel{
+			ID:          types.Int64Value(osd.ID),
+			Name:        types.StringValue(osd.Name),
+			Host:        types.StringValue(osd.Host),
+			Up:          types.BoolValue(osd.Up),
+			In:          types.BoolValue(osd.In),
+			DeviceClass: types.StringValue(osd.DeviceClass),
+		})
+	}
+
+	hosts := make([]string, 0, len(unhealthy))
+	healthy := make([]string, 0, len(allHosts))
+	for host := range allHosts {
+		if unhealthy[host] {
+			hosts = append(hosts, host)
+		} else {
+			healthy = append(healthy, host)
+		}
+	}
+	sort.Strings(hosts)
+	sort.Strings(healthy)
+
+	state.ID = types.StringValue("osd_down_detection")
+	state.Hosts, diags = types.ListValueFrom(ctx, types.StringType, hosts)
+	resp.Diagnostics.Append(diags...)
+	state.HealthyHosts, diags = types.ListValueFrom(ctx, types.StringType, healthy)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
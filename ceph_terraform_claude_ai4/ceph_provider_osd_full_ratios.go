@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ratios restored on destroy; these are Ceph's defaults for new clusters.
+const (
+	defaultNearfullRatio     = 0.85
+	defaultBackfillfullRatio = 0.90
+	defaultFullRatio         = 0.95
+)
+
+// OSD full ratios as reported by `ceph osd dump --format json`
+type osdFullRatios struct {
+	FullRatio         float64 `json:"full_ratio"`
+	BackfillfullRatio float64 `json:"backfillfull_ratio"`
+	NearfullRatio     float64 `json:"nearfull_ratio"`
+}
+
+func parseOSDFullRatios(output string) (*osdFullRatios, error) {
+	var ratios osdFullRatios
+	if err := json.Unmarshal([]byte(output), &ratios); err != nil {
+		return nil, fmt.Errorf("failed to parse osd dump: %w", err)
+	}
+	return &ratios, nil
+}
+
+// validateFullRatios checks that each configured ratio lies in (0, 1] and
+// that nearfull <= backfillfull <= full. Nil means the ratio is unmanaged.
+func validateFullRatios(nearfull, backfillfull, full *float64) []string {
+	var problems []string
+	named := []struct {
+		name  string
+		value *float64
+	}{
+		{"nearfull_ratio", nearfull},
+		{"backfillfull_ratio", backfillfull},
+		{"full_ratio", full},
+	}
+
+	for _, r := range named {
+		if r.value != nil && (*r.value <= 0 || *r.value > 1) {
+			problems = append(problems, fmt.Sprintf("%s must be greater than 0 and at most 1, got %g", r.name, *r.value))
+		}
+	}
+	for i := 0; i < len(named); i++ {
+		for j := i + 1; j < len(named); j++ {
+			lower, higher := named[i], named[j]
+			if lower.value != nil && higher.value != nil && *lower.value > *higher.value {
+				problems = append(problems, fmt.Sprintf("%s (%g) must not exceed %s (%g)",
+					lower.name, *lower.value, higher.name, *higher.value))
+			}
+		}
+	}
+	return problems
+}
+
+func float64Ptr(v types.Float64) *float64 {
+	if v.IsNull() || v.IsUnknown() {
+		return nil
+	}
+	f := v.ValueFloat64()
+	return &f
+}
+
+// OSD Full Ratios Resource
+type osdFullRatiosResource struct {
+	client *CephClient
+}
+
+type osdFullRatiosResourceModel struct {
+	ID                types.String  `tfsdk:"id"`
+	NearfullRatio     types.Float64 `tfsdk:"nearfull_ratio"`
+	BackfillfullRatio types.Float64 `tfsdk:"backfillfull_ratio"`
+	FullRatio         types.Float64 `tfsdk:"full_ratio"`
+}
+
+func NewOSDFullRatiosResource() resource.Resource {
+	return &osdFullRatiosResource{}
+}
+
+func (r *osdFullRatiosResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_osd_full_ratios"
+}
+
+func (r *osdFullRatiosResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages the cluster-wide OSD nearfull, backfillfull and full ratios",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Always osd_full_ratios; the ratios are cluster-wide"),
+			"nearfull_ratio": schema.Float64Attribute{
+				Description: "Utilization at which OSDs are reported as nearfull",
+				Optional:    true,
+			},
+			"backfillfull_ratio": schema.Float64Attribute{
+				Description: "Utilization above which OSDs refuse backfill",
+				Optional:    true,
+			},
+			"full_ratio": schema.Float64Attribute{
+				Description: "Utilization at which OSDs stop accepting writes",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func (r *osdFullRatiosResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *osdFullRatiosResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config osdFullRatiosResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for _, problem := range validateFullRatios(float64Ptr(config.NearfullRatio), float64Ptr(config.BackfillfullRatio), float64Ptr(config.FullRatio)) {
+		resp.Diagnostics.AddAttributeError(path.Root("full_ratio"), "Invalid OSD full ratios", problem)
+	}
+}
+
+func (r *osdFullRatiosResource) setRatio(command string, value float64) error {
+	_, err := r.client.ExecuteCommand(NewCommand("ceph", "osd", command).Float(value))
+	return err
+}
+
+func (r *osdFullRatiosResource) apply(plan *osdFullRatiosResourceModel) error {
+	ratios := []struct {
+		command string
+		value   types.Float64
+	}{
+		{"set-full-ratio", plan.FullRatio},
+		{"set-backfillfull-ratio", plan.BackfillfullRatio},
+		{"set-nearfull-ratio", plan.NearfullRatio},
+	}
+	for _, ratio := range ratios {
+		if ratio.value.IsNull() {
+			continue
+		}
+		if err := r.setRatio(ratio.command, ratio.value.ValueFloat64()); err != nil {
+			return fmt.Errorf("%s failed: %w", ratio.command, err)
+		}
+	}
+	return nil
+}
+
+func (r *osdFullRatiosResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan osdFullRatiosResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.apply(&plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to set OSD full ratios", err)
+		return
+	}
+	plan.ID = types.StringValue(osdFullRatiosID)
+
+	tflog.Info(ctx, "Set Ceph OSD full ratios")
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *osdFullRatiosResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state osdFullRatiosResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	output, err := r.client.ReadJSON(NewCommand("ceph", "osd", "dump"))
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read OSD full ratios", err)
+		return
+	}
+
+	ratios, err := parseOSDFullRatios(output)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read OSD full ratios", err)
+		return
+	}
+
+	state.ID = types.StringValue(osdFullRatiosID)
+
+	// Only managed ratios are refreshed so unmanaged ones never show diffs.
+	if !state.NearfullRatio.IsNull() {
+		state.NearfullRatio = types.Float64Value(ratios.NearfullRatio)
+	}
+	if !state.BackfillfullRatio.IsNull() {
+		state.BackfillfullRatio = types.Float64Value(ratios.BackfillfullRatio)
+	}
+	if !state.FullRatio.IsNull() {
+		state.FullRatio = types.Float64Value(ratios.FullRatio)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *osdFullRatiosResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan osdFullRatiosResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.apply(&plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to set OSD full ratios", err)
+		return
+	}
+	plan.ID = types.StringValue(osdFullRatiosID)
+
+	tflog.Info(ctx, "Updated Ceph OSD full ratios")
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *osdFullRatiosResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state osdFullRatiosResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Put managed ratios back to Ceph's defaults.
+	defaults := osdFullRatiosResourceModel{
+		NearfullRatio:     types.Float64Null(),
+		BackfillfullRatio: types.Float64Null(),
+		FullRatio:         types.Float64Null(),
+	}
+	if !state.NearfullRatio.IsNull() {
+		defaults.NearfullRatio = types.Float64Value(defaultNearfullRatio)
+	}
+	if !state.BackfillfullRatio.IsNull() {
+		defaults.BackfillfullRatio = types.Float64Value(defaultBackfillfullRatio)
+	}
+	if !state.FullRatio.IsNull() {
+		defaults.FullRatio = types.Float64Value(defaultFullRatio)
+	}
+
+	if err := r.apply(&defaults); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to restore default OSD full ratios", err)
+		return
+	}
+
+	tflog.Info(ctx, "Restored default Ceph OSD full ratios")
+}
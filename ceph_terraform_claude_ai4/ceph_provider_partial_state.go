@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Partial state for resources created in several steps. Once the object
+// exists in the cluster, Create records it with setPartialState before
+// running the remaining steps. If one of them fails, Terraform keeps the
+// resource in state as tainted and the next apply replaces it, instead of
+// losing track of an object the provider created and failing on it with
+// "already exists".
+//
+// Computed values that the remaining steps would fill in are still
+// unknown at that point, and state cannot hold unknown values, so they are
+// recorded as null. A Create that finishes sets the full state over them.
+
+// setPartialState records model in state, with unknown values as null.
+func setPartialState(ctx context.Context, state *tfsdk.State, model interface{}) diag.Diagnostics {
+	diags := state.Set(ctx, model)
+	if diags.HasError() {
+		return diags
+	}
+	raw, err := tftypes.Transform(state.Raw, func(_ *tftypes.AttributePath, value tftypes.Value) (tftypes.Value, error) {
+		if !value.IsKnown() {
+			return tftypes.NewValue(value.Type(), nil), nil
+		}
+		return value, nil
+	})
+	if err != nil {
+		diags.AddError("Failed to record partial state", err.Error())
+		return diags
+	}
+	state.Raw = raw
+	return diags
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Cache tiering. A ceph_pool with a cache_tier block is the base pool; the
+// cache pool is a separate ceph_pool, referenced by name so Terraform
+// destroys the base, and with it the tier relationship, first. Removing
+// the block, or destroying the base pool, switches the cache to proxy
+// mode, flushes and evicts its objects to the base pool and then detaches
+// it. Cache tiering is deprecated since Reef; it is supported for clusters
+// that still run it.
+
+// Cache modes a tier can be added in. readonly and none need
+// --yes-i-really-mean-it and are not offered.
+var cacheTierModes = map[string]bool{
+	"writeback": true,
+	"readproxy": true,
+	"proxy":     true,
+}
+
+var hitSetTypes = map[string]bool{
+	"bloom":           true,
+	"explicit_hash":   true,
+	"explicit_object": true,
+}
+
+type poolCacheTierModel struct {
+	Pool           types.String  `tfsdk:"pool"`
+	Mode           types.String  `tfsdk:"mode"`
+	HitSetType     types.String  `tfsdk:"hit_set_type"`
+	HitSetCount    types.Int64   `tfsdk:"hit_set_count"`
+	HitSetPeriod   durationValue `tfsdk:"hit_set_period"`
+	TargetMaxBytes sizeValue     `tfsdk:"target_max_bytes"`
+}
+
+func cacheTierAttribute() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Description: "Put a cache pool in front of this pool with `ceph osd tier add`, `cache-mode` and `set-overlay`",
+		Optional:    true,
+		Attributes: map[string]schema.Attribute{
+			"pool": schema.StringAttribute{
+				Description: "Name of the cache pool, usually a replicated pool on faster OSDs",
+				Required:    true,
+			},
+			"mode": schema.StringAttribute{
+				Description: "Cache mode: writeback, readproxy or proxy",
+				Required:    true,
+			},
+			"hit_set_type": schema.StringAttribute{
+				Description: "Hit set type on the cache pool: bloom, explicit_hash or explicit_object",
+				Optional:    true,
+			},
+			"hit_set_count": schema.Int64Attribute{
+				Description: "Number of hit sets the cache pool keeps",
+				Optional:    true,
+			},
+			"hit_set_period": schema.StringAttribute{
+				Description: "Time each hit set covers, e.g. \"1h\"",
+				CustomType:  durationType{},
+				Optional:    true,
+			},
+			"target_max_bytes": schema.StringAttribute{
+				Description: "Size at which the cache pool starts flushing and evicting, in bytes or with a unit such as 1T",
+				CustomType:  sizeType{},
+				Optional:    true,
+			},
+		},
+	}
+}
+
+// validateCacheTier reports a cache_tier block Ceph would refuse.
+func validateCacheTier(config *poolResourceModel, diags *diag.Diagnostics) {
+	tier := config.CacheTier
+	if tier == nil {
+		return
+	}
+	at := path.Root("cache_tier")
+	if !tier.Pool.IsUnknown() && !config.Name.IsUnknown() && tier.Pool.Equal(config.Name) {
+		diags.AddAttributeError(at.AtName("pool"), "Invalid cache tier", "A pool cannot be its own cache tier.")
+	}
+	if !tier.Mode.IsUnknown() && !cacheTierModes[tier.Mode.ValueString()] {
+		diags.AddAttributeError(at.AtName("mode"), "Invalid cache mode",
+			fmt.Sprintf("mode must be writeback, readproxy or proxy, not %q", tier.Mode.ValueString()))
+	}
+	if !tier.HitSetType.IsNull() && !tier.HitSetType.IsUnknown() && !hitSetTypes[tier.HitSetType.ValueString()] {
+		diags.AddAttributeError(at.AtName("hit_set_type"), "Invalid hit set type",
+			fmt.Sprintf("hit_set_type must be bloom, explicit_hash or explicit_object, not %q", tier.HitSetType.ValueString()))
+	}
+	if !tier.HitSetCount.IsNull() && !tier.HitSetCount.IsUnknown() && tier.HitSetCount.ValueInt64() < 1 {
+		diags.AddAttributeError(at.AtName("hit_set_count"), "Invalid hit set count", "hit_set_count must be at least 1.")
+	}
+	if !tier.HitSetPeriod.IsNull() && !tier.HitSetPeriod.IsUnknown() && tier.HitSetPeriod.Seconds() < 1 {
+		diags.AddAttributeError(at.AtName("hit_set_period"), "Invalid hit set period", "hit_set_period must be at least 1s.")
+	}
+}
+
+// AddCacheTier makes cache a tier of base in the given mode and sends
+// client I/O through it. Every step is idempotent, so a tier left half
+// configured by a failed apply is completed.
+func (c *CephClient) AddCacheTier(base, cache, mode string) error {
+	for _, cmd := range []*CommandBuilder{
+		NewCommand("ceph", "osd", "tier", "add").Arg(base, cache),
+		NewCommand("ceph", "osd", "tier", "cache-mode").Arg(cache, mode),
+		NewCommand("ceph", "osd", "tier", "set-overlay").Arg(base, cache),
+	} {
+		if _, err := c.ExecuteCommand(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveCacheTier detaches cache from base. The cache is switched to proxy
+// mode first, so no new objects are promoted while the dirty ones are
+// flushed to the base pool.
+func (c *CephClient) RemoveCacheTier(base, cache string) error {
+	for _, cmd := range []*CommandBuilder{
+		NewCommand("ceph", "osd", "tier", "cache-mode").Arg(cache, "proxy"),
+		NewCommand("rados", "cache-flush-evict-all").Option("-p", cache),
+		NewCommand("ceph", "osd", "tier", "remove-overlay").Arg(base),
+		NewCommand("ceph", "osd", "tier", "remove").Arg(base, cache),
+	} {
+		if _, err := c.ExecuteCommand(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyCacheTier brings the tier relationship from state to plan. A tier
+// whose cache pool changed is removed before the new one is added.
+func (r *poolResource) applyCacheTier(plan, state *poolResourceModel) error {
+	base := plan.Name.ValueString()
+	current := state.CacheTier
+	if current != nil && (plan.CacheTier == nil || !plan.CacheTier.Pool.Equal(current.Pool)) {
+		if err := r.client.RemoveCacheTier(base, current.Pool.ValueString()); err != nil {
+			return err
+		}
+		current = nil
+	}
+	tier := plan.CacheTier
+	if tier == nil {
+		return nil
+	}
+	cache := tier.Pool.ValueString()
+
+	if current == nil {
+		if err := r.client.AddCacheTier(base, cache, tier.Mode.ValueString()); err != nil {
+			return err
+		}
+		current = &poolCacheTierModel{
+			Mode:           tier.Mode,
+			HitSetType:     types.StringNull(),
+			HitSetCount:    types.Int64Null(),
+			HitSetPeriod:   durationNull(),
+			TargetMaxBytes: sizeNull(),
+		}
+	} else if !tier.Mode.Equal(current.Mode) {
+		if _, err := r.client.ExecuteCommand(NewCommand("ceph", "osd", "tier", "cache-mode").Arg(cache, tier.Mode.ValueString())); err != nil {
+			return err
+		}
+	}
+
+	// Settings left out of the block keep whatever the cache pool has.
+	var settings []*CommandBuilder
+	if !tier.HitSetType.IsNull() && !tier.HitSetType.Equal(current.HitSetType) {
+		settings = append(settings, NewCommand("ceph", "osd", "pool", "set").Arg(cache, "hit_set_type", tier.HitSetType.ValueString()))
+	}
+	if !tier.HitSetCount.IsNull() && !tier.HitSetCount.Equal(current.HitSetCount) {
+		settings = append(settings, NewCommand("ceph", "osd", "pool", "set").Arg(cache, "hit_set_count").Int(tier.HitSetCount.ValueInt64()))
+	}
+	if !tier.HitSetPeriod.IsNull() && tier.HitSetPeriod.Seconds() != current.HitSetPeriod.Seconds() {
+		settings = append(settings, NewCommand("ceph", "osd", "pool", "set").Arg(cache, "hit_set_period").Int(int64(tier.HitSetPeriod.Seconds())))
+	}
+	if !tier.TargetMaxBytes.IsNull() && bytesOrZero(tier.TargetMaxBytes) != bytesOrZero(current.TargetMaxBytes) {
+		settings = append(settings, NewCommand("ceph", "osd", "pool", "set").Arg(cache, "target_max_bytes").Int(tier.TargetMaxBytes.Bytes()))
+	}
+	for _, cmd := range settings {
+		if _, err := r.client.ExecuteCommand(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readCacheTier refreshes a managed cache_tier block from the cluster. It
+// returns nil when the cache pool is gone, is no longer a tier of base or
+// no longer overlays it, so the plan adds the tier again. Settings the
+// block leaves out stay null.
+func (r *poolResource) readCacheTier(base *poolDetail, tier *poolCacheTierModel) (*poolCacheTierModel, error) {
+	cache, err := r.client.GetPoolDetail(tier.Pool.ValueString())
+	if err != nil {
+		return nil, err
+	}
+	if cache == nil || cache.TierOf != base.PoolID || base.ReadTier != cache.PoolID {
+		return nil, nil
+	}
+
+	refreshed := *tier
+	refreshed.Mode = types.StringValue(cache.CacheMode)
+	if !tier.HitSetType.IsNull() {
+		refreshed.HitSetType = types.StringValue(cache.HitSetParams.Type)
+	}
+	if !tier.HitSetCount.IsNull() {
+		refreshed.HitSetCount = types.Int64Value(cache.HitSetCount)
+	}
+	if !tier.HitSetPeriod.IsNull() {
+		refreshed.HitSetPeriod = durationSeconds(float64(cache.HitSetPeriod))
+	}
+	if !tier.TargetMaxBytes.IsNull() {
+		refreshed.TargetMaxBytes = sizeBytes(cache.TargetMaxBytes)
+	}
+	return &refreshed, nil
+}
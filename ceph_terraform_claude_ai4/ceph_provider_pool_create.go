@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strconv"
+)
+
+// Pool creation. Each `ceph` invocation starts a CLI process and a new
+// monitor session, which takes longer than the command itself, so a plan
+// creating dozens of pools spends most of its time connecting. Since
+// Pacific `ceph osd pool create` takes the size, rule and autoscaler mode
+// itself; they are passed there instead of with one `ceph osd pool set`
+// each. Older or undetected releases get the separate commands.
+
+// poolCreateFolded records which settings the create command applied.
+type poolCreateFolded struct {
+	Size          bool
+	CrushRule     bool
+	AutoscaleMode bool
+}
+
+// poolCreateCommand returns the `ceph osd pool create` command for plan,
+// with as many settings folded in as release accepts. Without pg_num the
+// cluster (or its autoscaler) picks it.
+func poolCreateCommand(plan *poolResourceModel, poolType string, release cephRelease) (*CommandBuilder, poolCreateFolded) {
+	cmd := NewCommand("ceph", "osd", "pool", "create").Arg(plan.Name.ValueString())
+	if !plan.PgNum.IsUnknown() {
+		cmd.Int(plan.PgNum.ValueInt64()).Int(plan.PgpNum.ValueInt64())
+	}
+	cmd.Arg(poolType)
+	if !plan.ErasureCodeProfile.IsNull() {
+		cmd.Arg(plan.ErasureCodeProfile.ValueString())
+	}
+
+	var folded poolCreateFolded
+	// The size of an erasure-coded pool comes from its profile.
+	if release < releasePacific || poolType != "replicated" {
+		return cmd, folded
+	}
+	if !plan.Size.IsUnknown() {
+		cmd.Option("--size", strconv.FormatInt(plan.Size.ValueInt64(), 10))
+		folded.Size = true
+	}
+	// A device class rule may not exist yet; applyDeviceClass creates it
+	// after the pool.
+	if !plan.CrushRule.IsUnknown() && !plan.CrushRule.IsNull() && plan.DeviceClass.IsNull() {
+		cmd.Option("--rule", plan.CrushRule.ValueString())
+		folded.CrushRule = true
+	}
+	if !plan.PgAutoscaleMode.IsUnknown() && !plan.PgAutoscaleMode.IsNull() {
+		cmd.Option("--autoscale_mode", plan.PgAutoscaleMode.ValueString())
+		folded.AutoscaleMode = true
+	}
+	return cmd, folded
+}
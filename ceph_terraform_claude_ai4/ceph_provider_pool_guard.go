@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Guardrails against rebalance-heavy pool changes. Changing pg_num,
+// pgp_num or size moves data across the cluster, and shrinking a quota can
+// stop writes; with max_change_percent set, a change larger than that in
+// either direction fails the plan unless force is set, so a typo cannot
+// start moving a production pool or fill it up. Changes
+// Ceph cannot make in place at all are planned as replacements, which
+// delete_protection and confirm_data_loss still guard.
+
+// changePercent returns how much to differs from from, in percent of from.
+func changePercent(from, to int64) int64 {
+	if from <= 0 || from == to {
+		return 0
+	}
+	diff := to - from
+	if diff < 0 {
+		diff = -diff
+	}
+	return (diff*100 + from - 1) / from
+}
+
+// checkPoolChangeGuard reports each guarded attribute whose planned change
+// exceeds plan.MaxChangePercent.
+func checkPoolChangeGuard(plan, state *poolResourceModel, diags *diag.Diagnostics) {
+	if plan.MaxChangePercent.IsNull() || plan.MaxChangePercent.IsUnknown() || plan.Force.ValueBool() {
+		return
+	}
+	limit := plan.MaxChangePercent.ValueInt64()
+
+	type guardedChange struct {
+		name     string
+		from, to int64
+		effect   string
+	}
+	// Only changes between two set values are guarded; a quota added or
+	// removed has no baseline to compare against.
+	var guarded []guardedChange
+	for _, attr := range []struct {
+		name     string
+		from, to types.Int64
+	}{
+		{"pg_num", state.PgNum, plan.PgNum},
+		{"pgp_num", state.PgpNum, plan.PgpNum},
+		{"size", state.Size, plan.Size},
+		{"quota_max_objects", state.QuotaMaxObjects, plan.QuotaMaxObjects},
+	} {
+		if attr.from.IsNull() || attr.to.IsNull() || attr.to.IsUnknown() {
+			continue
+		}
+		effect := "would move a large share of the pool's data"
+		if attr.name == "quota_max_objects" {
+			effect = "could stop writes to the pool or lift a limit it relies on"
+		}
+		guarded = append(guarded, guardedChange{attr.name, attr.from.ValueInt64(), attr.to.ValueInt64(), effect})
+	}
+	if from, to := state.QuotaMaxBytes, plan.QuotaMaxBytes; !from.IsNull() && !to.IsNull() && !to.IsUnknown() {
+		guarded = append(guarded, guardedChange{"quota_max_bytes", from.Bytes(), to.Bytes(),
+			"could stop writes to the pool or lift a limit it relies on"})
+	}
+
+	for _, attr := range guarded {
+		if pct := changePercent(attr.from, attr.to); pct > limit {
+			diags.AddAttributeError(path.Root(attr.name), "Pool change exceeds max_change_percent",
+				fmt.Sprintf("Changing %s of pool %s from %d to %d is a %d%% change, more than max_change_percent (%d%%), "+
+					"and %s. If this is intended, set force = true for this apply.",
+					attr.name, plan.Name.ValueString(), attr.from, attr.to, pct, limit, attr.effect))
+		}
+	}
+}
+
+// poolReplacements returns the planned changes Ceph cannot make to an
+// existing pool, which replace it instead: its type, its erasure code
+// profile, its name unless allow_rename is set, and, before Nautilus, a
+// lower pg_num. release is the cluster's release, 0 when unknown.
+func poolReplacements(plan, state *poolResourceModel, release cephRelease) path.Paths {
+	var replace path.Paths
+	if !plan.Name.Equal(state.Name) && !plan.AllowRename.ValueBool() {
+		replace = append(replace, path.Root("name"))
+	}
+	if !plan.Type.IsUnknown() && !state.Type.IsNull() && !plan.Type.Equal(state.Type) {
+		replace = append(replace, path.Root("type"))
+	}
+	// The profile is only compared once managed, so naming the profile a
+	// pool already has does not replace it.
+	if !plan.ErasureCodeProfile.IsNull() && !plan.ErasureCodeProfile.IsUnknown() && !state.ErasureCodeProfile.IsNull() &&
+		!plan.ErasureCodeProfile.Equal(state.ErasureCodeProfile) {
+		replace = append(replace, path.Root("erasure_code_profile"))
+	}
+	if release != 0 && release < releaseNautilus && !plan.PgNum.IsUnknown() && !plan.PgNum.IsNull() && !state.PgNum.IsNull() &&
+		plan.PgNum.ValueInt64() < state.PgNum.ValueInt64() {
+		replace = append(replace, path.Root("pg_num"))
+	}
+	return replace
+}
+
+// validatePoolLayout reports pool types, sizes and PG counts Ceph would
+// refuse, and a pg_num that is not a power of two, which leaves PGs of
+// uneven size.
+func validatePoolLayout(config *poolResourceModel, diags *diag.Diagnostics) {
+	if t := config.Type; !t.IsNull() && !t.IsUnknown() && t.ValueString() != "replicated" && t.ValueString() != "erasure" {
+		diags.AddAttributeError(path.Root("type"), "Invalid pool type",
+			fmt.Sprintf("type must be replicated or erasure, not %q", t.ValueString()))
+	}
+
+	known := func(v types.Int64) bool { return !v.IsNull() && !v.IsUnknown() }
+	for name, value := range map[string]types.Int64{
+		"pg_num":   config.PgNum,
+		"pgp_num":  config.PgpNum,
+		"size":     config.Size,
+		"min_size": config.MinSize,
+	} {
+		if known(value) && value.ValueInt64() < 1 {
+			diags.AddAttributeError(path.Root(name), "Invalid "+name, fmt.Sprintf("%s must be at least 1", name))
+		}
+	}
+	if known(config.Size) && known(config.MinSize) && config.MinSize.ValueInt64() > config.Size.ValueInt64() {
+		diags.AddAttributeError(path.Root("min_size"), "min_size exceeds size",
+			fmt.Sprintf("min_size (%d) cannot be larger than size (%d); Ceph refuses the change",
+				config.MinSize.ValueInt64(), config.Size.ValueInt64()))
+	}
+	if known(config.PgNum) && known(config.PgpNum) && config.PgpNum.ValueInt64() > config.PgNum.ValueInt64() {
+		diags.AddAttributeError(path.Root("pgp_num"), "pgp_num exceeds pg_num",
+			fmt.Sprintf("pgp_num (%d) cannot be larger than pg_num (%d)", config.PgpNum.ValueInt64(), config.PgNum.ValueInt64()))
+	}
+	if n := config.PgNum.ValueInt64(); known(config.PgNum) && n > 0 && n&(n-1) != 0 {
+		diags.AddAttributeWarning(path.Root("pg_num"), "pg_num is not a power of two",
+			fmt.Sprintf("With pg_num = %d some PGs hold twice as much data as others. Use a power of two such as %d.",
+				n, nextPowerOfTwo(n)))
+	}
+}
+
+// nextPowerOfTwo returns the smallest power of two that is at least n.
+func nextPowerOfTwo(n int64) int64 {
+	p := int64(1)
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Pool Quota Resource
+//
+// Manages only the quotas of a pool that exists already, for teams that
+// own the quotas but not the pool. Destroying the resource clears the
+// quotas again. ceph_pool refreshes quotas too, so a pool managed by
+// ceph_pool needs ignore_changes on its quota attributes.
+type poolQuotaResource struct {
+	client *CephClient
+}
+
+type poolQuotaResourceModel struct {
+	ID         types.String `tfsdk:"id"`
+	Pool       types.String `tfsdk:"pool"`
+	MaxBytes   sizeValue    `tfsdk:"max_bytes"`
+	MaxObjects types.Int64  `tfsdk:"max_objects"`
+}
+
+func NewPoolQuotaResource() resource.Resource {
+	return &poolQuotaResource{}
+}
+
+// SetPoolQuota sets the quotas that differ from the current ones, as
+// `ceph osd pool set-quota` takes them: 0 clears a quota.
+func (c *CephClient) SetPoolQuota(pool string, maxBytes, maxObjects, currentBytes, currentObjects int64) error {
+	if maxBytes != currentBytes {
+		cmd := NewCommand("ceph", "osd", "pool", "set-quota").Arg(pool, "max_bytes").Int(maxBytes)
+		if _, err := c.ExecuteCommand(cmd); err != nil {
+			return err
+		}
+	}
+	if maxObjects != currentObjects {
+		cmd := NewCommand("ceph", "osd", "pool", "set-quota").Arg(pool, "max_objects").Int(maxObjects)
+		if _, err := c.ExecuteCommand(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *poolQuotaResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_pool_quota"
+}
+
+func (r *poolQuotaResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages the quotas of an existing Ceph pool",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Pool name"),
+			"pool": schema.StringAttribute{
+				Description: "Name of the existing pool",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"max_bytes": schema.StringAttribute{
+				Description: "Pool quota size, in bytes or with a unit such as 100G (unlimited when unset)",
+				Optional:    true,
+				CustomType:  sizeType{},
+			},
+			"max_objects": schema.Int64Attribute{
+				Description: "Pool quota in objects (unlimited when unset)",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func (r *poolQuotaResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *poolQuotaResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config poolQuotaResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.MaxObjects.IsNull() && !config.MaxObjects.IsUnknown() && config.MaxObjects.ValueInt64() < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_objects"), "Invalid quota",
+			"max_objects cannot be negative; leave it unset for no quota")
+	}
+}
+
+func (r *poolQuotaResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan poolQuotaResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pool := plan.Pool.ValueString()
+	detail, err := r.client.GetPoolDetail(pool)
+	if err == nil && detail == nil {
+		err = fmt.Errorf("pool %s does not exist", pool)
+	}
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read pool", err)
+		return
+	}
+
+	// Quotas the pool already has are replaced, or cleared when unset.
+	maxBytes, maxObjects := poolQuota(plan.MaxBytes, plan.MaxObjects)
+	if err := r.client.SetPoolQuota(pool, maxBytes, maxObjects, detail.QuotaMaxBytes, detail.QuotaMaxObjects); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to set pool quota", err)
+		return
+	}
+
+	plan.ID = plan.Pool
+
+	tflog.Info(ctx, "Set Ceph pool quota", map[string]interface{}{
+		"pool":        pool,
+		"max_bytes":   maxBytes,
+		"max_objects": maxObjects,
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *poolQuotaResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state poolQuotaResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	detail, err := r.client.GetPoolDetail(state.Pool.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read pool", err)
+		return
+	}
+	if detail == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	state.ID = state.Pool
+	state.MaxBytes = sizeNull()
+	if detail.QuotaMaxBytes > 0 {
+		state.MaxBytes = sizeBytes(detail.QuotaMaxBytes)
+	}
+	state.MaxObjects = optionalInt64(detail.QuotaMaxObjects)
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *poolQuotaResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan poolQuotaResourceModel
+	var state poolQuotaResourceModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	maxBytes, maxObjects := poolQuota(plan.MaxBytes, plan.MaxObjects)
+	currentBytes, currentObjects := poolQuota(state.MaxBytes, state.MaxObjects)
+	if err := r.client.SetPoolQuota(plan.Pool.ValueString(), maxBytes, maxObjects, currentBytes, currentObjects); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update pool quota", err)
+		return
+	}
+
+	plan.ID = plan.Pool
+
+	tflog.Info(ctx, "Updated Ceph pool quota", map[string]interface{}{
+		"pool":        plan.Pool.ValueString(),
+		"max_bytes":   maxBytes,
+		"max_objects": maxObjects,
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *poolQuotaResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state poolQuotaResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The pool itself may be gone already, taking its quotas with it.
+	pool := state.Pool.ValueString()
+	detail, err := r.client.GetPoolDetail(pool)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read pool", err)
+		return
+	}
+	if detail == nil {
+		return
+	}
+
+	if err := r.client.SetPoolQuota(pool, 0, 0, detail.QuotaMaxBytes, detail.QuotaMaxObjects); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to clear pool quota", err)
+		return
+	}
+
+	tflog.Info(ctx, "Cleared Ceph pool quota", map[string]interface{}{
+		"pool": pool,
+	})
+}
+
+// ImportState adopts the quotas of an existing pool by its name, e.g.
+// `terraform import ceph_pool_quota.data data`.
+func (r *poolQuotaResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("pool"), req, resp)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// RenamePool renames a pool. Clients and caps that name the old pool are
+// not updated.
+func (c *CephClient) RenamePool(oldName, newName string) error {
+	_, err := c.ExecuteCommand(NewCommand("ceph", "osd", "pool", "rename").Arg(oldName, newName))
+	return err
+}
+
+// EnsurePoolRenamed renames a pool unless that already happened: a pool
+// that carries the new name while the old one is gone is taken as renamed,
+// so a retried apply succeeds. It returns the renamed pool and whether
+// this call renamed it.
+func (c *CephClient) EnsurePoolRenamed(oldName, newName string) (*poolDetail, bool, error) {
+	oldPool, err := c.GetPoolDetail(oldName)
+	if err != nil {
+		return nil, false, err
+	}
+	newPool, err := c.GetPoolDetail(newName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch {
+	case oldPool != nil && newPool != nil:
+		return nil, false, fmt.Errorf("cannot rename pool %s to %s: a pool named %s already exists", oldName, newName, newName)
+	case oldPool == nil && newPool == nil:
+		return nil, false, fmt.Errorf("cannot rename pool %s to %s: neither pool exists", oldName, newName)
+	case oldPool == nil:
+		return newPool, false, nil
+	}
+	if err := c.RenamePool(oldName, newName); err != nil {
+		return nil, false, err
+	}
+	return oldPool, true, nil
+}
+
+// OSD Pool Rename Resource
+//
+// An action resource: creating it renames the pool. It is meant for
+// migrations where the ceph_pool resource is moved to the new name by hand
+// (terraform state mv or an import), so the rename itself is still applied
+// and recorded by Terraform. A pool that already carries the new name and
+// no longer has the old one is taken as renamed, so a retried apply
+// succeeds. Destroying the resource only drops it from state.
+type poolRenameResource struct {
+	client *CephClient
+}
+
+type poolRenameResourceModel struct {
+	ID        types.String `tfsdk:"id"`
+	OldName   types.String `tfsdk:"old_name"`
+	NewName   types.String `tfsdk:"new_name"`
+	Triggers  types.Map    `tfsdk:"triggers"`
+	PoolID    types.Int64  `tfsdk:"pool_id"`
+	RenamedAt types.String `tfsdk:"renamed_at"`
+}
+
+func NewPoolRenameResource() resource.Resource {
+	return &poolRenameResource{}
+}
+
+func (r *poolRenameResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_osd_pool_rename"
+}
+
+func (r *poolRenameResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	requiresReplace := []planmodifier.String{
+		stringplanmodifier.RequiresReplace(),
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Renames a pool with `ceph osd pool rename`, for migrations where the pool's own resource is moved in state by hand",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Rename in old_name:new_name form"),
+			"old_name": schema.StringAttribute{
+				Description:   "Current name of the pool",
+				Required:      true,
+				PlanModifiers: requiresReplace,
+			},
+			"new_name": schema.StringAttribute{
+				Description:   "Name to give the pool",
+				Required:      true,
+				PlanModifiers: requiresReplace,
+			},
+			"triggers": schema.MapAttribute{
+				Description: "Arbitrary values that run the rename again when changed",
+				ElementType: types.StringType,
+				Optional:    true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"pool_id": schema.Int64Attribute{
+				Description: "Numeric id of the renamed pool, which the rename keeps",
+				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"renamed_at": schema.StringAttribute{
+				Description: "RFC 3339 time the pool was renamed",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *poolRenameResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *poolRenameResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config poolRenameResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || config.OldName.IsUnknown() || config.NewName.IsUnknown() {
+		return
+	}
+
+	if config.OldName.ValueString() == config.NewName.ValueString() {
+		resp.Diagnostics.AddAttributeError(path.Root("new_name"), "Invalid pool rename",
+			fmt.Sprintf("new_name must differ from old_name, got %q for both", config.NewName.ValueString()))
+	}
+}
+
+func (r *poolRenameResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan poolRenameResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	oldName, newName := plan.OldName.ValueString(), plan.NewName.ValueString()
+	pool, renamed, err := r.client.EnsurePoolRenamed(oldName, newName)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to rename pool", err)
+		return
+	}
+	plan.PoolID = types.Int64Value(pool.PoolID)
+	tflog.Info(ctx, "Renamed Ceph pool", map[string]interface{}{
+		"old_name":        oldName,
+		"new_name":        newName,
+		"already_renamed": !renamed,
+	})
+
+	plan.ID = types.StringValue(oldName + ":" + newName)
+	plan.RenamedAt = types.StringValue(time.Now().UTC().Format(time.RFC3339))
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *poolRenameResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// The rename is a one-off action; there is nothing to refresh.
+}
+
+func (r *poolRenameResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Every argument forces replacement, so Update is never called with a
+	// change that needs applying.
+	var plan poolRenameResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *poolRenameResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Info(ctx, "Removed Ceph pool rename from state; the pool keeps its new name")
+}
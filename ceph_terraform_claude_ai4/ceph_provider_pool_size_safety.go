@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Pool size safety. min_size = 1 lets a pool accept writes with a single
+// copy, so losing that one disk loses acknowledged data; min_size = size
+// blocks I/O as soon as any replica is down, which tempts operators into
+// lowering min_size in a hurry. Both are classic causes of data-loss
+// incidents, so plans that produce them are reported according to the
+// provider's pool_size_safety setting.
+
+const (
+	poolSizeSafetyWarn   = "warn"
+	poolSizeSafetyError  = "error"
+	poolSizeSafetyAdjust = "adjust"
+	poolSizeSafetyOff    = "off"
+)
+
+// parsePoolSizeSafety validates the provider setting; empty means warn.
+func parsePoolSizeSafety(mode string) (string, error) {
+	switch mode {
+	case "":
+		return poolSizeSafetyWarn, nil
+	case poolSizeSafetyWarn, poolSizeSafetyError, poolSizeSafetyAdjust, poolSizeSafetyOff:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("pool_size_safety must be warn, error, adjust or off, not %q", mode)
+	}
+}
+
+// safeMinSize returns the min_size Ceph picks by default for a size, which
+// is above 1 and below size for any size of 3 or more.
+func safeMinSize(size int64) int64 {
+	return size - size/2
+}
+
+// checkPoolSizeSafety reports an unsafe size/min_size combination in the
+// plan. In adjust mode a min_size left to the cluster is planned as
+// safeMinSize instead; configured values are never changed, only
+// reported. It returns whether plan.MinSize was adjusted.
+func checkPoolSizeSafety(mode string, minSizeConfigured bool, plan *poolResourceModel, diags *diag.Diagnostics) bool {
+	if mode == "" {
+		mode = poolSizeSafetyWarn
+	}
+	if mode == poolSizeSafetyOff || plan.Size.IsNull() || plan.Size.IsUnknown() {
+		return false
+	}
+	size := plan.Size.ValueInt64()
+
+	adjusted := false
+	if mode == poolSizeSafetyAdjust && !minSizeConfigured && size >= 3 {
+		if plan.MinSize.IsUnknown() || plan.MinSize.IsNull() || !poolSizeSafe(size, plan.MinSize.ValueInt64()) {
+			adjusted = !plan.MinSize.Equal(types.Int64Value(safeMinSize(size)))
+			plan.MinSize = types.Int64Value(safeMinSize(size))
+		}
+	}
+	if plan.MinSize.IsNull() || plan.MinSize.IsUnknown() {
+		return adjusted
+	}
+	minSize := plan.MinSize.ValueInt64()
+
+	var problem string
+	switch {
+	case minSize <= 1:
+		problem = fmt.Sprintf("Pool %s would accept writes with a single copy (size %d, min_size %d); "+
+			"losing that one OSD loses acknowledged data.", plan.Name.ValueString(), size, minSize)
+	case minSize >= size:
+		problem = fmt.Sprintf("Pool %s would block I/O as soon as one replica is down (size %d, min_size %d).",
+			plan.Name.ValueString(), size, minSize)
+	default:
+		return adjusted
+	}
+	if size >= 3 {
+		problem += fmt.Sprintf(" min_size = %d is the usual choice for size %d.", safeMinSize(size), size)
+	}
+	problem += " Set pool_size_safety on the provider to change how this is reported."
+
+	if mode == poolSizeSafetyError {
+		diags.AddAttributeError(path.Root("min_size"), "Unsafe pool size and min_size", problem)
+	} else {
+		diags.AddAttributeWarning(path.Root("min_size"), "Unsafe pool size and min_size", problem)
+	}
+	return adjusted
+}
+
+// poolSizeSafe reports whether min_size leaves room for one failure without
+// accepting single-copy writes.
+func poolSizeSafe(size, minSize int64) bool {
+	return minSize > 1 && minSize < size
+}
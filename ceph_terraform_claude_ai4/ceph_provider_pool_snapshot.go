@@ -0,0 +1,358 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	dsschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Pool snapshots: `ceph osd pool mksnap` snapshots every object of a pool
+// at once. A pool uses either these or self-managed snapshots, which RBD
+// and CephFS take per image or directory; once a pool has had one
+// self-managed snapshot the monitors refuse pool snapshots on it.
+
+// poolSnapshot is one entry of pool_snaps in `ceph osd pool ls detail`.
+type poolSnapshot struct {
+	SnapID int64  `json:"snapid"`
+	Stamp  string `json:"stamp"`
+	Name   string `json:"name"`
+}
+
+// SelfManagedSnaps reports whether the pool is in self-managed snapshot
+// mode, which excludes pool snapshots.
+func (p *poolDetail) SelfManagedSnaps() bool {
+	for _, flag := range strings.Split(p.FlagsNames, ",") {
+		if flag == "selfmanaged_snaps" {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot returns the pool snapshot with the given name, or nil.
+func (p *poolDetail) Snapshot(name string) *poolSnapshot {
+	for i := range p.PoolSnaps {
+		if p.PoolSnaps[i].Name == name {
+			return &p.PoolSnaps[i]
+		}
+	}
+	return nil
+}
+
+// poolSnapshotID returns the id of a pool snapshot, pool@snapshot as
+// rbd writes image snapshots.
+func poolSnapshotID(pool, snapshot string) string {
+	return pool + "@" + snapshot
+}
+
+// Pool Snapshot Resource
+type poolSnapshotResource struct {
+	client *CephClient
+}
+
+type poolSnapshotResourceModel struct {
+	ID      types.String `tfsdk:"id"`
+	Pool    types.String `tfsdk:"pool"`
+	Name    types.String `tfsdk:"name"`
+	SnapID  types.Int64  `tfsdk:"snap_id"`
+	Created types.String `tfsdk:"created"`
+}
+
+func NewPoolSnapshotResource() resource.Resource {
+	return &poolSnapshotResource{}
+}
+
+func (r *poolSnapshotResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_pool_snapshot"
+}
+
+func (r *poolSnapshotResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages a RADOS pool snapshot (`ceph osd pool mksnap`)",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Snapshot id in pool@snapshot form"),
+			"pool": schema.StringAttribute{
+				Description: "Pool to snapshot",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Description: "Snapshot name",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"snap_id": schema.Int64Attribute{
+				Description: "Snapshot id the monitors assigned",
+				Computed:    true,
+			},
+			"created": schema.StringAttribute{
+				Description: "Time the snapshot was taken, as the monitors report it",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (r *poolSnapshotResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *poolSnapshotResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan poolSnapshotResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pool, name := plan.Pool.ValueString(), plan.Name.ValueString()
+	detail, err := r.client.GetPoolDetail(pool)
+	if err == nil && detail == nil {
+		err = fmt.Errorf("pool %s does not exist", pool)
+	}
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read pool", err)
+		return
+	}
+	// The monitors' own error for this is "pool is in unmanaged snaps
+	// mode", which does not say why.
+	if detail.SelfManagedSnaps() {
+		resp.Diagnostics.AddError("Pool uses self-managed snapshots",
+			fmt.Sprintf("Pool %s has self-managed snapshots, such as RBD image or CephFS snapshots, and cannot "+
+				"also have pool snapshots. Snapshot its images or directories instead.", pool))
+		return
+	}
+
+	if _, err := r.client.ExecuteCommand(NewCommand("ceph", "osd", "pool", "mksnap").Arg(pool, name)); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create pool snapshot", err)
+		return
+	}
+
+	detail, err = r.client.GetPoolDetail(pool)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read pool", err)
+		return
+	}
+	var snap *poolSnapshot
+	if detail != nil {
+		snap = detail.Snapshot(name)
+	}
+	if snap == nil {
+		resp.Diagnostics.AddError("Pool snapshot not found",
+			fmt.Sprintf("`ceph osd pool mksnap` succeeded but pool %s does not list snapshot %s.", pool, name))
+		return
+	}
+
+	plan.ID = types.StringValue(poolSnapshotID(pool, name))
+	plan.SnapID = types.Int64Value(snap.SnapID)
+	plan.Created = types.StringValue(snap.Stamp)
+
+	tflog.Info(ctx, "Created Ceph pool snapshot", map[string]interface{}{
+		"pool":     pool,
+		"snapshot": name,
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *poolSnapshotResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state poolSnapshotResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	detail, err := r.client.GetPoolDetail(state.Pool.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read pool", err)
+		return
+	}
+	var snap *poolSnapshot
+	if detail != nil {
+		snap = detail.Snapshot(state.Name.ValueString())
+	}
+	if snap == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	state.ID = types.StringValue(poolSnapshotID(state.Pool.ValueString(), state.Name.ValueString()))
+	state.SnapID = types.Int64Value(snap.SnapID)
+	state.Created = types.StringValue(snap.Stamp)
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Update is never called with a change: every argument forces a new
+// snapshot.
+func (r *poolSnapshotResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan poolSnapshotResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *poolSnapshotResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state poolSnapshotResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The pool may be gone already, taking its snapshots with it.
+	pool, name := state.Pool.ValueString(), state.Name.ValueString()
+	detail, err := r.client.GetPoolDetail(pool)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read pool", err)
+		return
+	}
+	if detail == nil || detail.Snapshot(name) == nil {
+		return
+	}
+
+	if _, err := r.client.ExecuteCommand(NewCommand("ceph", "osd", "pool", "rmsnap").Arg(pool, name)); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to delete pool snapshot", err)
+		return
+	}
+
+	tflog.Info(ctx, "Deleted Ceph pool snapshot", map[string]interface{}{
+		"pool":     pool,
+		"snapshot": name,
+	})
+}
+
+// Pool Snapshots Data Source
+type poolSnapshotsDataSource struct {
+	client *CephClient
+}
+
+type poolSnapshotsDataSourceModel struct {
+	ID        types.String         `tfsdk:"id"`
+	Pool      types.String         `tfsdk:"pool"`
+	NameRegex types.String         `tfsdk:"name_regex"`
+	Limit     types.Int64          `tfsdk:"limit"`
+	Names     types.List           `tfsdk:"names"`
+	Snapshots []poolSnapshotsEntry `tfsdk:"snapshots"`
+}
+
+type poolSnapshotsEntry struct {
+	Name    types.String `tfsdk:"name"`
+	SnapID  types.Int64  `tfsdk:"snap_id"`
+	Created types.String `tfsdk:"created"`
+}
+
+func NewPoolSnapshotsDataSource() datasource.DataSource {
+	return &poolSnapshotsDataSource{}
+}
+
+func (d *poolSnapshotsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_pool_snapshots"
+}
+
+func (d *poolSnapshotsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = dsschema.Schema{
+		Description: "Lists the snapshots of a pool",
+		Attributes: withListFilterAttributes(map[string]dsschema.Attribute{
+			"pool": dsschema.StringAttribute{
+				Description: "Pool name",
+				Required:    true,
+			},
+			"names": dsschema.ListAttribute{
+				Description: "Sorted snapshot names",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"snapshots": dsschema.ListNestedAttribute{
+				Description: "Matching snapshots, in the same order as names",
+				Computed:    true,
+				NestedObject: dsschema.NestedAttributeObject{
+					Attributes: map[string]dsschema.Attribute{
+						"name":    dsschema.StringAttribute{Computed: true, Description: "Snapshot name"},
+						"snap_id": dsschema.Int64Attribute{Computed: true, Description: "Snapshot id"},
+						"created": dsschema.StringAttribute{Computed: true, Description: "Time the snapshot was taken"},
+					},
+				},
+			},
+		}),
+	}
+}
+
+func (d *poolSnapshotsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*CephClient)
+}
+
+func (d *poolSnapshotsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state poolSnapshotsDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pool := state.Pool.ValueString()
+	detail, err := d.client.GetPoolDetail(pool)
+	if err == nil && detail == nil {
+		err = fmt.Errorf("pool %s does not exist", pool)
+	}
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read pool", err)
+		return
+	}
+
+	snapshots := make([]string, 0, len(detail.PoolSnaps))
+	for _, snap := range detail.PoolSnaps {
+		snapshots = append(snapshots, snap.Name)
+	}
+	names, err := filterNames(snapshots, state.NameRegex, state.Limit)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Invalid pool snapshot filter", err)
+		return
+	}
+	state.ID = types.StringValue(listID("pool_snapshots", pool))
+	state.Snapshots = make([]poolSnapshotsEntry, 0, len(names))
+	for _, name := range names {
+		snap := detail.Snapshot(name)
+		state.Snapshots = append(state.Snapshots, poolSnapshotsEntry{
+			Name:    types.StringValue(snap.Name),
+			SnapID:  types.Int64Value(snap.SnapID),
+			Created: types.StringValue(snap.Stamp),
+		})
+	}
+
+	state.Names, diags = types.ListValueFrom(ctx, types.StringType, names)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
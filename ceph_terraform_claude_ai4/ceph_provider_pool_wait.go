@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// wait_for_active_clean: a new pool's PGs are created and peered after
+// `ceph osd pool create` returns. I/O to a PG that is not active blocks,
+// so an RBD image created right after the pool can hang or time out.
+// With wait_for_active_clean the pool's create does not finish until
+// every PG is active+clean.
+
+// defaultActiveCleanTimeout is how long the wait lasts without
+// active_clean_timeout.
+const defaultActiveCleanTimeout = 10 * time.Minute
+
+// activeCleanPollInterval is the time between two `ceph pg ls-by-pool`.
+var activeCleanPollInterval = 2 * time.Second
+
+// pgStat is one PG in the output of `ceph pg ls-by-pool`.
+type pgStat struct {
+	PGID  string `json:"pgid"`
+	State string `json:"state"`
+}
+
+// parsePGList parses `ceph pg ls-by-pool`. Nautilus and later wrap the
+// PGs in an object; older releases print the list alone.
+func parsePGList(output string) ([]pgStat, error) {
+	var wrapped struct {
+		PGStats []pgStat `json:"pg_stats"`
+	}
+	if err := json.Unmarshal([]byte(output), &wrapped); err == nil {
+		return wrapped.PGStats, nil
+	}
+	var pgs []pgStat
+	if err := json.Unmarshal([]byte(output), &pgs); err != nil {
+		return nil, fmt.Errorf("failed to parse PG list: %w", err)
+	}
+	return pgs, nil
+}
+
+// pgActiveClean reports whether a PG state such as active+clean+scrubbing
+// serves I/O with every replica in place.
+func pgActiveClean(state string) bool {
+	var active, clean bool
+	for _, part := range strings.Split(state, "+") {
+		active = active || part == "active"
+		clean = clean || part == "clean"
+	}
+	return active && clean
+}
+
+// pgStateSummary counts the PGs that are not active+clean by state, e.g.
+// "3 creating+peering, 1 unknown".
+func pgStateSummary(pgs []pgStat) string {
+	counts := map[string]int{}
+	for _, pg := range pgs {
+		if !pgActiveClean(pg.State) {
+			counts[pg.State]++
+		}
+	}
+	states := make([]string, 0, len(counts))
+	for state := range counts {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+	parts := make([]string, len(states))
+	for i, state := range states {
+		parts[i] = fmt.Sprintf("%d %s", counts[state], state)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WaitForPoolActiveClean polls the pool's PGs until all of them are
+// active+clean, and fails with the states of the others once timeout has
+// passed. A pool whose PGs are not listed yet is still being created.
+func (c *CephClient) WaitForPoolActiveClean(ctx context.Context, pool string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		output, err := c.ReadJSON(NewCommand("ceph", "pg", "ls-by-pool").Arg(pool))
+		if err != nil {
+			return err
+		}
+		pgs, err := parsePGList(output)
+		if err != nil {
+			return err
+		}
+		clean := 0
+		for _, pg := range pgs {
+			if pgActiveClean(pg.State) {
+				clean++
+			}
+		}
+		if len(pgs) > 0 && clean == len(pgs) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if len(pgs) == 0 {
+				return fmt.Errorf("pool %s has no PGs after %s", pool, timeout)
+			}
+			return fmt.Errorf("pool %s has %d of %d PGs active+clean after %s; the others are %s",
+				pool, clean, len(pgs), timeout, pgStateSummary(pgs))
+		case <-time.After(activeCleanPollInterval):
+		}
+	}
+}
+
+// activeCleanTimeout returns the configured wait, or the default.
+func activeCleanTimeout(value durationValue) time.Duration {
+	if value.IsNull() || value.IsUnknown() {
+		return defaultActiveCleanTimeout
+	}
+	return time.Duration(value.Seconds() * float64(time.Second))
+}
+
+// validateActiveCleanWait reports an active_clean_timeout that cannot
+// take effect.
+func validateActiveCleanWait(wait types.Bool, timeout durationValue, diags *diag.Diagnostics) {
+	if timeout.IsNull() || timeout.IsUnknown() {
+		return
+	}
+	if !wait.IsUnknown() && !wait.ValueBool() {
+		diags.AddAttributeError(path.Root("active_clean_timeout"), "active_clean_timeout without wait_for_active_clean",
+			"active_clean_timeout only limits the wait enabled with wait_for_active_clean = true.")
+	}
+	if timeout.Seconds() <= 0 {
+		diags.AddAttributeError(path.Root("active_clean_timeout"), "Invalid active_clean_timeout",
+			"active_clean_timeout must be positive.")
+	}
+}
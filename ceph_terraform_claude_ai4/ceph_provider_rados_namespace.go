@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Caps granted to a namespace user unless overridden; the same grants
+// ceph-csi documents for a radosNamespace.
+const defaultNamespaceMonCaps = "profile rbd"
+
+func defaultNamespaceOSDCaps(pool, namespace string) string {
+	return fmt.Sprintf("profile rbd pool=%s namespace=%s", pool, namespace)
+}
+
+// radosNamespaceID returns the pool/namespace spec used by rbd.
+func radosNamespaceID(pool, namespace string) string {
+	return pool + "/" + namespace
+}
+
+// validateNamespaceCaps checks that every grant in an OSD cap string is
+// restricted to the namespace, so the user cannot reach other tenants'
+// objects in the pool.
+func validateNamespaceCaps(osdCaps, pool, namespace string) error {
+	for _, grant := range strings.Split(osdCaps, ",") {
+		grant = strings.TrimSpace(grant)
+		if grant == "" {
+			continue
+		}
+		var inPool, inNamespace bool
+		for _, field := range strings.Fields(grant) {
+			switch field {
+			case "pool=" + pool:
+				inPool = true
+			case "namespace=" + namespace:
+				inNamespace = true
+			}
+		}
+		if !inPool || !inNamespace {
+			return fmt.Errorf("grant %q must be restricted with pool=%s namespace=%s", grant, pool, namespace)
+		}
+	}
+	return nil
+}
+
+type rbdNamespace struct {
+	Name string `json:"name"`
+}
+
+// ListRBDNamespaces returns the RBD namespaces of a pool, unsorted.
+func (c *CephClient) ListRBDNamespaces(pool string) ([]string, error) {
+	var namespaces []rbdNamespace
+	if err := c.ExecuteJSON(NewCommand("rbd", "namespace", "ls").Option("--pool", pool), &namespaces); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		names = append(names, ns.Name)
+	}
+	return names, nil
+}
+
+func (c *CephClient) RBDNamespaceExists(pool, namespace string) (bool, error) {
+	namespaces, err := c.ListRBDNamespaces(pool)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range namespaces {
+		if name == namespace {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RADOS Namespace Resource
+//
+// RADOS namespaces exist implicitly, but RBD tracks them explicitly with
+// `rbd namespace create`, which is what ceph-csi expects for a
+// radosNamespace. An optional user block creates a client confined to the
+// namespace.
+type radosNamespaceResource struct {
+	client *CephClient
+}
+
+type radosNamespaceResourceModel struct {
+	ID      types.String             `tfsdk:"id"`
+	Pool    types.String             `tfsdk:"pool"`
+	Name    types.String             `tfsdk:"name"`
+	User    *radosNamespaceUserModel `tfsdk:"user"`
+	UserKey types.String             `tfsdk:"user_key"`
+}
+
+type radosNamespaceUserModel struct {
+	Name    types.String `tfsdk:"name"`
+	MonCaps types.String `tfsdk:"mon_caps"`
+	OSDCaps types.String `tfsdk:"osd_caps"`
+}
+
+// caps returns the user's caps, falling back to the namespace defaults.
+func (m *radosNamespaceResourceModel) caps() map[string]string {
+	caps := map[string]string{
+		"mon": defaultNamespaceMonCaps,
+		"osd": defaultNamespaceOSDCaps(m.Pool.ValueString(), m.Name.ValueString()),
+	}
+	if !m.User.MonCaps.IsNull() {
+		caps["mon"] = m.User.MonCaps.ValueString()
+	}
+	if !m.User.OSDCaps.IsNull() {
+		caps["osd"] = m.User.OSDCaps.ValueString()
+	}
+	return caps
+}
+
+func NewRadosNamespaceResource() resource.Resource {
+	return &radosNamespaceResource{}
+}
+
+func (r *radosNamespaceResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rados_namespace"
+}
+
+func (r *radosNamespaceResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages an RBD namespace inside a pool and, optionally, a user confined to it",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Namespace spec in pool/namespace form"),
+			"pool": schema.StringAttribute{
+				Description: "Pool name",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Description: "Namespace name",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"user": schema.SingleNestedAttribute{
+				Description: "Create a user scoped to the namespace",
+				Optional:    true,
+				Attributes: map[string]schema.Attribute{
+					"name": schema.StringAttribute{
+						Description: "Entity name, e.g. client.tenant-a",
+						Required:    true,
+					},
+					"mon_caps": schema.StringAttribute{
+						Description: "Monitor caps (default: profile rbd)",
+						Optional:    true,
+					},
+					"osd_caps": schema.StringAttribute{
+						Description: "OSD caps; every grant must name the pool and namespace (default: profile rbd pool=<pool> namespace=<name>)",
+						Optional:    true,
+					},
+				},
+			},
+			"user_key": schema.StringAttribute{
+				Description: "Key of the scoped user",
+				Computed:    true,
+				Sensitive:   true,
+			},
+		},
+	}
+}
+
+func (r *radosNamespaceResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *radosNamespaceResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config radosNamespaceResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.Name.IsUnknown() && strings.ContainsAny(config.Name.ValueString(), "/@") {
+		resp.Diagnostics.AddAttributeError(path.Root("name"), "Invalid namespace name",
+			"Namespace names must not contain '/' or '@'")
+	}
+
+	if config.User == nil {
+		return
+	}
+	if !config.User.Name.IsUnknown() && !strings.HasPrefix(config.User.Name.ValueString(), "client.") {
+		resp.Diagnostics.AddAttributeError(path.Root("user").AtName("name"), "Invalid user name",
+			fmt.Sprintf("Namespace users must be client entities, got %q", config.User.Name.ValueString()))
+	}
+	if config.User.OSDCaps.IsNull() || config.User.OSDCaps.IsUnknown() || config.Pool.IsUnknown() || config.Name.IsUnknown() {
+		return
+	}
+	if err := validateNamespaceCaps(config.User.OSDCaps.ValueString(), config.Pool.ValueString(), config.Name.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("user").AtName("osd_caps"), "OSD caps not scoped to the namespace", err.Error())
+	}
+}
+
+func (r *radosNamespaceResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan radosNamespaceResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	cmd := NewCommand("rbd", "namespace", "create").
+		Option("--pool", plan.Pool.ValueString()).Option("--namespace", plan.Name.ValueString())
+	if _, err := r.client.ExecuteCommand(cmd); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create namespace", err)
+		return
+	}
+
+	plan.ID = types.StringValue(radosNamespaceID(plan.Pool.ValueString(), plan.Name.ValueString()))
+	plan.UserKey = types.StringNull()
+
+	if plan.User != nil {
+		// Record the namespace before creating its user; see
+		// setPartialState.
+		resp.Diagnostics.Append(setPartialState(ctx, &resp.State, plan)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		key, err := r.client.ImportAuth(plan.User.Name.ValueString(), plan.caps(), false)
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to create namespace user", err)
+			return
+		}
+		plan.UserKey = types.StringValue(key)
+	}
+
+	tflog.Info(ctx, "Created Ceph RBD namespace", map[string]interface{}{
+		"namespace": plan.ID.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *radosNamespaceResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state radosNamespaceResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	exists, err := r.client.RBDNamespaceExists(state.Pool.ValueString(), state.Name.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read namespace", err)
+		return
+	}
+	if !exists {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	state.ID = types.StringValue(radosNamespaceID(state.Pool.ValueString(), state.Name.ValueString()))
+
+	if state.User != nil {
+		key, err := r.client.GetAuthKey(state.User.Name.ValueString())
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to read namespace user", err)
+			return
+		}
+		state.UserKey = types.StringValue(key)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *radosNamespaceResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan radosNamespaceResourceModel
+	var state radosNamespaceResourceModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only the user block can change in place. A renamed or removed user
+	// is deleted; caps of a kept user are replaced without rotating its key.
+	renamed := state.User != nil && (plan.User == nil || !plan.User.Name.Equal(state.User.Name))
+	if renamed {
+		if err := r.client.DeleteAuth(state.User.Name.ValueString()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to delete namespace user", err)
+			return
+		}
+	}
+
+	plan.UserKey = types.StringNull()
+	if plan.User != nil {
+		exists := state.User != nil && !renamed
+		key, err := r.client.ImportAuth(plan.User.Name.ValueString(), plan.caps(), exists)
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update namespace user", err)
+			return
+		}
+		plan.UserKey = types.StringValue(key)
+	}
+
+	plan.ID = types.StringValue(radosNamespaceID(plan.Pool.ValueString(), plan.Name.ValueString()))
+
+	tflog.Info(ctx, "Updated Ceph RBD namespace", map[string]interface{}{
+		"namespace": plan.ID.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *radosNamespaceResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state radosNamespaceResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if state.User != nil {
+		if err := r.client.DeleteAuth(state.User.Name.ValueString()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to delete namespace user", err)
+			return
+		}
+	}
+
+	cmd := NewCommand("rbd", "namespace", "remove").
+		Option("--pool", state.Pool.ValueString()).Option("--namespace", state.Name.ValueString())
+	if _, err := r.client.ExecuteCommand(cmd); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove namespace", err)
+		return
+	}
+
+	tflog.Info(ctx, "Removed Ceph RBD namespace", map[string]interface{}{
+		"namespace": radosNamespaceID(state.Pool.ValueString(), state.Name.ValueString()),
+	})
+}
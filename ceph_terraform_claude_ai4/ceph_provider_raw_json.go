@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	dsschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Data sources expose the JSON document they were built from as raw_json,
+// so fields the schema does not model yet can be read with jsondecode()
+// without waiting for a provider release.
+
+func rawJSONAttribute(source string) dsschema.StringAttribute {
+	return dsschema.StringAttribute{
+		Description: fmt.Sprintf("Full %s document as compact JSON, for fields not modelled as attributes; decode with jsondecode()", source),
+		Computed:    true,
+	}
+}
+
+// rawJSONValue compacts a JSON document so the attribute does not change
+// with Ceph's whitespace.
+func rawJSONValue(raw []byte) (types.String, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return types.StringNull(), fmt.Errorf("invalid JSON document: %w", err)
+	}
+	return types.StringValue(buf.String()), nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RBD clone chains. A clone reads the data it has not written yet from its
+// parent snapshot, and the parent may itself be a clone. Every level adds
+// a lookup to reads of unwritten extents, so deep chains are slow until
+// the clones are flattened.
+
+// rbdMaxCloneDepth bounds the walk up a clone chain, so metadata pointing
+// in a circle cannot hang a refresh.
+const rbdMaxCloneDepth = 64
+
+// rbdParent is the parent of a clone as `rbd info` prints it. A parent
+// deleted while it still had clones sits in the trash and can only be
+// addressed by id.
+type rbdParent struct {
+	Pool          string `json:"pool"`
+	PoolNamespace string `json:"pool_namespace"`
+	Image         string `json:"image"`
+	ID            string `json:"id"`
+	Snapshot      string `json:"snapshot"`
+	Trash         bool   `json:"trash"`
+}
+
+// String returns the parent snapshot as pool[/namespace]/image@snapshot.
+func (p *rbdParent) String() string {
+	spec := blockImageID(p.Pool, p.Image)
+	if p.PoolNamespace != "" {
+		spec = blockImageID(p.Pool, p.PoolNamespace+"/"+p.Image)
+	}
+	return spec + "@" + p.Snapshot
+}
+
+// infoCommand is the `rbd info` command for the parent image itself.
+func (p *rbdParent) infoCommand() *CommandBuilder {
+	cmd := NewCommand("rbd", "info").Option("--pool", p.Pool)
+	if p.PoolNamespace != "" {
+		cmd.Option("--namespace", p.PoolNamespace)
+	}
+	if p.Trash {
+		return cmd.Option("--image-id", p.ID)
+	}
+	return cmd.Option("--image", p.Image)
+}
+
+// parseRBDParent returns the parent in the output of `rbd info`, or nil if
+// the image is not a clone.
+func parseRBDParent(output string) (*rbdParent, error) {
+	var info struct {
+		Parent *rbdParent `json:"parent"`
+	}
+	if err := json.Unmarshal([]byte(output), &info); err != nil {
+		return nil, fmt.Errorf("failed to parse image info: %w", err)
+	}
+	return info.Parent, nil
+}
+
+// RBDCloneDepth follows a clone's parents and returns how many images the
+// chain above the clone holds: 1 when parent is not a clone itself, 0 when
+// parent is nil because the image is not a clone.
+func (c *CephClient) RBDCloneDepth(parent *rbdParent) (int64, error) {
+	var depth int64
+	for next := parent; next != nil; depth++ {
+		if depth == rbdMaxCloneDepth {
+			return 0, fmt.Errorf("clone chain above %s is deeper than %d images", parent, rbdMaxCloneDepth)
+		}
+		output, err := c.ReadJSON(next.infoCommand())
+		if err != nil {
+			return 0, fmt.Errorf("failed to read parent %s: %w", next, err)
+		}
+		if next, err = parseRBDParent(output); err != nil {
+			return 0, err
+		}
+	}
+	return depth, nil
+}
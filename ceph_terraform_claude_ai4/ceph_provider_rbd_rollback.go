@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// RBD Rollback Resource
+//
+// An action resource: creating it rolls the image back to the snapshot.
+// Every argument forces replacement, so changing the snapshot or the
+// triggers performs another rollback. Destroying it only drops it from
+// state; the image is not touched.
+type rbdRollbackResource struct {
+	client *CephClient
+}
+
+type rbdRollbackResourceModel struct {
+	ID           types.String `tfsdk:"id"`
+	Pool         types.String `tfsdk:"pool"`
+	Image        types.String `tfsdk:"image"`
+	Snapshot     types.String `tfsdk:"snapshot"`
+	Confirm      types.Bool   `tfsdk:"confirm"`
+	Triggers     types.Map    `tfsdk:"triggers"`
+	RolledBackAt types.String `tfsdk:"rolled_back_at"`
+}
+
+func NewRBDRollbackResource() resource.Resource {
+	return &rbdRollbackResource{}
+}
+
+func (r *rbdRollbackResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rbd_rollback"
+}
+
+func (r *rbdRollbackResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	requiresReplace := []planmodifier.String{
+		stringplanmodifier.RequiresReplace(),
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Rolls an RBD image back to a snapshot with `rbd snap rollback`. The image's current contents are overwritten; unmap it or stop its clients first",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Snapshot spec in pool/image@snapshot form"),
+			"pool": schema.StringAttribute{
+				Description:   "Pool name",
+				Required:      true,
+				PlanModifiers: requiresReplace,
+			},
+			"image": schema.StringAttribute{
+				Description:   "Image name",
+				Required:      true,
+				PlanModifiers: requiresReplace,
+			},
+			"snapshot": schema.StringAttribute{
+				Description:   "Snapshot to roll back to",
+				Required:      true,
+				PlanModifiers: requiresReplace,
+			},
+			"confirm": schema.BoolAttribute{
+				Description: "Must be true; acknowledges that data written since the snapshot is lost",
+				Required:    true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"triggers": schema.MapAttribute{
+				Description: "Arbitrary values that perform another rollback when changed",
+				ElementType: types.StringType,
+				Optional:    true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"rolled_back_at": schema.StringAttribute{
+				Description: "RFC 3339 time the rollback completed",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *rbdRollbackResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *rbdRollbackResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config rbdRollbackResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || config.Confirm.IsUnknown() {
+		return
+	}
+
+	if !config.Confirm.ValueBool() {
+		resp.Diagnostics.AddAttributeError(path.Root("confirm"), "Rollback not confirmed",
+			"Rolling back overwrites the image with the snapshot contents. Set confirm = true to proceed")
+	}
+}
+
+func (r *rbdRollbackResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan rbdRollbackResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	spec := fmt.Sprintf("%s@%s",
+		blockImageID(plan.Pool.ValueString(), plan.Image.ValueString()),
+		plan.Snapshot.ValueString())
+
+	_, err := r.client.ExecuteCommand(NewCommand("rbd", "snap", "rollback").Arg(spec).Confirmed())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to roll back RBD image", err)
+		return
+	}
+
+	plan.ID = types.StringValue(spec)
+	plan.RolledBackAt = types.StringValue(time.Now().UTC().Format(time.RFC3339))
+
+	tflog.Info(ctx, "Rolled back Ceph block image", map[string]interface{}{
+		"snapshot": spec,
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rbdRollbackResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// The rollback is a one-off action; there is nothing to refresh.
+}
+
+func (r *rbdRollbackResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Every argument forces replacement, so Update is never called with a
+	// change that needs applying.
+	var plan rbdRollbackResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rbdRollbackResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Info(ctx, "Removed Ceph RBD rollback from state; the image is unchanged")
+}
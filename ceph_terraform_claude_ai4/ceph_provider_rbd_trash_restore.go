@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Trashed image as listed by `rbd trash ls --format json`
+type trashedImage struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// TrashedImages lists the images in a pool's trash.
+func (c *CephClient) TrashedImages(pool string) ([]trashedImage, error) {
+	var images []trashedImage
+	if err := c.ExecuteJSON(NewCommand("rbd", "trash", "ls").Arg(pool), &images); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// RestoreTrashedImage moves an image out of the trash under the given name.
+func (c *CephClient) RestoreTrashedImage(pool, id, name string) error {
+	_, err := c.ExecuteCommand(NewCommand("rbd", "trash", "restore").
+		Option("--image", name).
+		Arg(blockImageID(pool, id)))
+	return err
+}
+
+// imageID returns the internal id of an image, or "" if it does not exist.
+func (c *CephClient) imageID(pool, name string) (string, error) {
+	var info struct {
+		ID string `json:"id"`
+	}
+	if err := c.ExecuteJSON(NewCommand("rbd", "info").Arg(blockImageID(pool, name)), &info); err != nil {
+		if strings.Contains(err.Error(), "No such file or directory") {
+			return "", nil
+		}
+		return "", err
+	}
+	return info.ID, nil
+}
+
+// RBD Trash Restore Resource
+//
+// An action resource: creating it restores the image from the trash. It
+// records a recovery after an accidental delete in the plan. If the image
+// is no longer in the trash but an image with the same id already has the
+// target name, the restore counts as done, so a retried apply succeeds.
+// Destroying it only drops it from state; the image is kept.
+type rbdTrashRestoreResource struct {
+	client *CephClient
+}
+
+type rbdTrashRestoreResourceModel struct {
+	ID           types.String `tfsdk:"id"`
+	Pool         types.String `tfsdk:"pool"`
+	ImageID      types.String `tfsdk:"image_id"`
+	Name         types.String `tfsdk:"name"`
+	Triggers     types.Map    `tfsdk:"triggers"`
+	OriginalName types.String `tfsdk:"original_name"`
+	RestoredAt   types.String `tfsdk:"restored_at"`
+}
+
+func NewRBDTrashRestoreResource() resource.Resource {
+	return &rbdTrashRestoreResource{}
+}
+
+func (r *rbdTrashRestoreResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rbd_trash_restore"
+}
+
+func (r *rbdTrashRestoreResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	requiresReplace := []planmodifier.String{
+		stringplanmodifier.RequiresReplace(),
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Restores an RBD image from the trash with `rbd trash restore`, for recovering from accidental deletes",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Restored image in pool/name form"),
+			"pool": schema.StringAttribute{
+				Description:   "Pool whose trash holds the image",
+				Required:      true,
+				PlanModifiers: requiresReplace,
+			},
+			"image_id": schema.StringAttribute{
+				Description:   "Id of the trashed image, as shown by `rbd trash ls`",
+				Required:      true,
+				PlanModifiers: requiresReplace,
+			},
+			"name": schema.StringAttribute{
+				Description:   "Name to restore the image under",
+				Required:      true,
+				PlanModifiers: requiresReplace,
+			},
+			"triggers": schema.MapAttribute{
+				Description: "Arbitrary values that run the restore again when changed",
+				ElementType: types.StringType,
+				Optional:    true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.RequiresReplace(),
+				},
+			},
+			"original_name": schema.StringAttribute{
+				Description: "Name the image had when it was moved to the trash",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"restored_at": schema.StringAttribute{
+				Description: "RFC 3339 time the image was restored",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *rbdTrashRestoreResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *rbdTrashRestoreResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan rbdTrashRestoreResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	pool, id, name := plan.Pool.ValueString(), plan.ImageID.ValueString(), plan.Name.ValueString()
+	trashed, err := r.client.TrashedImages(pool)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to list RBD trash", err)
+		return
+	}
+	var entry *trashedImage
+	for i := range trashed {
+		if trashed[i].ID == id {
+			entry = &trashed[i]
+			break
+		}
+	}
+
+	existingID, err := r.client.imageID(pool, name)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to look up RBD image", err)
+		return
+	}
+
+	switch {
+	case entry == nil && existingID == id:
+		tflog.Info(ctx, "RBD image already restored", map[string]interface{}{
+			"image": blockImageID(pool, name),
+			"id":    id,
+		})
+		plan.OriginalName = types.StringNull()
+	case entry == nil:
+		resp.Diagnostics.AddError("Image not in trash",
+			fmt.Sprintf("No image with id %s is in the trash of pool %s. Run `rbd trash ls %s` to find the id", id, pool, pool))
+		return
+	case existingID != "":
+		resp.Diagnostics.AddError("Image already exists",
+			fmt.Sprintf("Cannot restore image %s: an image named %s already exists", id, blockImageID(pool, name)))
+		return
+	default:
+		if err := r.client.RestoreTrashedImage(pool, id, name); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to restore RBD image", err)
+			return
+		}
+		plan.OriginalName = types.StringValue(entry.Name)
+		tflog.Info(ctx, "Restored Ceph block image from trash", map[string]interface{}{
+			"image": blockImageID(pool, name),
+			"id":    id,
+		})
+	}
+
+	plan.ID = types.StringValue(blockImageID(pool, name))
+	plan.RestoredAt = types.StringValue(time.Now().UTC().Format(time.RFC3339))
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rbdTrashRestoreResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	// The restore is a one-off action; there is nothing to refresh.
+}
+
+func (r *rbdTrashRestoreResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Every argument forces replacement, so Update is never called with a
+	// change that needs applying.
+	var plan rbdTrashRestoreResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rbdTrashRestoreResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	tflog.Info(ctx, "Removed Ceph RBD trash restore from state; the image is kept")
+}
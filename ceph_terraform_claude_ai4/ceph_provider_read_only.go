@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Read-only mode. With read_only set, the client runs only commands known
+// to leave the cluster unchanged, so a plan in a production account can be
+// shown to make no changes. The check is an allowlist: a command that is
+// not listed here is refused, including ones added later.
+
+// Commands that only read, keyed by their leading words.
+var readOnlyCommands = []string{
+	"ceph auth export",
+	"ceph auth get",
+	"ceph auth get-key",
+	"ceph auth ls",
+	"ceph config dump",
+	"ceph config get",
+	"ceph config-key dump",
+	"ceph config-key get",
+	"ceph config-key ls",
+	"ceph df",
+	"ceph fs snap-schedule status",
+	"ceph fs subvolume authorized_list",
+	"ceph fs subvolume info",
+	"ceph fs subvolume ls",
+	"ceph fsid",
+	"ceph health",
+	"ceph mgr module ls",
+	"ceph mon dump",
+	"ceph orch ls",
+	"ceph orch ps",
+	"ceph orch upgrade check",
+	"ceph osd crush class ls",
+	"ceph osd crush dump",
+	"ceph osd crush rule dump",
+	"ceph osd crush rule ls",
+	"ceph osd dump",
+	"ceph osd getcrushmap",
+	"ceph osd pool get",
+	"ceph osd pool ls",
+	"ceph osd tree",
+	"ceph pg ls-by-pool",
+	"ceph quorum_status",
+	"ceph smb show",
+	"ceph status",
+	"ceph time-sync-status",
+	"ceph version",
+	"ceph versions",
+	"radosgw-admin bucket list",
+	"radosgw-admin bucket stats",
+	"radosgw-admin metadata get",
+	"radosgw-admin metadata list",
+	"radosgw-admin period get",
+	"radosgw-admin sync status",
+	"radosgw-admin user info",
+	"radosgw-admin user list",
+	"radosgw-admin zonegroup get",
+	"rbd image-meta list",
+	"rbd info",
+	"rbd ls",
+	"rbd config image list",
+	"rbd config pool list",
+	"rbd mirror pool info",
+	"rbd mirror snapshot schedule ls",
+	"rbd namespace ls",
+	"rbd trash ls",
+	// crushtool only compiles and decompiles local files.
+	"crushtool",
+}
+
+// Read-only requests to a daemon, the words after `ceph tell <daemon>`.
+var readOnlyTellCommands = []string{
+	"config get",
+	"config show",
+	"perf dump",
+	"version",
+}
+
+// isReadOnlyCommand reports whether args leave the cluster unchanged.
+func isReadOnlyCommand(args []string) bool {
+	allowed := readOnlyCommands
+	if len(args) >= 3 && args[0] == "ceph" && args[1] == "tell" {
+		args, allowed = args[3:], readOnlyTellCommands
+	}
+	cmd := strings.Join(args, " ")
+	for _, prefix := range allowed {
+		if cmd == prefix || strings.HasPrefix(cmd, prefix+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReadOnly refuses a command that would change the cluster when the
+// client is read-only.
+func (c *CephClient) checkReadOnly(cmd *CommandBuilder) error {
+	args, err := cmd.Args()
+	if !c.ReadOnly || err != nil || isReadOnlyCommand(args) {
+		return nil
+	}
+	return &readOnlyError{Operation: "`" + cmd.String() + "`"}
+}
+
+// readOnlyError reports a change refused in read-only mode.
+type readOnlyError struct {
+	Operation string
+}
+
+func (e *readOnlyError) Error() string {
+	return fmt.Sprintf("the provider is read-only and did not run %s, which would change the cluster", e.Operation)
+}
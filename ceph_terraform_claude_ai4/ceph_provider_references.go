@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Plan-time reference checks. A resource only sees its own configuration,
+// so a pool or rule created by another resource in the same plan looks the
+// same as a typo. Names missing from the cluster are reported as warnings
+// that point at the likely mistake rather than as errors, and a cluster
+// that cannot be queried at plan time skips the checks.
+
+// ListPoolNames returns the names of all pools.
+func (c *CephClient) ListPoolNames() ([]string, error) {
+	output, err := c.ReadJSON(NewCommand("ceph", "osd", "pool", "ls"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pools []string
+	if err := json.Unmarshal([]byte(output), &pools); err != nil {
+		return nil, fmt.Errorf("failed to parse pool list: %w", err)
+	}
+	return pools, nil
+}
+
+// capsPoolReferences returns the pools named by pool= in a cap string.
+// Wildcard matches (pool=rbd*) are skipped.
+func capsPoolReferences(caps string) []string {
+	var pools []string
+	for _, grant := range strings.Split(normalizeCaps(caps), ", ") {
+		for _, field := range strings.Fields(grant) {
+			if value, ok := strings.CutPrefix(field, "pool="); ok && value != "" && !strings.Contains(value, "*") {
+				pools = append(pools, value)
+			}
+		}
+	}
+	return pools
+}
+
+// warnMissingPools warns about each named pool that does not exist.
+func (c *CephClient) warnMissingPools(ctx context.Context, diags *diag.Diagnostics, attr path.Path, names ...string) {
+	if len(names) == 0 {
+		return
+	}
+	existing, err := c.ListPoolNames()
+	if err != nil {
+		tflog.Debug(ctx, "Skipping pool reference check", map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	for _, name := range names {
+		if !containsString(existing, name) {
+			diags.AddAttributeWarning(attr, "Pool not found",
+				fmt.Sprintf("Pool %q does not exist in the cluster. If another resource in this configuration creates it, "+
+					"reference that resource (e.g. ceph_pool.example.name) so it is created first; otherwise check the name "+
+					"for typos. Existing pools: %s", name, strings.Join(existing, ", ")))
+		}
+	}
+}
+
+// warnMissingCrushRule warns when the named CRUSH rule does not exist.
+func (c *CephClient) warnMissingCrushRule(ctx context.Context, diags *diag.Diagnostics, attr path.Path, name string) {
+	rules, err := c.ListCrushRules()
+	if err != nil {
+		tflog.Debug(ctx, "Skipping CRUSH rule reference check", map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	if !containsString(rules, name) {
+		diags.AddAttributeWarning(attr, "CRUSH rule not found",
+			fmt.Sprintf("CRUSH rule %q does not exist in the cluster. If ceph_crush_map adds it in this configuration, "+
+				"make the pool depend on it; otherwise check the name for typos. Existing rules: %s", name, strings.Join(rules, ", ")))
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Release detection. Commands that an older cluster does not know fail with
+// a bare EINVAL or "unrecognized command". The provider detects the release
+// once at Configure and checks it before running a command that needs a
+// newer one, so the error says which release is required instead.
+
+// cephRelease is a Ceph major version, e.g. 17 for Quincy.
+type cephRelease int
+
+const (
+	releaseLuminous cephRelease = 12
+	releaseMimic    cephRelease = 13
+	releaseNautilus cephRelease = 14
+	releaseOctopus  cephRelease = 15
+	releasePacific  cephRelease = 16
+	releaseQuincy   cephRelease = 17
+	releaseReef     cephRelease = 18
+	releaseSquid    cephRelease = 19
+	releaseTentacle cephRelease = 20
+)
+
+var releaseNames = map[cephRelease]string{
+	releaseLuminous: "Luminous",
+	releaseMimic:    "Mimic",
+	releaseNautilus: "Nautilus",
+	releaseOctopus:  "Octopus",
+	releasePacific:  "Pacific",
+	releaseQuincy:   "Quincy",
+	releaseReef:     "Reef",
+	releaseSquid:    "Squid",
+	releaseTentacle: "Tentacle",
+}
+
+func (r cephRelease) String() string {
+	if name, ok := releaseNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("release %d", int(r))
+}
+
+// Oldest release each command needs, keyed by the leading words of the
+// command like capRequirements. Commands not listed run on any release.
+var releaseRequirements = map[string]cephRelease{
+	"ceph config":           releaseMimic,
+	"rbd namespace":         releaseNautilus,
+	"ceph fs subvolume":     releaseNautilus,
+	"ceph fs snap-schedule": releasePacific,
+	"rbd mirror snapshot":   releaseOctopus,
+	"ceph orch":             releaseOctopus,
+	"ceph smb":              releaseSquid,
+}
+
+var versionPattern = regexp.MustCompile(`ceph version (\d+)\.(\d+)\.(\d+)`)
+
+// parseCephVersions returns the oldest release in the output of
+// `ceph versions`, and its full version string. During an upgrade daemons
+// run mixed versions; the oldest one decides what the cluster accepts.
+func parseCephVersions(output string) (cephRelease, string, error) {
+	var versions struct {
+		Overall map[string]int `json:"overall"`
+	}
+	if err := json.Unmarshal([]byte(output), &versions); err != nil {
+		return 0, "", fmt.Errorf("failed to parse ceph versions: %w", err)
+	}
+
+	var oldest []int
+	for version := range versions.Overall {
+		m := versionPattern.FindStringSubmatch(version)
+		if m == nil {
+			continue
+		}
+		parts := make([]int, 3)
+		for i := range parts {
+			parts[i], _ = strconv.Atoi(m[i+1])
+		}
+		if oldest == nil || versionLess(parts, oldest) {
+			oldest = parts
+		}
+	}
+	if oldest == nil {
+		return 0, "", fmt.Errorf("no daemon versions in ceph versions output")
+	}
+	return cephRelease(oldest[0]), fmt.Sprintf("%d.%d.%d", oldest[0], oldest[1], oldest[2]), nil
+}
+
+func versionLess(a, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// detectRelease asks the cluster which versions its daemons run and keeps
+// the oldest for later checks.
+func (c *CephClient) detectRelease() error {
+	output, err := c.ReadJSON(NewCommand("ceph", "versions"))
+	if err != nil {
+		return err
+	}
+	release, version, err := parseCephVersions(output)
+	if err != nil {
+		return err
+	}
+	c.release, c.version = release, version
+	return nil
+}
+
+// requireRelease fails if the detected release is older than min. When the
+// release is unknown, because detection failed, everything is allowed and
+// the cluster has the last word.
+func (c *CephClient) requireRelease(min cephRelease, feature string) error {
+	if c.release == 0 || c.release >= min {
+		return nil
+	}
+	return &unsupportedReleaseError{Feature: feature, Required: min, Release: c.release, Version: c.version}
+}
+
+// checkRelease applies releaseRequirements to a command.
+func (c *CephClient) checkRelease(args []string) error {
+	cmd := strings.Join(args, " ")
+	best := ""
+	for prefix := range releaseRequirements {
+		if (cmd == prefix || strings.HasPrefix(cmd, prefix+" ")) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	return c.requireRelease(releaseRequirements[best], "`"+best+"`")
+}
+
+// unsupportedReleaseError reports a feature the cluster is too old for.
+type unsupportedReleaseError struct {
+	Feature  string
+	Required cephRelease
+	Release  cephRelease
+	Version  string
+}
+
+func (e *unsupportedReleaseError) Error() string {
+	return fmt.Sprintf("%s requires %s or later; the cluster runs %s (%s)",
+		e.Feature, e.Required, e.Release, e.Version)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// require_health: a resource that sets it reads `ceph status` right before
+// each create, update and delete and refuses to run the operation while
+// the cluster is less healthy than required, so a pool change does not
+// land in the middle of a recovery. HEALTH_WARN admits HEALTH_OK too.
+// The check is a read, so it runs in read-only mode as well.
+
+// healthRank orders the cluster health states from healthy to failed.
+var healthRank = map[string]int{
+	"HEALTH_OK":   0,
+	"HEALTH_WARN": 1,
+	"HEALTH_ERR":  2,
+}
+
+func requireHealthAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Description: "Refuse to create, update or delete the resource unless cluster health is at least this good: HEALTH_OK or HEALTH_WARN",
+		Optional:    true,
+	}
+}
+
+// validateRequireHealth reports a require_health that is not a level an
+// operation can require.
+func validateRequireHealth(value types.String, diags *diag.Diagnostics) {
+	if value.IsNull() || value.IsUnknown() {
+		return
+	}
+	switch value.ValueString() {
+	case "HEALTH_OK", "HEALTH_WARN":
+	default:
+		diags.AddAttributeError(path.Root("require_health"), "Invalid require_health",
+			fmt.Sprintf("require_health must be HEALTH_OK or HEALTH_WARN, not %q", value.ValueString()))
+	}
+}
+
+// checkHealth fails with the current health checks unless the cluster is
+// at least as healthy as required. An empty requirement always passes.
+func (c *CephClient) checkHealth(required string) error {
+	if required == "" {
+		return nil
+	}
+	want, ok := healthRank[required]
+	if !ok {
+		return fmt.Errorf("require_health must be HEALTH_OK or HEALTH_WARN, not %q", required)
+	}
+
+	var status statusHealth
+	if err := c.ExecuteJSON(NewCommand("ceph", "status"), &status); err != nil {
+		return err
+	}
+	current := status.Health.Status
+	if rank, ok := healthRank[current]; ok && rank <= want {
+		return nil
+	}
+
+	checks := make([]string, 0, len(status.Health.Checks))
+	for name, check := range status.Health.Checks {
+		checks = append(checks, fmt.Sprintf("%s: %s", name, check.Summary.Message))
+	}
+	sort.Strings(checks)
+	message := fmt.Sprintf("cluster health is %s, require_health is %s", current, required)
+	if len(checks) > 0 {
+		message += ":\n" + strings.Join(checks, "\n")
+	}
+	return errors.New(message)
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RGW user information as reported by `radosgw-admin user info`
+type rgwUserInfo struct {
+	UserID      string   `json:"user_id"`
+	DisplayName string   `json:"display_name"`
+	Email       string   `json:"email"`
+	Suspended   int64    `json:"suspended"`
+	MaxBuckets  int64    `json:"max_buckets"`
+	Keys        []rgwKey `json:"keys"`
+	UserQuota   rgwQuota `json:"user_quota"`
+}
+
+type rgwKey struct {
+	User      string `json:"user"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+type rgwQuota struct {
+	Enabled    bool  `json:"enabled"`
+	MaxSize    int64 `json:"max_size"`
+	MaxObjects int64 `json:"max_objects"`
+}
+
+// rgwUserID builds the RGW user identifier, qualified as tenant$user when
+// the user lives in a tenant.
+func rgwUserID(tenant, uid string) string {
+	if tenant == "" {
+		return uid
+	}
+	return tenant + "$" + uid
+}
+
+// rgwBucketID builds the bucket identifier understood by radosgw-admin,
+// qualified as tenant/bucket when the bucket lives in a tenant.
+func rgwBucketID(tenant, bucket string) string {
+	if tenant == "" {
+		return bucket
+	}
+	return tenant + "/" + bucket
+}
+
+// splitRGWUserID splits a tenant$user identifier into its parts.
+func splitRGWUserID(id string) (tenant, uid string) {
+	if i := strings.Index(id, "$"); i >= 0 {
+		return id[:i], id[i+1:]
+	}
+	return "", id
+}
+
+// RGW bucket statistics as reported by `radosgw-admin bucket stats`
+type rgwBucketStats struct {
+	Bucket string `json:"bucket"`
+	Tenant string `json:"tenant"`
+	ID     string `json:"id"`
+	Owner  string `json:"owner"`
+}
+
+func parseRGWUserInfo(output string) (*rgwUserInfo, error) {
+	var info rgwUserInfo
+	if err := json.Unmarshal([]byte(output), &info); err != nil {
+		return nil, fmt.Errorf("failed to parse RGW user info: %w", err)
+	}
+	return &info, nil
+}
+
+// RGWUserExists reports whether the (tenant-qualified) user id exists.
+func (c *CephClient) RGWUserExists(scope rgwScope, uid string) (bool, error) {
+	var users []string
+	if err := c.ExecuteJSON(rgwCommand(scope, "user", "list"), &users); err != nil {
+		return false, err
+	}
+	for _, user := range users {
+		if user == uid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *CephClient) RGWUserInfo(scope rgwScope, uid string) (*rgwUserInfo, error) {
+	output, err := c.ReadJSON(rgwCommand(scope, "user", "info").OptionEquals("--uid", uid))
+	if err != nil {
+		return nil, err
+	}
+	return parseRGWUserInfo(output)
+}
+
+func (c *CephClient) RGWCreateUser(scope rgwScope, uid, displayName string) (*rgwUserInfo, error) {
+	output, err := c.ExecuteCommand(rgwCommand(scope, "user", "create").
+		OptionEquals("--uid", uid).OptionEquals("--display-name", displayName))
+	if err != nil {
+		return nil, err
+	}
+	return parseRGWUserInfo(output)
+}
+
+// RGWSetUserQuota sets and enables the user-scope quota. A negative value
+// means unlimited; when both are unlimited the quota is disabled.
+func (c *CephClient) RGWSetUserQuota(scope rgwScope, uid string, maxSize, maxObjects int64) error {
+	if maxSize < 0 && maxObjects < 0 {
+		_, err := c.ExecuteCommand(rgwCommand(scope, "quota", "disable", "--quota-scope=user").OptionEquals("--uid", uid))
+		return err
+	}
+
+	cmd := rgwCommand(scope, "quota", "set", "--quota-scope=user").OptionEquals("--uid", uid).
+		OptionEquals("--max-size", strconv.FormatInt(maxSize, 10)).
+		OptionEquals("--max-objects", strconv.FormatInt(maxObjects, 10))
+	if _, err := c.ExecuteCommand(cmd); err != nil {
+		return err
+	}
+	_, err := c.ExecuteCommand(rgwCommand(scope, "quota", "enable", "--quota-scope=user").OptionEquals("--uid", uid))
+	return err
+}
+
+// RGWRemoveBucket removes a bucket. purgeObjects, set from force_destroy,
+// confirms deleting the objects it still holds.
+func (c *CephClient) RGWRemoveBucket(scope rgwScope, bucketID string, purgeObjects bool) error {
+	cmd := rgwCommand(scope, "bucket", "rm").OptionEquals("--bucket", bucketID)
+	if purgeObjects {
+		cmd.Flag("--purge-objects").Confirmed()
+	}
+	_, err := c.ExecuteCommand(cmd)
+	return err
+}
+
+// RGWChownBucket moves a bucket to another user in the same tenant. link
+// moves the bucket entry between the users' bucket lists; chown then
+// rewrites the owner of each object, which takes a while on large buckets.
+// The bucket and its data stay in place.
+func (c *CephClient) RGWChownBucket(scope rgwScope, bucketID, instanceID, uid string) error {
+	link := rgwCommand(scope, "bucket", "link").
+		OptionEquals("--bucket", bucketID).
+		OptionEquals("--bucket-id", instanceID).
+		OptionEquals("--uid", uid)
+	if _, err := c.ExecuteCommand(link); err != nil {
+		return fmt.Errorf("failed to link bucket to %s: %w", uid, err)
+	}
+	chown := rgwCommand(scope, "bucket", "chown").
+		OptionEquals("--bucket", bucketID).
+		OptionEquals("--uid", uid)
+	if _, err := c.ExecuteCommand(chown); err != nil {
+		return fmt.Errorf("failed to change object owners to %s: %w", uid, err)
+	}
+	return nil
+}
+
+func (c *CephClient) RGWRemoveUser(scope rgwScope, uid string) error {
+	_, err := c.ExecuteCommand(rgwCommand(scope, "user", "rm").OptionEquals("--uid", uid))
+	return err
+}
+
+// RGWBucketExists reports whether the (tenant-qualified) bucket exists.
+func (c *CephClient) RGWBucketExists(scope rgwScope, bucketID string) (bool, error) {
+	var buckets []string
+	if err := c.ExecuteJSON(rgwCommand(scope, "metadata", "list", "bucket"), &buckets); err != nil {
+		return false, err
+	}
+	for _, bucket := range buckets {
+		if bucket == bucketID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rgwBucketDataSyncDisabled is the bucket instance flag set by `radosgw-admin
+// bucket sync disable`.
+const rgwBucketDataSyncDisabled = 0x8
+
+// RGWBucketSyncEnabled reports whether multisite sync is enabled for the
+// bucket, from the flags of its instance metadata.
+func (c *CephClient) RGWBucketSyncEnabled(scope rgwScope, bucketID, instanceID string) (bool, error) {
+	var instance struct {
+		Data struct {
+			BucketInfo struct {
+				Flags int64 `json:"flags"`
+			} `json:"bucket_info"`
+		} `json:"data"`
+	}
+	key := "bucket.instance:" + bucketID + ":" + instanceID
+	if err := c.ExecuteJSON(rgwCommand(scope, "metadata", "get").Arg(key), &instance); err != nil {
+		return false, err
+	}
+	return instance.Data.BucketInfo.Flags&rgwBucketDataSyncDisabled == 0, nil
+}
+
+// RGWSetBucketSync enables or disables multisite sync for one bucket. A
+// disabled bucket keeps its data in every zone it already reached.
+func (c *CephClient) RGWSetBucketSync(scope rgwScope, bucketID string, enabled bool) error {
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	_, err := c.ExecuteCommand(rgwCommand(scope, "bucket", "sync", action).OptionEquals("--bucket", bucketID))
+	return err
+}
+
+func (c *CephClient) RGWBucketStats(scope rgwScope, bucketID string) (*rgwBucketStats, error) {
+	var stats rgwBucketStats
+	if err := c.ExecuteJSON(rgwCommand(scope, "bucket", "stats").OptionEquals("--bucket", bucketID), &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// RGWS3ClientForUser returns an S3 client authenticated as the given user,
+// looking up its first key with radosgw-admin.
+func (c *CephClient) RGWS3ClientForUser(scope rgwScope, endpoint, uid string) (*s3Client, error) {
+	if c.ReadOnly {
+		return c.rgwS3Client(endpoint, "", "")
+	}
+	info, err := c.RGWUserInfo(scope, uid)
+	if err != nil {
+		return nil, err
+	}
+	if len(info.Keys) == 0 {
+		return nil, fmt.Errorf("RGW user %s has no S3 keys", uid)
+	}
+	return c.rgwS3Client(endpoint, info.Keys[0].AccessKey, info.Keys[0].SecretKey)
+}
+
+// rgwS3Client returns an S3 client with the given keys. S3 requests only
+// create buckets and change policies, and bypass ExecuteCommand, so they
+// are refused here in read-only mode.
+func (c *CephClient) rgwS3Client(endpoint, accessKey, secretKey string) (*s3Client, error) {
+	if c.ReadOnly {
+		return nil, &readOnlyError{Operation: "S3 requests to " + endpoint}
+	}
+	return newS3Client(endpoint, accessKey, secretKey), nil
+}
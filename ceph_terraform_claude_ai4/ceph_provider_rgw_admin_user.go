@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// SetDashboardRGWCredentials hands the dashboard the keys it uses for the
+// RGW admin API. The keys are passed in files so they never appear in the
+// process list.
+func (c *CephClient) SetDashboardRGWCredentials(accessKey, secretKey string) error {
+	dir, err := os.MkdirTemp("", "ceph-dashboard")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	keys := []struct {
+		command string
+		value   string
+	}{
+		{"set-rgw-api-access-key", accessKey},
+		{"set-rgw-api-secret-key", secretKey},
+	}
+	for _, key := range keys {
+		file := filepath.Join(dir, key.command)
+		if err := os.WriteFile(file, []byte(key.value), 0600); err != nil {
+			return fmt.Errorf("failed to write key file: %w", err)
+		}
+		if _, err := c.ExecuteCommand(NewCommand("ceph", "dashboard", key.command).Option("-i", file)); err != nil {
+			return fmt.Errorf("%s failed: %w", key.command, err)
+		}
+	}
+	return nil
+}
+
+// ResetDashboardRGWCredentials clears the dashboard's RGW admin API keys.
+func (c *CephClient) ResetDashboardRGWCredentials() error {
+	for _, command := range []string{"reset-rgw-api-access-key", "reset-rgw-api-secret-key"} {
+		if _, err := c.ExecuteCommand(NewCommand("ceph", "dashboard", command)); err != nil {
+			return fmt.Errorf("%s failed: %w", command, err)
+		}
+	}
+	return nil
+}
+
+// RGW Admin User Resource
+//
+// Bootstraps the RGW system user the dashboard's Object Gateway pages use
+// and wires its keys into the dashboard. Destroy clears the keys from the
+// dashboard before removing the user.
+type rgwAdminUserResource struct {
+	client *CephClient
+}
+
+type rgwAdminUserResourceModel struct {
+	ID          types.String   `tfsdk:"id"`
+	UID         types.String   `tfsdk:"uid"`
+	DisplayName types.String   `tfsdk:"display_name"`
+	AccessKey   types.String   `tfsdk:"access_key"`
+	SecretKey   types.String   `tfsdk:"secret_key"`
+	RGWScope    *rgwScopeModel `tfsdk:"rgw_scope"`
+}
+
+func NewRGWAdminUserResource() resource.Resource {
+	return &rgwAdminUserResource{}
+}
+
+func (r *rgwAdminUserResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rgw_admin_user"
+}
+
+func (r *rgwAdminUserResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Creates the RGW system user used by the dashboard and configures the dashboard with its keys",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("RGW user id, same as uid"),
+			"uid": schema.StringAttribute{
+				Description: "RGW user id of the system user",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("dashboard"),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"display_name": schema.StringAttribute{
+				Description: "Display name of the system user",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("dashboard"),
+			},
+			"access_key": schema.StringAttribute{
+				Description: "S3 access key handed to the dashboard",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"secret_key": schema.StringAttribute{
+				Description: "S3 secret key handed to the dashboard",
+				Computed:    true,
+				Sensitive:   true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"rgw_scope": rgwScopeAttribute(),
+		},
+	}
+}
+
+func (r *rgwAdminUserResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *rgwAdminUserResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan rgwAdminUserResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uid := plan.UID.ValueString()
+	cmd := rgwCommand(plan.RGWScope.scope(), "user", "create").
+		OptionEquals("--uid", uid).OptionEquals("--display-name", plan.DisplayName.ValueString()).Flag("--system")
+	output, err := r.client.ExecuteCommand(cmd)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create RGW system user", err)
+		return
+	}
+
+	info, err := parseRGWUserInfo(output)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse RGW user info", err)
+		return
+	}
+	if len(info.Keys) == 0 {
+		resp.Diagnostics.AddError("RGW system user has no keys",
+			fmt.Sprintf("radosgw-admin did not return S3 keys for %s", uid))
+		return
+	}
+
+	plan.ID = types.StringValue(uid)
+	plan.AccessKey = types.StringValue(info.Keys[0].AccessKey)
+	plan.SecretKey = types.StringValue(info.Keys[0].SecretKey)
+
+	// Record the user before touching the dashboard; see setPartialState.
+	resp.Diagnostics.Append(setPartialState(ctx, &resp.State, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.SetDashboardRGWCredentials(plan.AccessKey.ValueString(), plan.SecretKey.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to configure dashboard RGW credentials", err)
+		return
+	}
+
+	tflog.Info(ctx, "Created Ceph RGW dashboard system user", map[string]interface{}{
+		"uid": uid,
+	})
+}
+
+func (r *rgwAdminUserResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state rgwAdminUserResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uid := state.UID.ValueString()
+	exists, err := r.client.RGWUserExists(state.RGWScope.scope(), uid)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW system user", err)
+		return
+	}
+	if !exists {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	info, err := r.client.RGWUserInfo(state.RGWScope.scope(), uid)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW system user", err)
+		return
+	}
+
+	state.ID = types.StringValue(uid)
+	state.DisplayName = types.StringValue(info.DisplayName)
+	if len(info.Keys) > 0 {
+		state.AccessKey = types.StringValue(info.Keys[0].AccessKey)
+		state.SecretKey = types.StringValue(info.Keys[0].SecretKey)
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwAdminUserResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan rgwAdminUserResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uid := plan.UID.ValueString()
+	cmd := rgwCommand(plan.RGWScope.scope(), "user", "modify").
+		OptionEquals("--uid", uid).OptionEquals("--display-name", plan.DisplayName.ValueString())
+	if _, err := r.client.ExecuteCommand(cmd); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update RGW system user", err)
+		return
+	}
+
+	// Re-apply the keys in case the dashboard was reset out of band.
+	if err := r.client.SetDashboardRGWCredentials(plan.AccessKey.ValueString(), plan.SecretKey.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to configure dashboard RGW credentials", err)
+		return
+	}
+
+	tflog.Info(ctx, "Updated Ceph RGW dashboard system user", map[string]interface{}{
+		"uid": uid,
+	})
+
+	plan.ID = types.StringValue(uid)
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwAdminUserResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state rgwAdminUserResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.ResetDashboardRGWCredentials(); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to reset dashboard RGW credentials", err)
+		return
+	}
+
+	if err := r.client.RGWRemoveUser(state.RGWScope.scope(), state.UID.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove RGW system user", err)
+		return
+	}
+
+	tflog.Info(ctx, "Removed Ceph RGW dashboard system user", map[string]interface{}{
+		"uid": state.UID.ValueString(),
+	})
+}
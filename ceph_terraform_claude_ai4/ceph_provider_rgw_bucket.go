@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// RGW Bucket Resource
+//
+// Buckets can only be created through the S3 API, so the bucket is created
+// at endpoint with the owner's keys, looked up with radosgw-admin. A new
+// owner is applied in place with radosgw-admin, as recreating the bucket
+// would lose its data. sync_enabled opts a bucket out of multisite
+// replication with `radosgw-admin bucket sync disable`.
+type rgwBucketResource struct {
+	client *CephClient
+}
+
+type rgwBucketResourceModel struct {
+	ID           types.String   `tfsdk:"id"`
+	Name         types.String   `tfsdk:"name"`
+	Tenant       types.String   `tfsdk:"tenant"`
+	Owner        types.String   `tfsdk:"owner"`
+	Endpoint     types.String   `tfsdk:"endpoint"`
+	Policy       types.String   `tfsdk:"policy"`
+	ForceDestroy types.Bool     `tfsdk:"force_destroy"`
+	SyncEnabled  types.Bool     `tfsdk:"sync_enabled"`
+	BucketID     types.String   `tfsdk:"bucket_id"`
+	Tags         types.Map      `tfsdk:"tags"`
+	RGWScope     *rgwScopeModel `tfsdk:"rgw_scope"`
+}
+
+func NewRGWBucketResource() resource.Resource {
+	return &rgwBucketResource{}
+}
+
+func (r *rgwBucketResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rgw_bucket"
+}
+
+func (r *rgwBucketResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages a RADOS Gateway bucket",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Bucket in tenant/bucket form, or the bare name without a tenant"),
+			"name": schema.StringAttribute{
+				Description: "Bucket name",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"tenant": schema.StringAttribute{
+				Description: "RGW tenant of the bucket and its owner",
+				Optional:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"owner": schema.StringAttribute{
+				Description: "Owning user id (without tenant); changing it transfers the bucket in place",
+				Required:    true,
+			},
+			"endpoint": schema.StringAttribute{
+				Description: "RGW S3 endpoint URL used to create the bucket and apply its policy",
+				Required:    true,
+			},
+			"policy": schema.StringAttribute{
+				Description: "JSON bucket policy",
+				Optional:    true,
+			},
+			"force_destroy": schema.BoolAttribute{
+				Description: "Purge objects on destroy instead of failing on a non-empty bucket",
+				Optional:    true,
+			},
+			"sync_enabled": schema.BoolAttribute{
+				Description: "Whether multisite sync replicates the bucket to other zones; false runs `radosgw-admin bucket sync disable`. Removing it enables sync again",
+				Optional:    true,
+			},
+			"bucket_id": schema.StringAttribute{
+				Description: "RGW internal bucket instance id",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"tags":      tagsAttribute(),
+			"rgw_scope": rgwScopeAttribute(),
+		},
+	}
+}
+
+func (r *rgwBucketResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *rgwBucketResource) ownerS3(model *rgwBucketResourceModel) (*s3Client, error) {
+	owner := rgwUserID(model.Tenant.ValueString(), model.Owner.ValueString())
+	return r.client.RGWS3ClientForUser(model.RGWScope.scope(), model.Endpoint.ValueString(), owner)
+}
+
+func (r *rgwBucketResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan rgwBucketResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	s3, err := r.ownerS3(&plan)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to look up bucket owner credentials", err)
+		return
+	}
+
+	if err := s3.CreateBucket(ctx, plan.Name.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create RGW bucket", err)
+		return
+	}
+
+	bucketID := rgwBucketID(plan.Tenant.ValueString(), plan.Name.ValueString())
+	stats, err := r.client.RGWBucketStats(plan.RGWScope.scope(), bucketID)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW bucket", err)
+		return
+	}
+	plan.ID = types.StringValue(bucketID)
+	plan.BucketID = types.StringValue(stats.ID)
+
+	// Record the bucket before applying its policy; see setPartialState.
+	resp.Diagnostics.Append(setPartialState(ctx, &resp.State, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !plan.Policy.IsNull() {
+		if err := s3.PutBucketPolicy(ctx, plan.Name.ValueString(), plan.Policy.ValueString()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to set RGW bucket policy", err)
+			return
+		}
+	}
+
+	// New buckets are synced; only opting out needs a command.
+	if !plan.SyncEnabled.IsNull() && !plan.SyncEnabled.ValueBool() {
+		if err := r.client.RGWSetBucketSync(plan.RGWScope.scope(), bucketID, false); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to disable RGW bucket sync", err)
+			return
+		}
+	}
+
+	if err := r.client.applyRegistryTags(ctx, "rgw_bucket", bucketID, plan.Tags, types.MapNull(types.StringType)); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to set RGW bucket tags", err)
+		return
+	}
+
+	tflog.Info(ctx, "Created Ceph RGW bucket", map[string]interface{}{
+		"bucket": bucketID,
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwBucketResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state rgwBucketResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	bucketID := rgwBucketID(state.Tenant.ValueString(), state.Name.ValueString())
+	exists, err := r.client.RGWBucketExists(state.RGWScope.scope(), bucketID)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW bucket", err)
+		return
+	}
+	if !exists {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	stats, err := r.client.RGWBucketStats(state.RGWScope.scope(), bucketID)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW bucket", err)
+		return
+	}
+
+	_, owner := splitRGWUserID(stats.Owner)
+	state.Owner = types.StringValue(owner)
+	state.ID = types.StringValue(bucketID)
+	state.BucketID = types.StringValue(stats.ID)
+
+	// Only refreshed when managed, so buckets on single-zone clusters do
+	// not read metadata they never use.
+	if !state.SyncEnabled.IsNull() {
+		enabled, err := r.client.RGWBucketSyncEnabled(state.RGWScope.scope(), bucketID, stats.ID)
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to read RGW bucket sync", err)
+			return
+		}
+		state.SyncEnabled = types.BoolValue(enabled)
+	}
+
+	state.Tags, err = r.client.readRegistryTags(ctx, "rgw_bucket", bucketID, state.Tags)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW bucket tags", err)
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwBucketResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan rgwBucketResourceModel
+	var state rgwBucketResourceModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	bucketID := rgwBucketID(plan.Tenant.ValueString(), plan.Name.ValueString())
+	if !plan.Owner.Equal(state.Owner) {
+		owner := rgwUserID(plan.Tenant.ValueString(), plan.Owner.ValueString())
+		if err := r.client.RGWChownBucket(plan.RGWScope.scope(), bucketID, state.BucketID.ValueString(), owner); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to transfer RGW bucket", err)
+			return
+		}
+		tflog.Info(ctx, "Transferred Ceph RGW bucket", map[string]interface{}{
+			"bucket": bucketID,
+			"from":   state.Owner.ValueString(),
+			"to":     plan.Owner.ValueString(),
+		})
+	}
+
+	if !plan.Policy.Equal(state.Policy) {
+		s3, err := r.ownerS3(&plan)
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to look up bucket owner credentials", err)
+			return
+		}
+
+		if plan.Policy.IsNull() {
+			err = s3.DeleteBucketPolicy(ctx, plan.Name.ValueString())
+		} else {
+			err = s3.PutBucketPolicy(ctx, plan.Name.ValueString(), plan.Policy.ValueString())
+		}
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update RGW bucket policy", err)
+			return
+		}
+	}
+
+	if !plan.SyncEnabled.Equal(state.SyncEnabled) {
+		enabled := plan.SyncEnabled.IsNull() || plan.SyncEnabled.ValueBool()
+		if err := r.client.RGWSetBucketSync(plan.RGWScope.scope(), bucketID, enabled); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update RGW bucket sync", err)
+			return
+		}
+	}
+
+	if err := r.client.applyRegistryTags(ctx, "rgw_bucket", bucketID, plan.Tags, state.Tags); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update RGW bucket tags", err)
+		return
+	}
+
+	plan.ID = types.StringValue(bucketID)
+
+	tflog.Info(ctx, "Updated Ceph RGW bucket", map[string]interface{}{
+		"bucket": plan.ID.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwBucketResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state rgwBucketResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	bucketID := rgwBucketID(state.Tenant.ValueString(), state.Name.ValueString())
+	if err := r.client.RGWRemoveBucket(state.RGWScope.scope(), bucketID, state.ForceDestroy.ValueBool()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to delete RGW bucket", err)
+		return
+	}
+	if err := r.client.applyRegistryTags(ctx, "rgw_bucket", bucketID, types.MapNull(types.StringType), state.Tags); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove RGW bucket tags", err)
+		return
+	}
+
+	tflog.Info(ctx, "Deleted Ceph RGW bucket", map[string]interface{}{
+		"bucket": bucketID,
+	})
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// rgwCertificateKey is where cephadm's RGW service looks for the frontend
+// certificate: its beast frontend is configured with
+// ssl_certificate=config://rgw/cert/<service name>.
+func rgwCertificateKey(serviceName string) string {
+	return "rgw/cert/" + serviceName
+}
+
+// RGW Certificate Resource
+//
+// Stores the SSL certificate and key of an RGW service in the config-key
+// store and redeploys the gateways so they load it. The service must be
+// deployed with `ssl: true` and no certificate in its spec, or with a
+// frontend pointing at the same config key.
+type rgwCertificateResource struct {
+	client *CephClient
+}
+
+type rgwCertificateResourceModel struct {
+	ID          types.String `tfsdk:"id"`
+	ServiceName types.String `tfsdk:"service_name"`
+	Certificate types.String `tfsdk:"certificate"`
+	PrivateKey  types.String `tfsdk:"private_key"`
+	Redeploy    types.Bool   `tfsdk:"redeploy"`
+	ConfigKey   types.String `tfsdk:"config_key"`
+	NotAfter    types.String `tfsdk:"not_after"`
+}
+
+func NewRGWCertificateResource() resource.Resource {
+	return &rgwCertificateResource{}
+}
+
+func (r *rgwCertificateResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rgw_certificate"
+}
+
+func (r *rgwCertificateResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages the SSL certificate of an RGW service, stored in the config-key store, and redeploys the gateways when it changes",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Orchestrator service name"),
+			"service_name": schema.StringAttribute{
+				Description: "Orchestrator service name of the gateways, e.g. rgw.default",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"certificate": schema.StringAttribute{
+				Description: "PEM certificate, followed by any intermediate certificates",
+				Required:    true,
+			},
+			"private_key": schema.StringAttribute{
+				Description: "PEM private key of the certificate",
+				Required:    true,
+				Sensitive:   true,
+			},
+			"redeploy": schema.BoolAttribute{
+				Description: "Redeploy the service with `ceph orch redeploy` after storing a new certificate",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(true),
+			},
+			"config_key": schema.StringAttribute{
+				Description: "Config key holding the certificate and key",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"not_after": schema.StringAttribute{
+				Description: "Expiry of the certificate (RFC 3339)",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (r *rgwCertificateResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *rgwCertificateResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config rgwCertificateResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || config.Certificate.IsUnknown() || config.PrivateKey.IsUnknown() {
+		return
+	}
+
+	if err := validateCertificatePair(config.Certificate.ValueString(), config.PrivateKey.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("private_key"), "Invalid certificate", err.Error())
+	}
+}
+
+// apply stores the certificate and, if asked to, redeploys the gateways.
+func (r *rgwCertificateResource) apply(plan *rgwCertificateResourceModel) error {
+	certs, key := splitPEMBundle(plan.Certificate.ValueString() + "\n" + plan.PrivateKey.ValueString())
+	if err := r.client.SetConfigKey(plan.ConfigKey.ValueString(), certs+key); err != nil {
+		return err
+	}
+	if plan.Redeploy.ValueBool() {
+		return r.client.OrchRedeploy(plan.ServiceName.ValueString())
+	}
+	return nil
+}
+
+func (m *rgwCertificateResourceModel) setComputed() {
+	m.ID = types.StringValue(m.ServiceName.ValueString())
+	m.ConfigKey = types.StringValue(rgwCertificateKey(m.ServiceName.ValueString()))
+	m.NotAfter = notAfterValue(m.Certificate.ValueString())
+}
+
+func (r *rgwCertificateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan rgwCertificateResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.setComputed()
+	if err := r.apply(&plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to install RGW certificate", err)
+		return
+	}
+
+	tflog.Info(ctx, "Installed RGW certificate", map[string]interface{}{
+		"service":   plan.ServiceName.ValueString(),
+		"not_after": plan.NotAfter.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwCertificateResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state rgwCertificateResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.ConfigKey = types.StringValue(rgwCertificateKey(state.ServiceName.ValueString()))
+	exists, err := r.client.ConfigKeyExists(state.ConfigKey.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW certificate", err)
+		return
+	}
+	if !exists {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	stored, err := r.client.GetConfigKey(state.ConfigKey.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW certificate", err)
+		return
+	}
+	// Keep the configured spelling unless the stored certificate differs.
+	storedCerts, storedKey := splitPEMBundle(stored)
+	certs, key := splitPEMBundle(state.Certificate.ValueString() + "\n" + state.PrivateKey.ValueString())
+	if storedCerts != certs || storedKey != key {
+		state.Certificate = types.StringValue(storedCerts)
+		state.PrivateKey = types.StringValue(storedKey)
+	}
+	state.setComputed()
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwCertificateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan rgwCertificateResourceModel
+	var state rgwCertificateResourceModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	plan.setComputed()
+	// Toggling redeploy alone does not touch the gateways.
+	if !plan.Certificate.Equal(state.Certificate) || !plan.PrivateKey.Equal(state.PrivateKey) {
+		if err := r.apply(&plan); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update RGW certificate", err)
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Updated RGW certificate", map[string]interface{}{
+		"service":   plan.ServiceName.ValueString(),
+		"not_after": plan.NotAfter.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwCertificateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state rgwCertificateResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The gateways keep serving the loaded certificate until they restart.
+	if err := r.client.RemoveConfigKey(rgwCertificateKey(state.ServiceName.ValueString())); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove RGW certificate", err)
+		return
+	}
+
+	tflog.Info(ctx, "Removed RGW certificate", map[string]interface{}{
+		"service": state.ServiceName.ValueString(),
+	})
+}
@@ -0,0 +1,522 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// RGW cloud transition. A storage class of a zonegroup placement target
+// can be a cloud-s3 tier: lifecycle rules that transition objects to that
+// storage class copy them to an external S3 endpoint. radosgw-admin only
+// takes the tier's credentials in --tier-config, so they are redacted from
+// diagnostics, the command record and the audit log.
+
+// rgwCloudTier is the configuration of a cloud-s3 storage class, as
+// stored in the zonegroup's tier_targets.
+type rgwCloudTier struct {
+	Endpoint               string
+	AccessKey              string
+	Secret                 string
+	Region                 string
+	HostStyle              string
+	TargetPath             string
+	TargetStorageClass     string
+	RetainHeadObject       bool
+	MultipartSyncThreshold int64
+	MultipartMinPartSize   int64
+}
+
+// tierConfig renders the settings for --tier-config. Unset optional
+// settings are left out, so RGW keeps its defaults.
+func (t *rgwCloudTier) tierConfig() string {
+	settings := []string{
+		"endpoint=" + t.Endpoint,
+		"access_key=" + t.AccessKey,
+		"secret=" + t.Secret,
+		"retain_head_object=" + strconv.FormatBool(t.RetainHeadObject),
+	}
+	for _, s := range []struct{ key, value string }{
+		{"region", t.Region},
+		{"host_style", t.HostStyle},
+		{"target_path", t.TargetPath},
+		{"target_storage_class", t.TargetStorageClass},
+	} {
+		if s.value != "" {
+			settings = append(settings, s.key+"="+s.value)
+		}
+	}
+	if t.MultipartSyncThreshold > 0 {
+		settings = append(settings, "multipart_sync_threshold="+strconv.FormatInt(t.MultipartSyncThreshold, 10))
+	}
+	if t.MultipartMinPartSize > 0 {
+		settings = append(settings, "multipart_min_part_size="+strconv.FormatInt(t.MultipartMinPartSize, 10))
+	}
+	return strings.Join(settings, ",")
+}
+
+// rgwZonegroup is the subset of `radosgw-admin zonegroup get` needed for
+// cloud tiers.
+type rgwZonegroup struct {
+	Name             string `json:"name"`
+	PlacementTargets []struct {
+		Name           string   `json:"name"`
+		StorageClasses []string `json:"storage_classes"`
+		TierTargets    []struct {
+			Key string `json:"key"`
+			Val struct {
+				TierType         string `json:"tier_type"`
+				RetainHeadObject string `json:"retain_head_object"`
+				S3               struct {
+					Endpoint    string `json:"endpoint"`
+					Credentials struct {
+						AccessKey string `json:"access_key"`
+						Secret    string `json:"secret"`
+					} `json:"credentials"`
+					Region                 string `json:"region"`
+					HostStyle              string `json:"host_style"`
+					TargetStorageClass     string `json:"target_storage_class"`
+					TargetPath             string `json:"target_path"`
+					MultipartSyncThreshold int64  `json:"multipart_sync_threshold"`
+					MultipartMinPartSize   int64  `json:"multipart_min_part_size"`
+				} `json:"s3"`
+			} `json:"val"`
+		} `json:"tier_targets"`
+	} `json:"placement_targets"`
+}
+
+// parseRGWCloudTier returns the zonegroup name and the cloud-s3 tier of the
+// storage class, or a nil tier if there is none.
+func parseRGWCloudTier(output, placementID, storageClass string) (string, *rgwCloudTier, error) {
+	var zg rgwZonegroup
+	if err := json.Unmarshal([]byte(output), &zg); err != nil {
+		return "", nil, fmt.Errorf("failed to parse zonegroup: %w", err)
+	}
+	for _, target := range zg.PlacementTargets {
+		if target.Name != placementID {
+			continue
+		}
+		for _, tier := range target.TierTargets {
+			if tier.Key != storageClass || tier.Val.TierType != "cloud-s3" {
+				continue
+			}
+			s3 := tier.Val.S3
+			return zg.Name, &rgwCloudTier{
+				Endpoint:               s3.Endpoint,
+				AccessKey:              s3.Credentials.AccessKey,
+				Secret:                 s3.Credentials.Secret,
+				Region:                 s3.Region,
+				HostStyle:              s3.HostStyle,
+				TargetPath:             s3.TargetPath,
+				TargetStorageClass:     s3.TargetStorageClass,
+				RetainHeadObject:       tier.Val.RetainHeadObject == "true",
+				MultipartSyncThreshold: s3.MultipartSyncThreshold,
+				MultipartMinPartSize:   s3.MultipartMinPartSize,
+			}, nil
+		}
+	}
+	return zg.Name, nil, nil
+}
+
+// placementCommand starts a `radosgw-admin zonegroup placement` command for
+// the storage class.
+func placementCommand(verb string, scope rgwScope, placementID, storageClass string) *CommandBuilder {
+	return rgwCommand(scope, "zonegroup", "placement", verb).
+		OptionEquals("--placement-id", placementID).OptionEquals("--storage-class", storageClass)
+}
+
+// RGWCloudTier returns the zonegroup name and the cloud-s3 tier of the
+// storage class, or a nil tier if there is none. A scope without a
+// zonegroup means the default one.
+func (c *CephClient) RGWCloudTier(scope rgwScope, placementID, storageClass string) (string, *rgwCloudTier, error) {
+	output, err := c.ReadJSON(rgwCommand(scope, "zonegroup", "get"))
+	if err != nil {
+		return "", nil, err
+	}
+	return parseRGWCloudTier(output, placementID, storageClass)
+}
+
+// RGWSetCloudTier adds the storage class as a cloud-s3 tier, or with
+// exists set, updates the tier configuration of an existing one. Settings
+// in remove are reset to RGW's defaults.
+func (c *CephClient) RGWSetCloudTier(scope rgwScope, placementID, storageClass string, tier *rgwCloudTier, exists bool, remove []string) error {
+	if !exists {
+		add := placementCommand("add", scope, placementID, storageClass).OptionEquals("--tier-type", "cloud-s3")
+		if _, err := c.ExecuteCommand(add); err != nil {
+			return err
+		}
+	}
+	modify := placementCommand("modify", scope, placementID, storageClass).OptionEquals("--tier-config", tier.tierConfig())
+	if len(remove) > 0 {
+		modify.OptionEquals("--tier-config-rm", strings.Join(remove, ","))
+	}
+	_, err := c.ExecuteCommand(modify)
+	return err
+}
+
+// RGWRemoveCloudTier removes the storage class from the placement target.
+func (c *CephClient) RGWRemoveCloudTier(scope rgwScope, placementID, storageClass string) error {
+	_, err := c.ExecuteCommand(placementCommand("rm", scope, placementID, storageClass))
+	return err
+}
+
+// RGWCommitPeriod commits zonegroup changes to a new period, as a realm
+// needs before the gateways apply them.
+func (c *CephClient) RGWCommitPeriod(scope rgwScope) error {
+	_, err := c.ExecuteCommand(rgwCommand(scope, "period", "update").Flag("--commit"))
+	return err
+}
+
+// RGW Cloud Tier Resource
+type rgwCloudTierResource struct {
+	client *CephClient
+}
+
+type rgwCloudTierResourceModel struct {
+	ID                     types.String   `tfsdk:"id"`
+	Zonegroup              types.String   `tfsdk:"zonegroup"`
+	PlacementID            types.String   `tfsdk:"placement_id"`
+	StorageClass           types.String   `tfsdk:"storage_class"`
+	Endpoint               types.String   `tfsdk:"endpoint"`
+	AccessKey              types.String   `tfsdk:"access_key"`
+	SecretKey              types.String   `tfsdk:"secret_key"`
+	Region                 types.String   `tfsdk:"region"`
+	HostStyle              types.String   `tfsdk:"host_style"`
+	TargetPath             types.String   `tfsdk:"target_path"`
+	TargetStorageClass     types.String   `tfsdk:"target_storage_class"`
+	RetainHeadObject       types.Bool     `tfsdk:"retain_head_object"`
+	MultipartSyncThreshold types.Int64    `tfsdk:"multipart_sync_threshold"`
+	MultipartMinPartSize   types.Int64    `tfsdk:"multipart_min_part_size"`
+	CommitPeriod           types.Bool     `tfsdk:"commit_period"`
+	RGWScope               *rgwScopeModel `tfsdk:"rgw_scope"`
+}
+
+func NewRGWCloudTierResource() resource.Resource {
+	return &rgwCloudTierResource{}
+}
+
+func (r *rgwCloudTierResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rgw_cloud_tier"
+}
+
+func (r *rgwCloudTierResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	replace := []planmodifier.String{stringplanmodifier.RequiresReplace()}
+	resp.Schema = schema.Schema{
+		Description: "Manages a cloud-s3 storage class of an RGW placement target, so lifecycle rules can transition objects to an external S3 endpoint",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("zonegroup/placement_id/storage_class"),
+			"zonegroup": schema.StringAttribute{
+				Description: "Zonegroup of the placement target; the default zonegroup when unset",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"placement_id": schema.StringAttribute{
+				Description:   "Placement target the storage class belongs to (default default-placement)",
+				Optional:      true,
+				Computed:      true,
+				Default:       stringdefault.StaticString("default-placement"),
+				PlanModifiers: replace,
+			},
+			"storage_class": schema.StringAttribute{
+				Description:   "Storage class name that lifecycle transitions refer to, e.g. CLOUDTIER",
+				Required:      true,
+				PlanModifiers: replace,
+			},
+			"endpoint": schema.StringAttribute{
+				Description: "URL of the external S3 endpoint",
+				Required:    true,
+			},
+			"access_key": schema.StringAttribute{
+				Description: "Access key for the external endpoint",
+				Required:    true,
+				Sensitive:   true,
+			},
+			"secret_key": schema.StringAttribute{
+				Description: "Secret key for the external endpoint",
+				Required:    true,
+				Sensitive:   true,
+			},
+			"region": schema.StringAttribute{
+				Description: "Region of the external endpoint",
+				Optional:    true,
+			},
+			"host_style": schema.StringAttribute{
+				Description: "path or virtual addressing on the external endpoint (RGW default path)",
+				Optional:    true,
+			},
+			"target_path": schema.StringAttribute{
+				Description: "Bucket on the external endpoint that objects are copied to; RGW derives one from the zonegroup and storage class when unset",
+				Optional:    true,
+			},
+			"target_storage_class": schema.StringAttribute{
+				Description: "Storage class of the copies on the external endpoint (RGW default STANDARD)",
+				Optional:    true,
+			},
+			"retain_head_object": schema.BoolAttribute{
+				Description: "Keep a zero-size head object locally after transition, so listings still show the object",
+				Optional:    true,
+			},
+			"multipart_sync_threshold": schema.Int64Attribute{
+				Description: "Objects at least this many bytes are copied with multipart uploads",
+				Optional:    true,
+			},
+			"multipart_min_part_size": schema.Int64Attribute{
+				Description: "Minimum part size in bytes for multipart copies",
+				Optional:    true,
+			},
+			"commit_period": schema.BoolAttribute{
+				Description: "Run `radosgw-admin period update --commit` after each change, as clusters with a realm need",
+				Optional:    true,
+			},
+			"rgw_scope": rgwScopeAttribute(),
+		},
+	}
+}
+
+func (r *rgwCloudTierResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *rgwCloudTierResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config rgwCloudTierResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// --tier-config is a comma-separated key=value list.
+	for name, value := range map[string]types.String{
+		"endpoint":             config.Endpoint,
+		"access_key":           config.AccessKey,
+		"secret_key":           config.SecretKey,
+		"region":               config.Region,
+		"target_path":          config.TargetPath,
+		"target_storage_class": config.TargetStorageClass,
+	} {
+		if !value.IsUnknown() && strings.Contains(value.ValueString(), ",") {
+			resp.Diagnostics.AddAttributeError(path.Root(name), "Invalid tier setting",
+				fmt.Sprintf("%s cannot contain a comma, as radosgw-admin takes tier settings as a comma-separated list", name))
+		}
+	}
+	if hs := config.HostStyle; !hs.IsNull() && !hs.IsUnknown() && hs.ValueString() != "path" && hs.ValueString() != "virtual" {
+		resp.Diagnostics.AddAttributeError(path.Root("host_style"), "Invalid host_style",
+			fmt.Sprintf("host_style must be path or virtual, got %q", hs.ValueString()))
+	}
+	if config.RGWScope != nil && !config.RGWScope.Zonegroup.IsNull() && !config.Zonegroup.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("rgw_scope").AtName("zonegroup"), "Conflicting zonegroups",
+			"zonegroup already selects the zonegroup of the tier; leave it out of rgw_scope")
+	}
+}
+
+func (m *rgwCloudTierResourceModel) tier() *rgwCloudTier {
+	return &rgwCloudTier{
+		Endpoint:               m.Endpoint.ValueString(),
+		AccessKey:              m.AccessKey.ValueString(),
+		Secret:                 m.SecretKey.ValueString(),
+		Region:                 m.Region.ValueString(),
+		HostStyle:              m.HostStyle.ValueString(),
+		TargetPath:             m.TargetPath.ValueString(),
+		TargetStorageClass:     m.TargetStorageClass.ValueString(),
+		RetainHeadObject:       m.RetainHeadObject.ValueBool(),
+		MultipartSyncThreshold: m.MultipartSyncThreshold.ValueInt64(),
+		MultipartMinPartSize:   m.MultipartMinPartSize.ValueInt64(),
+	}
+}
+
+// scope returns the selectors of the tier's commands. zonegroup names the
+// zonegroup the tier is in, so it takes the place of rgw_scope's.
+func (m *rgwCloudTierResourceModel) scope() rgwScope {
+	scope := m.RGWScope.scope()
+	if zonegroup := m.Zonegroup.ValueString(); zonegroup != "" {
+		scope.Zonegroup = zonegroup
+	}
+	return scope
+}
+
+func (m *rgwCloudTierResourceModel) id() string {
+	return m.Zonegroup.ValueString() + "/" + m.PlacementID.ValueString() + "/" + m.StorageClass.ValueString()
+}
+
+// apply writes the tier configuration and commits the period if asked to.
+func (r *rgwCloudTierResource) apply(plan *rgwCloudTierResourceModel, exists bool, remove []string) error {
+	err := r.client.RGWSetCloudTier(plan.scope(), plan.PlacementID.ValueString(),
+		plan.StorageClass.ValueString(), plan.tier(), exists, remove)
+	if err != nil {
+		return err
+	}
+	if plan.CommitPeriod.ValueBool() {
+		return r.client.RGWCommitPeriod(plan.scope())
+	}
+	return nil
+}
+
+func (r *rgwCloudTierResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan rgwCloudTierResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.requireRelease(releasePacific, "RGW cloud transition"); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create RGW cloud tier", err)
+		return
+	}
+	zonegroup, existing, err := r.client.RGWCloudTier(plan.scope(), plan.PlacementID.ValueString(), plan.StorageClass.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read zonegroup", err)
+		return
+	}
+	if existing != nil {
+		resp.Diagnostics.AddError("Cloud tier already exists",
+			fmt.Sprintf("Storage class %s of placement target %s in zonegroup %s is already a cloud tier",
+				plan.StorageClass.ValueString(), plan.PlacementID.ValueString(), zonegroup))
+		return
+	}
+	plan.Zonegroup = types.StringValue(zonegroup)
+
+	if err := r.apply(&plan, false, nil); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create RGW cloud tier", err)
+		return
+	}
+	plan.ID = types.StringValue(plan.id())
+
+	tflog.Info(ctx, "Created RGW cloud tier", map[string]interface{}{
+		"id":       plan.ID.ValueString(),
+		"endpoint": plan.Endpoint.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwCloudTierResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state rgwCloudTierResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	_, tier, err := r.client.RGWCloudTier(state.scope(), state.PlacementID.ValueString(), state.StorageClass.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW cloud tier", err)
+		return
+	}
+	if tier == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	state.Endpoint = types.StringValue(tier.Endpoint)
+	state.AccessKey = types.StringValue(tier.AccessKey)
+	state.SecretKey = types.StringValue(tier.Secret)
+	// Optional settings are refreshed only when managed; otherwise RGW's
+	// defaults would show as drift.
+	refreshString := func(attr *types.String, value string) {
+		if !attr.IsNull() {
+			*attr = types.StringValue(value)
+		}
+	}
+	refreshString(&state.Region, tier.Region)
+	refreshString(&state.HostStyle, tier.HostStyle)
+	refreshString(&state.TargetPath, tier.TargetPath)
+	refreshString(&state.TargetStorageClass, tier.TargetStorageClass)
+	if !state.RetainHeadObject.IsNull() {
+		state.RetainHeadObject = types.BoolValue(tier.RetainHeadObject)
+	}
+	if !state.MultipartSyncThreshold.IsNull() {
+		state.MultipartSyncThreshold = types.Int64Value(tier.MultipartSyncThreshold)
+	}
+	if !state.MultipartMinPartSize.IsNull() {
+		state.MultipartMinPartSize = types.Int64Value(tier.MultipartMinPartSize)
+	}
+	state.ID = types.StringValue(state.id())
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwCloudTierResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state rgwCloudTierResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Settings no longer configured go back to RGW's defaults.
+	var remove []string
+	for key, gone := range map[string]bool{
+		"region":                   plan.Region.IsNull() && !state.Region.IsNull(),
+		"host_style":               plan.HostStyle.IsNull() && !state.HostStyle.IsNull(),
+		"target_path":              plan.TargetPath.IsNull() && !state.TargetPath.IsNull(),
+		"target_storage_class":     plan.TargetStorageClass.IsNull() && !state.TargetStorageClass.IsNull(),
+		"multipart_sync_threshold": plan.MultipartSyncThreshold.IsNull() && !state.MultipartSyncThreshold.IsNull(),
+		"multipart_min_part_size":  plan.MultipartMinPartSize.IsNull() && !state.MultipartMinPartSize.IsNull(),
+	} {
+		if gone {
+			remove = append(remove, key)
+		}
+	}
+	sort.Strings(remove)
+
+	if err := r.apply(&plan, true, remove); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update RGW cloud tier", err)
+		return
+	}
+	plan.ID = types.StringValue(plan.id())
+
+	tflog.Info(ctx, "Updated RGW cloud tier", map[string]interface{}{
+		"id": plan.ID.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwCloudTierResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state rgwCloudTierResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.RGWRemoveCloudTier(state.scope(), state.PlacementID.ValueString(), state.StorageClass.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove RGW cloud tier", err)
+		return
+	}
+	if state.CommitPeriod.ValueBool() {
+		if err := r.client.RGWCommitPeriod(state.scope()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to commit RGW period", err)
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Removed RGW cloud tier", map[string]interface{}{
+		"id": state.ID.ValueString(),
+	})
+}
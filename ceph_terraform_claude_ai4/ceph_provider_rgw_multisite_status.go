@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Sync state of one direction as reported by `radosgw-admin sync status`:
+// metadata from the master zone, or data from one source zone.
+type rgwSyncSection struct {
+	SourceZone     string
+	Status         string
+	FullSyncShards int64
+	BehindShards   int64
+	BehindShardIDs []int64
+	OldestChange   string
+}
+
+// CaughtUp reports whether the section has nothing left to sync.
+func (s rgwSyncSection) CaughtUp() bool {
+	status := strings.ToLower(s.Status)
+	return s.BehindShards == 0 && s.FullSyncShards == 0 &&
+		!strings.Contains(status, "fail") && !strings.Contains(status, "error")
+}
+
+type rgwSyncStatus struct {
+	Realm     string
+	Zonegroup string
+	Zone      string
+	Metadata  rgwSyncSection
+	Data      []rgwSyncSection
+}
+
+var (
+	syncNamePattern     = regexp.MustCompile(`^\S+ \((.*)\)$`)
+	syncFullPattern     = regexp.MustCompile(`^full sync: (\d+)/\d+ shards`)
+	syncBehindPattern   = regexp.MustCompile(`is behind on (\d+) shards?`)
+	syncShardIDsPattern = regexp.MustCompile(`^behind shards: \[(.*)\]`)
+	syncOldestPattern   = regexp.MustCompile(`^oldest incremental change not applied: (\S+)`)
+)
+
+// parseRGWSyncStatus parses the output of `radosgw-admin sync status`,
+// which has no JSON form. Each section starts with "metadata sync" or
+// "data sync source:" and is followed by indented detail lines.
+func parseRGWSyncStatus(output string) (*rgwSyncStatus, error) {
+	status := &rgwSyncStatus{}
+	var section *rgwSyncSection
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "realm "):
+			status.Realm = syncName(strings.TrimPrefix(line, "realm "))
+			continue
+		case strings.HasPrefix(line, "zonegroup ") && !strings.HasPrefix(line, "zonegroup features"):
+			status.Zonegroup = syncName(strings.TrimPrefix(line, "zonegroup "))
+			continue
+		case strings.HasPrefix(line, "zone "):
+			status.Zone = syncName(strings.TrimPrefix(line, "zone "))
+			continue
+		case strings.HasPrefix(line, "metadata sync"):
+			section = &status.Metadata
+			section.Status = strings.TrimSpace(strings.TrimPrefix(line, "metadata sync"))
+			continue
+		case strings.HasPrefix(line, "data sync source:"):
+			status.Data = append(status.Data, rgwSyncSection{
+				SourceZone: syncName(strings.TrimSpace(strings.TrimPrefix(line, "data sync source:"))),
+			})
+			section = &status.Data[len(status.Data)-1]
+			continue
+		}
+		if section == nil {
+			continue
+		}
+
+		if m := syncFullPattern.FindStringSubmatch(line); m != nil {
+			section.FullSyncShards, _ = strconv.ParseInt(m[1], 10, 64)
+		} else if m := syncBehindPattern.FindStringSubmatch(line); m != nil {
+			section.BehindShards, _ = strconv.ParseInt(m[1], 10, 64)
+		} else if m := syncShardIDsPattern.FindStringSubmatch(line); m != nil {
+			for _, id := range strings.Split(m[1], ",") {
+				if n, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64); err == nil {
+					section.BehindShardIDs = append(section.BehindShardIDs, n)
+				}
+			}
+		} else if m := syncOldestPattern.FindStringSubmatch(line); m != nil {
+			section.OldestChange = m[1]
+		} else if section.Status == "" {
+			section.Status = line
+		}
+	}
+
+	if status.Zone == "" {
+		return nil, fmt.Errorf("failed to parse sync status: no zone in output %q", output)
+	}
+	return status, nil
+}
+
+// syncName returns the name from an "<id> (<name>)" pair, or the text
+// unchanged if it has no name.
+func syncName(s string) string {
+	if m := syncNamePattern.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	return s
+}
+
+// RGWSyncStatus returns the multisite sync state seen from zone, or from
+// the default zone when zone is empty.
+func (c *CephClient) RGWSyncStatus(scope rgwScope) (*rgwSyncStatus, error) {
+	output, err := c.ReadText(rgwCommand(scope, "sync", "status"))
+	if err != nil {
+		return nil, err
+	}
+	return parseRGWSyncStatus(output)
+}
+
+// RGW Multisite Status Data Source
+type rgwMultisiteStatusDataSource struct {
+	client *CephClient
+}
+
+type rgwMultisiteStatusDataSourceModel struct {
+	ID        types.String       `tfsdk:"id"`
+	Zone      types.String       `tfsdk:"zone"`
+	Realm     types.String       `tfsdk:"realm"`
+	Zonegroup types.String       `tfsdk:"zonegroup"`
+	CaughtUp  types.Bool         `tfsdk:"caught_up"`
+	Metadata  *rgwSyncModel      `tfsdk:"metadata_sync"`
+	Data      []rgwDataSyncModel `tfsdk:"data_sync"`
+	RGWScope  *rgwScopeModel     `tfsdk:"rgw_scope"`
+}
+
+type rgwSyncModel struct {
+	Status       types.String `tfsdk:"status"`
+	CaughtUp     types.Bool   `tfsdk:"caught_up"`
+	BehindShards types.Int64  `tfsdk:"behind_shards"`
+	OldestChange types.String `tfsdk:"oldest_change"`
+}
+
+type rgwDataSyncModel struct {
+	SourceZone     types.String `tfsdk:"source_zone"`
+	Status         types.String `tfsdk:"status"`
+	CaughtUp       types.Bool   `tfsdk:"caught_up"`
+	BehindShards   types.Int64  `tfsdk:"behind_shards"`
+	BehindShardIDs types.List   `tfsdk:"behind_shard_ids"`
+	OldestChange   types.String `tfsdk:"oldest_change"`
+}
+
+func NewRGWMultisiteStatusDataSource() datasource.DataSource {
+	return &rgwMultisiteStatusDataSource{}
+}
+
+func (d *rgwMultisiteStatusDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rgw_multisite_status"
+}
+
+func syncSectionAttributes() map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"status": schema.StringAttribute{
+			Description: "Sync state as printed by radosgw-admin, e.g. syncing or no sync (zone is master)",
+			Computed:    true,
+		},
+		"caught_up": schema.BoolAttribute{
+			Description: "Whether no shards are behind or in full sync and no error is reported",
+			Computed:    true,
+		},
+		"behind_shards": schema.Int64Attribute{
+			Description: "Number of shards with changes not yet applied",
+			Computed:    true,
+		},
+		"oldest_change": schema.StringAttribute{
+			Description: "Timestamp of the oldest change not yet applied; empty when caught up",
+			Computed:    true,
+		},
+	}
+}
+
+func (d *rgwMultisiteStatusDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	dataAttributes := syncSectionAttributes()
+	dataAttributes["source_zone"] = schema.StringAttribute{
+		Description: "Zone the data is synced from",
+		Computed:    true,
+	}
+	dataAttributes["behind_shard_ids"] = schema.ListAttribute{
+		Description: "Ids of the shards that are behind",
+		ElementType: types.Int64Type,
+		Computed:    true,
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Multisite sync status of an RGW zone from `radosgw-admin sync status`",
+		Attributes: map[string]schema.Attribute{
+			"id": dataSourceIDAttribute("Zone name"),
+			"zone": schema.StringAttribute{
+				Description: "Zone to report on; the default zone of the cluster when unset",
+				Optional:    true,
+				Computed:    true,
+			},
+			"realm": schema.StringAttribute{
+				Description: "Realm name",
+				Computed:    true,
+			},
+			"zonegroup": schema.StringAttribute{
+				Description: "Zonegroup name",
+				Computed:    true,
+			},
+			"caught_up": schema.BoolAttribute{
+				Description: "Whether metadata and data sync from every source zone are caught up",
+				Computed:    true,
+			},
+			"metadata_sync": schema.SingleNestedAttribute{
+				Description: "Metadata sync from the master zone",
+				Computed:    true,
+				Attributes:  syncSectionAttributes(),
+			},
+			"data_sync": schema.ListNestedAttribute{
+				Description: "Data sync from each source zone, in the order radosgw-admin reports them",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: dataAttributes,
+				},
+			},
+			"rgw_scope": rgwScopeDataSourceAttribute(),
+		},
+	}
+}
+
+func (d *rgwMultisiteStatusDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*CephClient)
+}
+
+func (d *rgwMultisiteStatusDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state rgwMultisiteStatusDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// zone names the zone to report on, so it takes the place of
+	// rgw_scope's.
+	scope := state.RGWScope.scope()
+	if zone := state.Zone.ValueString(); zone != "" {
+		scope.Zone = zone
+	}
+	status, err := d.client.RGWSyncStatus(scope)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to get RGW sync status", err)
+		return
+	}
+
+	caughtUp := status.Metadata.CaughtUp()
+	state.Metadata = &rgwSyncModel{
+		Status:       types.StringValue(status.Metadata.Status),
+		CaughtUp:     types.BoolValue(status.Metadata.CaughtUp()),
+		BehindShards: types.Int64Value(status.Metadata.BehindShards),
+		OldestChange: types.StringValue(status.Metadata.OldestChange),
+	}
+	state.Data = make([]rgwDataSyncModel, 0, len(status.Data))
+	for _, section := range status.Data {
+		ids, diags := types.ListValueFrom(ctx, types.Int64Type, append([]int64{}, section.BehindShardIDs...))
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		caughtUp = caughtUp && section.CaughtUp()
+		state.Data = append(state.Data, rgwDataSyncModel{
+			SourceZone:     types.StringValue(section.SourceZone),
+			Status:         types.StringValue(section.Status),
+			CaughtUp:       types.BoolValue(section.CaughtUp()),
+			BehindShards:   types.Int64Value(section.BehindShards),
+			BehindShardIDs: ids,
+			OldestChange:   types.StringValue(section.OldestChange),
+		})
+	}
+
+	state.ID = types.StringValue(status.Zone)
+	state.Zone = types.StringValue(status.Zone)
+	state.Realm = types.StringValue(status.Realm)
+	state.Zonegroup = types.StringValue(status.Zonegroup)
+	state.CaughtUp = types.BoolValue(caughtUp)
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Minimal S3 client for the few RGW operations radosgw-admin cannot
+// perform itself (creating buckets, bucket policies). Requests use
+// path-style addressing and AWS Signature Version 4.
+type s3Client struct {
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	Region    string
+	HTTP      *http.Client
+}
+
+func newS3Client(endpoint, accessKey, secretKey string) *s3Client {
+	return &s3Client{
+		Endpoint:  strings.TrimRight(endpoint, "/"),
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Region:    "us-east-1",
+		HTTP:      &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode escapes a string as required by SigV4 canonical requests.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// signV4 adds SigV4 authentication headers to req. The x-amz-date and
+// x-amz-content-sha256 headers are set here and signed together with host.
+func signV4(req *http.Request, payloadHash, accessKey, secretKey, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	shortDate := now.UTC().Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{shortDate, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), shortDate)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func (s *s3Client) do(ctx context.Context, method, bucket string, query url.Values, body []byte) error {
+	u, err := url.Parse(s.Endpoint + "/" + bucket)
+	if err != nil {
+		return fmt.Errorf("invalid S3 endpoint %q: %w", s.Endpoint, err)
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	signV4(req, sha256Hex(body), s.AccessKey, s.SecretKey, s.Region, "s3", time.Now())
+
+	resp, err := s.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("S3 %s %s failed: %w", method, u.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("S3 %s %s returned %s: %s", method, u.Path, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+func (s *s3Client) CreateBucket(ctx context.Context, bucket string) error {
+	return s.do(ctx, http.MethodPut, bucket, nil, nil)
+}
+
+func (s *s3Client) PutBucketPolicy(ctx context.Context, bucket, policy string) error {
+	return s.do(ctx, http.MethodPut, bucket, url.Values{"policy": {""}}, []byte(policy))
+}
+
+func (s *s3Client) DeleteBucketPolicy(ctx context.Context, bucket string) error {
+	return s.do(ctx, http.MethodDelete, bucket, url.Values{"policy": {""}}, nil)
+}
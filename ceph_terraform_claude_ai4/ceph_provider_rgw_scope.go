@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+
+	dsschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// RGW selectors. On a multisite cluster radosgw-admin acts on the default
+// realm, zonegroup and zone of the host it runs on, which is not always
+// the one Terraform manages. The provider's rgw_realm, rgw_zonegroup and
+// rgw_zone are passed to every radosgw-admin command, and RGW resources
+// can override them with rgw_scope. Selectors a command sets itself, such
+// as a cloud tier's zonegroup, are left alone.
+
+// rgwScope selects the realm, zonegroup and zone of radosgw-admin
+// commands; empty fields are not passed.
+type rgwScope struct {
+	Realm     string
+	Zonegroup string
+	Zone      string
+}
+
+// over returns the scope with unset fields taken from defaults.
+func (s rgwScope) over(defaults rgwScope) rgwScope {
+	if s.Realm == "" {
+		s.Realm = defaults.Realm
+	}
+	if s.Zonegroup == "" {
+		s.Zonegroup = defaults.Zonegroup
+	}
+	if s.Zone == "" {
+		s.Zone = defaults.Zone
+	}
+	return s
+}
+
+// apply adds the scope's selectors to a radosgw-admin command, except
+// those it already has.
+func (s rgwScope) apply(cmd *CommandBuilder) *CommandBuilder {
+	if len(cmd.args) == 0 || cmd.args[0] != "radosgw-admin" {
+		return cmd
+	}
+	for _, selector := range []struct{ name, value string }{
+		{"--rgw-realm", s.Realm},
+		{"--rgw-zonegroup", s.Zonegroup},
+		{"--rgw-zone", s.Zone},
+	} {
+		if selector.value != "" && !cmd.hasOption(selector.name) {
+			cmd.OptionEquals(selector.name, selector.value)
+		}
+	}
+	return cmd
+}
+
+// rgwCommand starts a radosgw-admin command in scope, e.g.
+// rgwCommand(scope, "user", "info").
+func rgwCommand(scope rgwScope, words ...string) *CommandBuilder {
+	return scope.apply(NewCommand(append([]string{"radosgw-admin"}, words...)...))
+}
+
+// hasOption reports whether the command has the option, as "name value"
+// or "name=value".
+func (b *CommandBuilder) hasOption(name string) bool {
+	for _, arg := range b.args {
+		if arg == name || strings.HasPrefix(arg, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+type rgwScopeModel struct {
+	Realm     types.String `tfsdk:"realm"`
+	Zonegroup types.String `tfsdk:"zonegroup"`
+	Zone      types.String `tfsdk:"zone"`
+}
+
+// scope returns the selectors of a resource; none when rgw_scope is unset.
+func (m *rgwScopeModel) scope() rgwScope {
+	if m == nil {
+		return rgwScope{}
+	}
+	return rgwScope{
+		Realm:     m.Realm.ValueString(),
+		Zonegroup: m.Zonegroup.ValueString(),
+		Zone:      m.Zone.ValueString(),
+	}
+}
+
+func rgwScopeAttribute() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Description: "Realm, zonegroup and zone radosgw-admin acts on for this resource, over the provider's rgw_realm, rgw_zonegroup and rgw_zone",
+		Optional:    true,
+		Attributes: map[string]schema.Attribute{
+			"realm": schema.StringAttribute{
+				Description: "Realm, passed as --rgw-realm",
+				Optional:    true,
+			},
+			"zonegroup": schema.StringAttribute{
+				Description: "Zonegroup, passed as --rgw-zonegroup",
+				Optional:    true,
+			},
+			"zone": schema.StringAttribute{
+				Description: "Zone, passed as --rgw-zone",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func rgwScopeDataSourceAttribute() dsschema.SingleNestedAttribute {
+	return dsschema.SingleNestedAttribute{
+		Description: "Realm, zonegroup and zone radosgw-admin reads from for this data source, over the provider's rgw_realm, rgw_zonegroup and rgw_zone",
+		Optional:    true,
+		Attributes: map[string]dsschema.Attribute{
+			"realm": dsschema.StringAttribute{
+				Description: "Realm, passed as --rgw-realm",
+				Optional:    true,
+			},
+			"zonegroup": dsschema.StringAttribute{
+				Description: "Zonegroup, passed as --rgw-zonegroup",
+				Optional:    true,
+			},
+			"zone": dsschema.StringAttribute{
+				Description: "Zone, passed as --rgw-zone",
+				Optional:    true,
+			},
+		},
+	}
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// RGW Tenant Resource
+//
+// Bundles the objects a platform team creates when onboarding an
+// object-storage tenant: a service user inside the tenant, its default
+// bucket, a user quota and an optional bucket policy. The user id is
+// always <tenant>$<name> and the bucket defaults to <name>.
+type rgwTenantResource struct {
+	client *CephClient
+}
+
+type rgwTenantResourceModel struct {
+	ID              types.String   `tfsdk:"id"`
+	Tenant          types.String   `tfsdk:"tenant"`
+	Name            types.String   `tfsdk:"name"`
+	DisplayName     types.String   `tfsdk:"display_name"`
+	Bucket          types.String   `tfsdk:"bucket"`
+	Endpoint        types.String   `tfsdk:"endpoint"`
+	QuotaMaxSize    sizeValue      `tfsdk:"quota_max_size"`
+	QuotaMaxObjects types.Int64    `tfsdk:"quota_max_objects"`
+	BucketPolicy    types.String   `tfsdk:"bucket_policy"`
+	ForceDestroy    types.Bool     `tfsdk:"force_destroy"`
+	UID             types.String   `tfsdk:"uid"`
+	AccessKey       types.String   `tfsdk:"access_key"`
+	SecretKey       types.String   `tfsdk:"secret_key"`
+	RGWScope        *rgwScopeModel `tfsdk:"rgw_scope"`
+}
+
+func NewRGWTenantResource() resource.Resource {
+	return &rgwTenantResource{}
+}
+
+func (r *rgwTenantResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rgw_tenant"
+}
+
+func (r *rgwTenantResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages an RGW tenant service account: a user under the tenant, its default bucket, quotas and bucket policy",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Service account user id, same as uid"),
+			"tenant": schema.StringAttribute{
+				Description: "RGW tenant name",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Description: "Service account user name inside the tenant",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"display_name": schema.StringAttribute{
+				Description: "User display name (defaults to name)",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"bucket": schema.StringAttribute{
+				Description: "Default bucket name (defaults to name)",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"endpoint": schema.StringAttribute{
+				Description: "RGW S3 endpoint URL used to create the bucket and apply its policy",
+				Required:    true,
+			},
+			"quota_max_size": schema.StringAttribute{
+				Description: "User quota size, in bytes or with a unit such as 100G (unlimited when unset)",
+				Optional:    true,
+				CustomType:  sizeType{},
+			},
+			"quota_max_objects": schema.Int64Attribute{
+				Description: "User quota in objects (unlimited when unset)",
+				Optional:    true,
+			},
+			"bucket_policy": schema.StringAttribute{
+				Description: "JSON bucket policy applied to the default bucket",
+				Optional:    true,
+			},
+			"force_destroy": schema.BoolAttribute{
+				Description: "Purge bucket objects on destroy instead of failing on a non-empty bucket",
+				Optional:    true,
+			},
+			"uid": schema.StringAttribute{
+				Description: "Fully qualified user id (tenant$name)",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"access_key": schema.StringAttribute{
+				Description: "S3 access key of the service account",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"secret_key": schema.StringAttribute{
+				Description: "S3 secret key of the service account",
+				Computed:    true,
+				Sensitive:   true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"rgw_scope": rgwScopeAttribute(),
+		},
+	}
+}
+
+func (r *rgwTenantResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func optionalQuota(v types.Int64) int64 {
+	if v.IsNull() || v.IsUnknown() {
+		return -1
+	}
+	return v.ValueInt64()
+}
+
+func optionalSizeQuota(v sizeValue) int64 {
+	if v.IsNull() || v.IsUnknown() {
+		return -1
+	}
+	return v.Bytes()
+}
+
+func (r *rgwTenantResource) s3(model *rgwTenantResourceModel) (*s3Client, error) {
+	return r.client.rgwS3Client(model.Endpoint.ValueString(), model.AccessKey.ValueString(), model.SecretKey.ValueString())
+}
+
+func (r *rgwTenantResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan rgwTenantResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if plan.DisplayName.IsUnknown() || plan.DisplayName.IsNull() {
+		plan.DisplayName = plan.Name
+	}
+	if plan.Bucket.IsUnknown() || plan.Bucket.IsNull() {
+		plan.Bucket = plan.Name
+	}
+	uid := rgwUserID(plan.Tenant.ValueString(), plan.Name.ValueString())
+	plan.ID = types.StringValue(uid)
+	plan.UID = types.StringValue(uid)
+
+	info, err := r.client.RGWCreateUser(plan.RGWScope.scope(), uid, plan.DisplayName.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create RGW tenant user", err)
+		return
+	}
+	if len(info.Keys) == 0 {
+		resp.Diagnostics.AddError("Failed to create RGW tenant user", fmt.Sprintf("user %s has no S3 keys", uid))
+		return
+	}
+	plan.AccessKey = types.StringValue(info.Keys[0].AccessKey)
+	plan.SecretKey = types.StringValue(info.Keys[0].SecretKey)
+
+	// Record the user as soon as it exists; see setPartialState.
+	resp.Diagnostics.Append(setPartialState(ctx, &resp.State, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.RGWSetUserQuota(plan.RGWScope.scope(), uid, optionalSizeQuota(plan.QuotaMaxSize), optionalQuota(plan.QuotaMaxObjects)); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to set RGW tenant quota", err)
+		return
+	}
+
+	s3, err := r.s3(&plan)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create RGW tenant bucket", err)
+		return
+	}
+	if err := s3.CreateBucket(ctx, plan.Bucket.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create RGW tenant bucket", err)
+		return
+	}
+
+	if !plan.BucketPolicy.IsNull() {
+		if err := s3.PutBucketPolicy(ctx, plan.Bucket.ValueString(), plan.BucketPolicy.ValueString()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to set RGW tenant bucket policy", err)
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Created Ceph RGW tenant", map[string]interface{}{
+		"uid":    uid,
+		"bucket": plan.Bucket.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwTenantResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state rgwTenantResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uid := rgwUserID(state.Tenant.ValueString(), state.Name.ValueString())
+	exists, err := r.client.RGWUserExists(state.RGWScope.scope(), uid)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW tenant user", err)
+		return
+	}
+	if !exists {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	info, err := r.client.RGWUserInfo(state.RGWScope.scope(), uid)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW tenant user", err)
+		return
+	}
+
+	state.ID = types.StringValue(uid)
+	state.UID = types.StringValue(uid)
+	state.DisplayName = types.StringValue(info.DisplayName)
+	if len(info.Keys) > 0 {
+		state.AccessKey = types.StringValue(info.Keys[0].AccessKey)
+		state.SecretKey = types.StringValue(info.Keys[0].SecretKey)
+	}
+
+	state.QuotaMaxSize = sizeNull()
+	state.QuotaMaxObjects = types.Int64Null()
+	if info.UserQuota.Enabled {
+		if info.UserQuota.MaxSize >= 0 {
+			state.QuotaMaxSize = sizeBytes(info.UserQuota.MaxSize)
+		}
+		if info.UserQuota.MaxObjects >= 0 {
+			state.QuotaMaxObjects = types.Int64Value(info.UserQuota.MaxObjects)
+		}
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwTenantResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan rgwTenantResourceModel
+	var state rgwTenantResourceModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uid := state.UID.ValueString()
+	if plan.DisplayName.IsUnknown() || plan.DisplayName.IsNull() {
+		plan.DisplayName = plan.Name
+	}
+
+	if !plan.DisplayName.Equal(state.DisplayName) {
+		cmd := rgwCommand(plan.RGWScope.scope(), "user", "modify").
+			OptionEquals("--uid", uid).OptionEquals("--display-name", plan.DisplayName.ValueString())
+		if _, err := r.client.ExecuteCommand(cmd); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update RGW tenant user", err)
+			return
+		}
+	}
+
+	if optionalSizeQuota(plan.QuotaMaxSize) != optionalSizeQuota(state.QuotaMaxSize) || !plan.QuotaMaxObjects.Equal(state.QuotaMaxObjects) {
+		if err := r.client.RGWSetUserQuota(plan.RGWScope.scope(), uid, optionalSizeQuota(plan.QuotaMaxSize), optionalQuota(plan.QuotaMaxObjects)); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update RGW tenant quota", err)
+			return
+		}
+	}
+
+	if !plan.BucketPolicy.Equal(state.BucketPolicy) {
+		s3, err := r.s3(&plan)
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update RGW tenant bucket policy", err)
+			return
+		}
+		if plan.BucketPolicy.IsNull() {
+			err = s3.DeleteBucketPolicy(ctx, plan.Bucket.ValueString())
+		} else {
+			err = s3.PutBucketPolicy(ctx, plan.Bucket.ValueString(), plan.BucketPolicy.ValueString())
+		}
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update RGW tenant bucket policy", err)
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Updated Ceph RGW tenant", map[string]interface{}{
+		"uid": uid,
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwTenantResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state rgwTenantResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	bucketID := rgwBucketID(state.Tenant.ValueString(), state.Bucket.ValueString())
+	if err := r.client.RGWRemoveBucket(state.RGWScope.scope(), bucketID, state.ForceDestroy.ValueBool()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to delete RGW tenant bucket", err)
+		return
+	}
+
+	if err := r.client.RGWRemoveUser(state.RGWScope.scope(), state.UID.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to delete RGW tenant user", err)
+		return
+	}
+
+	tflog.Info(ctx, "Deleted Ceph RGW tenant", map[string]interface{}{
+		"uid": state.UID.ValueString(),
+	})
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// RGW User Resource
+type rgwUserResource struct {
+	client *CephClient
+}
+
+type rgwUserResourceModel struct {
+	ID          types.String   `tfsdk:"id"`
+	UID         types.String   `tfsdk:"uid"`
+	Tenant      types.String   `tfsdk:"tenant"`
+	DisplayName types.String   `tfsdk:"display_name"`
+	Email       types.String   `tfsdk:"email"`
+	MaxBuckets  types.Int64    `tfsdk:"max_buckets"`
+	UserID      types.String   `tfsdk:"user_id"`
+	AccessKey   types.String   `tfsdk:"access_key"`
+	SecretKey   types.String   `tfsdk:"secret_key"`
+	Tags        types.Map      `tfsdk:"tags"`
+	RGWScope    *rgwScopeModel `tfsdk:"rgw_scope"`
+}
+
+func NewRGWUserResource() resource.Resource {
+	return &rgwUserResource{}
+}
+
+func (r *rgwUserResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rgw_user"
+}
+
+func (r *rgwUserResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages a RADOS Gateway user",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Fully qualified user id, same as user_id"),
+			"uid": schema.StringAttribute{
+				Description: "User id (without tenant)",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"tenant": schema.StringAttribute{
+				Description: "RGW tenant the user belongs to",
+				Optional:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"display_name": schema.StringAttribute{
+				Description: "User display name",
+				Required:    true,
+			},
+			"email": schema.StringAttribute{
+				Description: "User email address",
+				Optional:    true,
+			},
+			"max_buckets": schema.Int64Attribute{
+				Description: "Maximum number of buckets the user may own",
+				Optional:    true,
+				Computed:    true,
+			},
+			"user_id": schema.StringAttribute{
+				Description: "Fully qualified user id (tenant$uid when a tenant is set)",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"access_key": schema.StringAttribute{
+				Description: "S3 access key",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"secret_key": schema.StringAttribute{
+				Description: "S3 secret key",
+				Computed:    true,
+				Sensitive:   true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"tags":      tagsAttribute(),
+			"rgw_scope": rgwScopeAttribute(),
+		},
+	}
+}
+
+func (r *rgwUserResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *rgwUserResource) applyInfo(state *rgwUserResourceModel, info *rgwUserInfo) {
+	state.DisplayName = types.StringValue(info.DisplayName)
+	if info.Email != "" || !state.Email.IsNull() {
+		state.Email = types.StringValue(info.Email)
+	}
+	state.MaxBuckets = types.Int64Value(info.MaxBuckets)
+	if len(info.Keys) > 0 {
+		state.AccessKey = types.StringValue(info.Keys[0].AccessKey)
+		state.SecretKey = types.StringValue(info.Keys[0].SecretKey)
+	}
+}
+
+func (r *rgwUserResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan rgwUserResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uid := rgwUserID(plan.Tenant.ValueString(), plan.UID.ValueString())
+	cmd := rgwCommand(plan.RGWScope.scope(), "user", "create").
+		OptionEquals("--uid", uid).OptionEquals("--display-name", plan.DisplayName.ValueString())
+	if !plan.Email.IsNull() {
+		cmd.OptionEquals("--email", plan.Email.ValueString())
+	}
+	if !plan.MaxBuckets.IsNull() && !plan.MaxBuckets.IsUnknown() {
+		cmd.OptionEquals("--max-buckets", strconv.FormatInt(plan.MaxBuckets.ValueInt64(), 10))
+	}
+
+	output, err := r.client.ExecuteCommand(cmd)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create RGW user", err)
+		return
+	}
+
+	info, err := parseRGWUserInfo(output)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse RGW user info", err)
+		return
+	}
+	plan.ID = types.StringValue(uid)
+	plan.UserID = types.StringValue(uid)
+	r.applyInfo(&plan, info)
+
+	// Record the user before tagging it; see setPartialState.
+	resp.Diagnostics.Append(setPartialState(ctx, &resp.State, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.applyRegistryTags(ctx, "rgw_user", uid, plan.Tags, types.MapNull(types.StringType)); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to set RGW user tags", err)
+		return
+	}
+
+	tflog.Info(ctx, "Created Ceph RGW user", map[string]interface{}{
+		"uid": uid,
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwUserResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state rgwUserResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uid := rgwUserID(state.Tenant.ValueString(), state.UID.ValueString())
+	exists, err := r.client.RGWUserExists(state.RGWScope.scope(), uid)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW user", err)
+		return
+	}
+	if !exists {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	info, err := r.client.RGWUserInfo(state.RGWScope.scope(), uid)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW user", err)
+		return
+	}
+	state.ID = types.StringValue(uid)
+	state.UserID = types.StringValue(uid)
+	r.applyInfo(&state, info)
+
+	state.Tags, err = r.client.readRegistryTags(ctx, "rgw_user", uid, state.Tags)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read RGW user tags", err)
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwUserResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan rgwUserResourceModel
+	var state rgwUserResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uid := rgwUserID(plan.Tenant.ValueString(), plan.UID.ValueString())
+	cmd := rgwCommand(plan.RGWScope.scope(), "user", "modify").
+		OptionEquals("--uid", uid).OptionEquals("--display-name", plan.DisplayName.ValueString())
+	if !plan.Email.IsNull() {
+		cmd.OptionEquals("--email", plan.Email.ValueString())
+	}
+	if !plan.MaxBuckets.IsNull() && !plan.MaxBuckets.IsUnknown() {
+		cmd.OptionEquals("--max-buckets", strconv.FormatInt(plan.MaxBuckets.ValueInt64(), 10))
+	}
+
+	output, err := r.client.ExecuteCommand(cmd)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update RGW user", err)
+		return
+	}
+
+	info, err := parseRGWUserInfo(output)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse RGW user info", err)
+		return
+	}
+	plan.ID = types.StringValue(uid)
+	plan.UserID = types.StringValue(uid)
+	r.applyInfo(&plan, info)
+
+	if err := r.client.applyRegistryTags(ctx, "rgw_user", uid, plan.Tags, state.Tags); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update RGW user tags", err)
+		return
+	}
+
+	tflog.Info(ctx, "Updated Ceph RGW user", map[string]interface{}{
+		"uid": uid,
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *rgwUserResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state rgwUserResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	uid := rgwUserID(state.Tenant.ValueString(), state.UID.ValueString())
+	if err := r.client.RGWRemoveUser(state.RGWScope.scope(), uid); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to delete RGW user", err)
+		return
+	}
+	if err := r.client.applyRegistryTags(ctx, "rgw_user", uid, types.MapNull(types.StringType), state.Tags); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove RGW user tags", err)
+		return
+	}
+
+	tflog.Info(ctx, "Deleted Ceph RGW user", map[string]interface{}{
+		"uid": uid,
+	})
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+var tellDaemonPrefix = regexp.MustCompile(`^([a-z]+\.[^\s:]+): ?(.*)$`)
+
+// parseTellConfigGet parses the output of `ceph tell <target> config get
+// <name>` into per-daemon values. A single target prints one JSON object;
+// wildcard targets prefix each daemon's object with "<daemon>: ".
+func parseTellConfigGet(output, target, name string) (map[string]string, error) {
+	chunks := make(map[string]string)
+	trimmed := strings.TrimSpace(output)
+	if strings.HasPrefix(trimmed, "{") {
+		chunks[target] = trimmed
+	} else {
+		var daemon string
+		for _, line := range strings.Split(output, "\n") {
+			if m := tellDaemonPrefix.FindStringSubmatch(line); m != nil {
+				daemon = m[1]
+				chunks[daemon] = m[2]
+				continue
+			}
+			if daemon != "" {
+				chunks[daemon] += "\n" + line
+			}
+		}
+	}
+
+	values := make(map[string]string, len(chunks))
+	for daemon, chunk := range chunks {
+		var parsed map[string]string
+		if err := json.Unmarshal([]byte(chunk), &parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse %s output for %s: %w", name, daemon, err)
+		}
+		value, ok := parsed[name]
+		if !ok {
+			return nil, fmt.Errorf("%s did not report %s", daemon, name)
+		}
+		values[daemon] = value
+	}
+	return values, nil
+}
+
+// Runtime Option Resource
+//
+// Injects a config value into running daemons with `ceph tell`. Nothing is
+// persisted in the config store; the values seen before injection are
+// recorded so destroy can put them back.
+type runtimeOptionResource struct {
+	client *CephClient
+}
+
+type runtimeOptionResourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	Target         types.String `tfsdk:"target"`
+	Name           types.String `tfsdk:"name"`
+	Value          types.String `tfsdk:"value"`
+	PreviousValues types.Map    `tfsdk:"previous_values"`
+}
+
+func NewRuntimeOptionResource() resource.Resource {
+	return &runtimeOptionResource{}
+}
+
+func (r *runtimeOptionResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_runtime_option"
+}
+
+func (r *runtimeOptionResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Injects a runtime-only config value with `ceph tell <target> config set` and reverts it on destroy",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Target and option name in target/name form"),
+			"target": schema.StringAttribute{
+				Description: "Daemon or daemon wildcard to inject into, e.g. osd.* or mon.a",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Description: "Config option name",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"value": schema.StringAttribute{
+				Description: "Value to inject",
+				Required:    true,
+			},
+			"previous_values": schema.MapAttribute{
+				Description: "Per-daemon values recorded before injection, restored on destroy",
+				ElementType: types.StringType,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *runtimeOptionResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *runtimeOptionResource) getValues(target, name string) (map[string]string, error) {
+	output, err := r.client.ReadText(NewCommand("ceph", "tell").Arg(target).Flag("config", "get").Arg(name))
+	if err != nil {
+		return nil, err
+	}
+	return parseTellConfigGet(output, target, name)
+}
+
+func (r *runtimeOptionResource) inject(target, name, value string) error {
+	_, err := r.client.ExecuteCommand(NewCommand("ceph", "tell").Arg(target).Flag("config", "set").Arg(name, value))
+	return err
+}
+
+func (r *runtimeOptionResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan runtimeOptionResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	previous, err := r.getValues(plan.Target.ValueString(), plan.Name.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read current runtime value", err)
+		return
+	}
+	plan.PreviousValues, diags = types.MapValueFrom(ctx, types.StringType, previous)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.inject(plan.Target.ValueString(), plan.Name.ValueString(), plan.Value.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to inject runtime option", err)
+		return
+	}
+
+	plan.ID = types.StringValue(configOptionID(plan.Target.ValueString(), plan.Name.ValueString()))
+
+	tflog.Info(ctx, "Injected Ceph runtime option", map[string]interface{}{
+		"target": plan.Target.ValueString(),
+		"name":   plan.Name.ValueString(),
+		"value":  plan.Value.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *runtimeOptionResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state runtimeOptionResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	current, err := r.getValues(state.Target.ValueString(), state.Name.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read runtime option", err)
+		return
+	}
+
+	state.ID = types.StringValue(configOptionID(state.Target.ValueString(), state.Name.ValueString()))
+
+	// Restarted daemons lose injected values; report the first one that
+	// no longer matches so the next apply injects it again.
+	daemons := make([]string, 0, len(current))
+	for daemon := range current {
+		daemons = append(daemons, daemon)
+	}
+	sort.Strings(daemons)
+	for _, daemon := range daemons {
+		if current[daemon] != state.Value.ValueString() {
+			state.Value = types.StringValue(current[daemon])
+			break
+		}
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *runtimeOptionResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan runtimeOptionResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.inject(plan.Target.ValueString(), plan.Name.ValueString(), plan.Value.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to inject runtime option", err)
+		return
+	}
+
+	plan.ID = types.StringValue(configOptionID(plan.Target.ValueString(), plan.Name.ValueString()))
+
+	tflog.Info(ctx, "Updated Ceph runtime option", map[string]interface{}{
+		"target": plan.Target.ValueString(),
+		"name":   plan.Name.ValueString(),
+		"value":  plan.Value.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *runtimeOptionResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state runtimeOptionResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	previous := make(map[string]string)
+	diags = state.PreviousValues.ElementsAs(ctx, &previous, false)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for daemon, value := range previous {
+		if err := r.inject(daemon, state.Name.ValueString(), value); err != nil {
+			resp.Diagnostics.AddError("Failed to revert runtime option",
+				fmt.Sprintf("%s on %s: %s", state.Name.ValueString(), daemon, err))
+			return
+		}
+	}
+
+	tflog.Info(ctx, "Reverted Ceph runtime option", map[string]interface{}{
+		"target": state.Target.ValueString(),
+		"name":   state.Name.ValueString(),
+	})
+}
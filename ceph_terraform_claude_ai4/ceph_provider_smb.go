@@ -0,0 +1,883 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// The smb mgr module is driven through its declarative resource format:
+// `ceph smb apply` takes a list of resources and `ceph smb show` returns
+// them. Passwords and share names may contain spaces, which the
+// imperative `ceph smb cluster create` arguments cannot carry through
+// ExecuteCommand, so everything goes through an apply file.
+
+const (
+	smbAuthModeUser = "user"
+	smbAuthModeAD   = "active-directory"
+)
+
+type smbSource struct {
+	SourceType string `json:"source_type"`
+	Ref        string `json:"ref"`
+}
+
+type smbDomainSettings struct {
+	Realm       string      `json:"realm"`
+	JoinSources []smbSource `json:"join_sources"`
+}
+
+type smbPlacement struct {
+	Count int64 `json:"count,omitempty"`
+}
+
+type smbCluster struct {
+	ResourceType      string             `json:"resource_type"`
+	ClusterID         string             `json:"cluster_id"`
+	AuthMode          string             `json:"auth_mode"`
+	DomainSettings    *smbDomainSettings `json:"domain_settings,omitempty"`
+	UserGroupSettings []smbSource        `json:"user_group_settings,omitempty"`
+	CustomDNS         []string           `json:"custom_dns,omitempty"`
+	Placement         *smbPlacement      `json:"placement,omitempty"`
+}
+
+type smbUser struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+type smbUsersGroups struct {
+	ResourceType  string `json:"resource_type"`
+	UsersGroupsID string `json:"users_groups_id"`
+	Values        struct {
+		Users  []smbUser     `json:"users"`
+		Groups []interface{} `json:"groups"`
+	} `json:"values"`
+}
+
+type smbJoinAuth struct {
+	ResourceType string `json:"resource_type"`
+	AuthID       string `json:"auth_id"`
+	Auth         struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"auth"`
+}
+
+type smbShareCephFS struct {
+	Volume         string `json:"volume"`
+	Path           string `json:"path"`
+	SubvolumeGroup string `json:"subvolumegroup,omitempty"`
+	Subvolume      string `json:"subvolume,omitempty"`
+}
+
+type smbLoginAccess struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Access   string `json:"access"`
+}
+
+type smbShare struct {
+	ResourceType   string           `json:"resource_type"`
+	ClusterID      string           `json:"cluster_id"`
+	ShareID        string           `json:"share_id"`
+	Name           string           `json:"name,omitempty"`
+	ReadOnly       bool             `json:"readonly"`
+	Browseable     bool             `json:"browseable"`
+	CephFS         smbShareCephFS   `json:"cephfs"`
+	RestrictAccess bool             `json:"restrict_access"`
+	LoginControl   []smbLoginAccess `json:"login_control,omitempty"`
+}
+
+// smbRemoval asks `ceph smb apply` to remove a resource.
+type smbRemoval struct {
+	ResourceType  string `json:"resource_type"`
+	ClusterID     string `json:"cluster_id,omitempty"`
+	ShareID       string `json:"share_id,omitempty"`
+	UsersGroupsID string `json:"users_groups_id,omitempty"`
+	AuthID        string `json:"auth_id,omitempty"`
+	Intent        string `json:"intent"`
+}
+
+type smbApplyResults struct {
+	Success bool `json:"success"`
+	Results []struct {
+		Success bool   `json:"success"`
+		State   string `json:"state"`
+		Msg     string `json:"msg"`
+	} `json:"results"`
+}
+
+// parseSMBApplyResults turns a failed `ceph smb apply` result set into an
+// error listing the messages of the resources that were rejected.
+func parseSMBApplyResults(output string) error {
+	var results smbApplyResults
+	if err := json.Unmarshal([]byte(output), &results); err != nil {
+		return fmt.Errorf("failed to parse smb apply results: %w", err)
+	}
+	if results.Success {
+		return nil
+	}
+
+	var msgs []string
+	for _, result := range results.Results {
+		if !result.Success && result.Msg != "" {
+			msgs = append(msgs, result.Msg)
+		}
+	}
+	if len(msgs) == 0 {
+		return fmt.Errorf("smb apply was not successful")
+	}
+	return fmt.Errorf("smb apply was not successful: %s", strings.Join(msgs, "; "))
+}
+
+// parseSMBShow normalises `ceph smb show` output, which is a single
+// resource when exactly one matches and a resources list otherwise.
+func parseSMBShow(output string) ([]json.RawMessage, error) {
+	var list struct {
+		Resources []json.RawMessage `json:"resources"`
+	}
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse smb resources: %w", err)
+	}
+	if list.Resources != nil {
+		return list.Resources, nil
+	}
+
+	var single struct {
+		ResourceType string `json:"resource_type"`
+	}
+	if err := json.Unmarshal([]byte(output), &single); err != nil {
+		return nil, fmt.Errorf("failed to parse smb resource: %w", err)
+	}
+	if single.ResourceType == "" {
+		return nil, nil
+	}
+	return []json.RawMessage{json.RawMessage(output)}, nil
+}
+
+// SMBApply submits resources to the smb module in one transaction.
+func (c *CephClient) SMBApply(resources ...interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"resources": resources})
+	if err != nil {
+		return fmt.Errorf("failed to encode smb resources: %w", err)
+	}
+
+	dir, err := os.MkdirTemp("", "ceph-smb")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "resources.json")
+	if err := os.WriteFile(file, body, 0600); err != nil {
+		return fmt.Errorf("failed to write smb resources: %w", err)
+	}
+
+	output, err := c.ExecuteCommand(NewCommand("ceph", "smb", "apply").Option("-i", file).Flag("--format", "json"))
+	if err != nil {
+		return err
+	}
+	return parseSMBApplyResults(output)
+}
+
+// SMBCluster returns the cluster resource, or nil if it does not exist.
+func (c *CephClient) SMBCluster(clusterID string) (*smbCluster, error) {
+	output, err := c.ReadJSON(NewCommand("ceph", "smb", "show", "ceph.smb.cluster"))
+	if err != nil {
+		return nil, err
+	}
+	resources, err := parseSMBShow(output)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range resources {
+		var cluster smbCluster
+		if err := json.Unmarshal(raw, &cluster); err != nil {
+			return nil, fmt.Errorf("failed to parse smb cluster: %w", err)
+		}
+		if cluster.ClusterID == clusterID {
+			return &cluster, nil
+		}
+	}
+	return nil, nil
+}
+
+// SMBShare returns the share resource, or nil if it does not exist.
+func (c *CephClient) SMBShare(clusterID, shareID string) (*smbShare, error) {
+	output, err := c.ReadJSON(NewCommand("ceph", "smb", "show", "ceph.smb.share"))
+	if err != nil {
+		return nil, err
+	}
+	resources, err := parseSMBShow(output)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range resources {
+		var share smbShare
+		if err := json.Unmarshal(raw, &share); err != nil {
+			return nil, fmt.Errorf("failed to parse smb share: %w", err)
+		}
+		if share.ClusterID == clusterID && share.ShareID == shareID {
+			return &share, nil
+		}
+	}
+	return nil, nil
+}
+
+// smbShareID returns the cluster/share spec used as the share's id.
+func smbShareID(clusterID, shareID string) string {
+	return clusterID + "/" + shareID
+}
+
+// SMB Cluster Resource
+//
+// An SMB cluster is a set of Samba containers deployed by the orchestrator.
+// In user mode the provider also owns the users-and-groups resource holding
+// the local accounts; in active-directory mode it owns the join auth
+// resource holding the domain join credentials. Both are named after the
+// cluster and removed with it.
+type smbClusterResource struct {
+	client *CephClient
+}
+
+type smbClusterResourceModel struct {
+	ID                 types.String `tfsdk:"id"`
+	ClusterID          types.String `tfsdk:"cluster_id"`
+	AuthMode           types.String `tfsdk:"auth_mode"`
+	Users              types.Map    `tfsdk:"users"`
+	DomainRealm        types.String `tfsdk:"domain_realm"`
+	DomainJoinUser     types.String `tfsdk:"domain_join_user"`
+	DomainJoinPassword types.String `tfsdk:"domain_join_password"`
+	CustomDNS          types.List   `tfsdk:"custom_dns"`
+	PlacementCount     types.Int64  `tfsdk:"placement_count"`
+}
+
+func NewSMBClusterResource() resource.Resource {
+	return &smbClusterResource{}
+}
+
+func (r *smbClusterResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_smb_cluster"
+}
+
+func (r *smbClusterResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages an SMB (Samba) cluster through the smb mgr module. Requires a cephadm-managed cluster with the smb module enabled",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("SMB cluster id, same as cluster_id"),
+			"cluster_id": schema.StringAttribute{
+				Description: "SMB cluster id",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"auth_mode": schema.StringAttribute{
+				Description: "How clients authenticate: \"user\" for local accounts or \"active-directory\" to join a domain",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(smbAuthModeUser),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"users": schema.MapAttribute{
+				Description: "Local accounts as user name to password, for auth_mode \"user\"",
+				ElementType: types.StringType,
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"domain_realm": schema.StringAttribute{
+				Description: "Active Directory realm to join, for auth_mode \"active-directory\"",
+				Optional:    true,
+			},
+			"domain_join_user": schema.StringAttribute{
+				Description: "Account used to join the domain",
+				Optional:    true,
+			},
+			"domain_join_password": schema.StringAttribute{
+				Description: "Password of the domain join account",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"custom_dns": schema.ListAttribute{
+				Description: "DNS servers for the Samba containers, typically the domain controllers",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"placement_count": schema.Int64Attribute{
+				Description: "Number of Samba daemons the orchestrator deploys",
+				Optional:    true,
+			},
+		},
+	}
+}
+
+func (r *smbClusterResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *smbClusterResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config smbClusterResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || config.AuthMode.IsUnknown() {
+		return
+	}
+
+	mode := smbAuthModeUser
+	if !config.AuthMode.IsNull() {
+		mode = config.AuthMode.ValueString()
+	}
+
+	switch mode {
+	case smbAuthModeUser:
+		if config.Users.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("users"), "Missing users",
+				"auth_mode \"user\" needs at least one local account in users")
+		}
+		if !config.DomainRealm.IsNull() || !config.DomainJoinUser.IsNull() || !config.DomainJoinPassword.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("domain_realm"), "Domain settings without active-directory",
+				"domain_realm, domain_join_user and domain_join_password only apply to auth_mode \"active-directory\"")
+		}
+	case smbAuthModeAD:
+		for _, attr := range []struct {
+			name  string
+			value types.String
+		}{
+			{"domain_realm", config.DomainRealm},
+			{"domain_join_user", config.DomainJoinUser},
+			{"domain_join_password", config.DomainJoinPassword},
+		} {
+			if attr.value.IsNull() {
+				resp.Diagnostics.AddAttributeError(path.Root(attr.name), "Missing domain setting",
+					fmt.Sprintf("auth_mode \"active-directory\" requires %s", attr.name))
+			}
+		}
+		if !config.Users.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("users"), "Local users with active-directory",
+				"users only applies to auth_mode \"user\"; accounts come from the domain")
+		}
+	default:
+		resp.Diagnostics.AddAttributeError(path.Root("auth_mode"), "Invalid auth_mode",
+			fmt.Sprintf("auth_mode must be %q or %q, got %q", smbAuthModeUser, smbAuthModeAD, mode))
+	}
+}
+
+// smbUsersGroupsID and smbJoinAuthID name the credential resources the
+// cluster owns.
+func smbUsersGroupsID(clusterID string) string { return clusterID + "-users" }
+func smbJoinAuthID(clusterID string) string    { return clusterID + "-join" }
+
+// resources builds the smb module resources for the planned cluster.
+func (m *smbClusterResourceModel) resources(ctx context.Context) ([]interface{}, error) {
+	id := m.ClusterID.ValueString()
+	cluster := smbCluster{
+		ResourceType: "ceph.smb.cluster",
+		ClusterID:    id,
+		AuthMode:     m.AuthMode.ValueString(),
+	}
+	if !m.CustomDNS.IsNull() {
+		if diags := m.CustomDNS.ElementsAs(ctx, &cluster.CustomDNS, false); diags.HasError() {
+			return nil, fmt.Errorf("invalid custom_dns")
+		}
+	}
+	if !m.PlacementCount.IsNull() {
+		cluster.Placement = &smbPlacement{Count: m.PlacementCount.ValueInt64()}
+	}
+
+	if cluster.AuthMode == smbAuthModeAD {
+		auth := smbJoinAuth{ResourceType: "ceph.smb.join.auth", AuthID: smbJoinAuthID(id)}
+		auth.Auth.Username = m.DomainJoinUser.ValueString()
+		auth.Auth.Password = m.DomainJoinPassword.ValueString()
+		cluster.DomainSettings = &smbDomainSettings{
+			Realm:       m.DomainRealm.ValueString(),
+			JoinSources: []smbSource{{SourceType: "resource", Ref: auth.AuthID}},
+		}
+		return []interface{}{auth, cluster}, nil
+	}
+
+	users := map[string]string{}
+	if diags := m.Users.ElementsAs(ctx, &users, false); diags.HasError() {
+		return nil, fmt.Errorf("invalid users")
+	}
+	group := smbUsersGroups{ResourceType: "ceph.smb.usersgroups", UsersGroupsID: smbUsersGroupsID(id)}
+	group.Values.Users = []smbUser{}
+	group.Values.Groups = []interface{}{}
+	for _, name := range sortedKeys(users) {
+		group.Values.Users = append(group.Values.Users, smbUser{Name: name, Password: users[name]})
+	}
+	cluster.UserGroupSettings = []smbSource{{SourceType: "resource", Ref: group.UsersGroupsID}}
+	return []interface{}{group, cluster}, nil
+}
+
+func (r *smbClusterResource) apply(ctx context.Context, plan *smbClusterResourceModel) error {
+	resources, err := plan.resources(ctx)
+	if err != nil {
+		return err
+	}
+	return r.client.SMBApply(resources...)
+}
+
+func (r *smbClusterResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan smbClusterResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.apply(ctx, &plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create SMB cluster", err)
+		return
+	}
+
+	plan.ID = plan.ClusterID
+
+	tflog.Info(ctx, "Created Ceph SMB cluster", map[string]interface{}{
+		"cluster_id": plan.ClusterID.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *smbClusterResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state smbClusterResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	cluster, err := r.client.SMBCluster(state.ClusterID.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read SMB cluster", err)
+		return
+	}
+	if cluster == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// Credentials live in the linked resources and are not read back, so
+	// users and the join password keep their configured values.
+	state.ID = state.ClusterID
+	state.AuthMode = types.StringValue(cluster.AuthMode)
+	if cluster.DomainSettings != nil {
+		state.DomainRealm = types.StringValue(cluster.DomainSettings.Realm)
+	}
+	if len(cluster.CustomDNS) > 0 {
+		state.CustomDNS, diags = types.ListValueFrom(ctx, types.StringType, cluster.CustomDNS)
+		resp.Diagnostics.Append(diags...)
+	} else {
+		state.CustomDNS = types.ListNull(types.StringType)
+	}
+	if cluster.Placement != nil && cluster.Placement.Count > 0 {
+		state.PlacementCount = types.Int64Value(cluster.Placement.Count)
+	} else {
+		state.PlacementCount = types.Int64Null()
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *smbClusterResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan smbClusterResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.apply(ctx, &plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update SMB cluster", err)
+		return
+	}
+
+	plan.ID = plan.ClusterID
+
+	tflog.Info(ctx, "Updated Ceph SMB cluster", map[string]interface{}{
+		"cluster_id": plan.ClusterID.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *smbClusterResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state smbClusterResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id := state.ClusterID.ValueString()
+	credentials := smbRemoval{ResourceType: "ceph.smb.usersgroups", UsersGroupsID: smbUsersGroupsID(id), Intent: "removed"}
+	if state.AuthMode.ValueString() == smbAuthModeAD {
+		credentials = smbRemoval{ResourceType: "ceph.smb.join.auth", AuthID: smbJoinAuthID(id), Intent: "removed"}
+	}
+
+	err := r.client.SMBApply(
+		smbRemoval{ResourceType: "ceph.smb.cluster", ClusterID: id, Intent: "removed"},
+		credentials,
+	)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove SMB cluster", err)
+		return
+	}
+
+	tflog.Info(ctx, "Removed Ceph SMB cluster", map[string]interface{}{
+		"cluster_id": id,
+	})
+}
+
+// SMB Share Resource
+//
+// A share exports a CephFS path, optionally inside a subvolume, from an SMB
+// cluster. Listing entries in access restricts logins to those users and
+// groups.
+type smbShareResource struct {
+	client *CephClient
+}
+
+type smbShareResourceModel struct {
+	ID             types.String          `tfsdk:"id"`
+	ClusterID      types.String          `tfsdk:"cluster_id"`
+	ShareID        types.String          `tfsdk:"share_id"`
+	Name           types.String          `tfsdk:"name"`
+	Volume         types.String          `tfsdk:"volume"`
+	Path           types.String          `tfsdk:"path"`
+	SubvolumeGroup types.String          `tfsdk:"subvolume_group"`
+	Subvolume      types.String          `tfsdk:"subvolume"`
+	ReadOnly       types.Bool            `tfsdk:"readonly"`
+	Browseable     types.Bool            `tfsdk:"browseable"`
+	Access         []smbShareAccessModel `tfsdk:"access"`
+}
+
+type smbShareAccessModel struct {
+	Name     types.String `tfsdk:"name"`
+	Category types.String `tfsdk:"category"`
+	Access   types.String `tfsdk:"access"`
+}
+
+var (
+	smbAccessCategories = []string{"user", "group"}
+	smbAccessLevels     = []string{"read", "read-write", "admin", "none"}
+)
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func NewSMBShareResource() resource.Resource {
+	return &smbShareResource{}
+}
+
+func (r *smbShareResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_smb_share"
+}
+
+func (r *smbShareResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	requiresReplace := []planmodifier.String{
+		stringplanmodifier.RequiresReplace(),
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Manages an SMB share backed by CephFS on a ceph_smb_cluster",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Share spec in cluster_id/share_id form"),
+			"cluster_id": schema.StringAttribute{
+				Description:   "SMB cluster the share belongs to",
+				Required:      true,
+				PlanModifiers: requiresReplace,
+			},
+			"share_id": schema.StringAttribute{
+				Description:   "Share id within the cluster",
+				Required:      true,
+				PlanModifiers: requiresReplace,
+			},
+			"name": schema.StringAttribute{
+				Description: "Share name clients see; defaults to share_id",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"volume": schema.StringAttribute{
+				Description: "CephFS volume to export",
+				Required:    true,
+			},
+			"path": schema.StringAttribute{
+				Description: "Path to export, relative to the subvolume if one is set",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("/"),
+			},
+			"subvolume_group": schema.StringAttribute{
+				Description: "Subvolume group of subvolume",
+				Optional:    true,
+			},
+			"subvolume": schema.StringAttribute{
+				Description: "Subvolume to export",
+				Optional:    true,
+			},
+			"readonly": schema.BoolAttribute{
+				Description: "Export the share read-only",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+			},
+			"browseable": schema.BoolAttribute{
+				Description: "List the share when clients browse the server",
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(true),
+			},
+			"access": schema.ListNestedAttribute{
+				Description: "Users and groups allowed to log in to the share; when set, everyone else is refused",
+				Optional:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Description: "User or group name",
+							Required:    true,
+						},
+						"category": schema.StringAttribute{
+							Description: "\"user\" or \"group\"",
+							Optional:    true,
+							Computed:    true,
+							Default:     stringdefault.StaticString("user"),
+						},
+						"access": schema.StringAttribute{
+							Description: "\"read\", \"read-write\", \"admin\" or \"none\"",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *smbShareResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *smbShareResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config smbShareResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !config.SubvolumeGroup.IsNull() && config.Subvolume.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("subvolume_group"), "subvolume_group without subvolume",
+			"subvolume_group selects the group of subvolume; set subvolume as well")
+	}
+
+	for i, entry := range config.Access {
+		if !entry.Category.IsNull() && !entry.Category.IsUnknown() && !containsString(smbAccessCategories, entry.Category.ValueString()) {
+			resp.Diagnostics.AddAttributeError(path.Root("access").AtListIndex(i).AtName("category"), "Invalid access category",
+				fmt.Sprintf("category must be one of %s", strings.Join(smbAccessCategories, ", ")))
+		}
+		if !entry.Access.IsUnknown() && !containsString(smbAccessLevels, entry.Access.ValueString()) {
+			resp.Diagnostics.AddAttributeError(path.Root("access").AtListIndex(i).AtName("access"), "Invalid access level",
+				fmt.Sprintf("access must be one of %s", strings.Join(smbAccessLevels, ", ")))
+		}
+	}
+}
+
+// share builds the smb module resource for the planned share.
+func (m *smbShareResourceModel) share() smbShare {
+	share := smbShare{
+		ResourceType: "ceph.smb.share",
+		ClusterID:    m.ClusterID.ValueString(),
+		ShareID:      m.ShareID.ValueString(),
+		ReadOnly:     m.ReadOnly.ValueBool(),
+		Browseable:   m.Browseable.ValueBool(),
+		CephFS: smbShareCephFS{
+			Volume:         m.Volume.ValueString(),
+			Path:           m.Path.ValueString(),
+			SubvolumeGroup: m.SubvolumeGroup.ValueString(),
+			Subvolume:      m.Subvolume.ValueString(),
+		},
+		RestrictAccess: len(m.Access) > 0,
+	}
+	if !m.Name.IsNull() && !m.Name.IsUnknown() {
+		share.Name = m.Name.ValueString()
+	}
+	for _, entry := range m.Access {
+		share.LoginControl = append(share.LoginControl, smbLoginAccess{
+			Name:     entry.Name.ValueString(),
+			Category: entry.Category.ValueString(),
+			Access:   entry.Access.ValueString(),
+		})
+	}
+	return share
+}
+
+// refresh copies the share as the smb module reports it into the model.
+func (m *smbShareResourceModel) refresh(share *smbShare) {
+	m.ID = types.StringValue(smbShareID(share.ClusterID, share.ShareID))
+	m.Name = types.StringValue(share.Name)
+	m.Volume = types.StringValue(share.CephFS.Volume)
+	m.Path = types.StringValue(share.CephFS.Path)
+	m.SubvolumeGroup = optionalString(share.CephFS.SubvolumeGroup)
+	m.Subvolume = optionalString(share.CephFS.Subvolume)
+	m.ReadOnly = types.BoolValue(share.ReadOnly)
+	m.Browseable = types.BoolValue(share.Browseable)
+
+	m.Access = nil
+	for _, entry := range share.LoginControl {
+		m.Access = append(m.Access, smbShareAccessModel{
+			Name:     types.StringValue(entry.Name),
+			Category: types.StringValue(entry.Category),
+			Access:   types.StringValue(entry.Access),
+		})
+	}
+}
+
+// optionalString maps an empty string to null so unset optional
+// attributes do not show a diff.
+func optionalString(value string) types.String {
+	if value == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(value)
+}
+
+func (r *smbShareResource) applyAndRefresh(plan *smbShareResourceModel) error {
+	if err := r.client.SMBApply(plan.share()); err != nil {
+		return err
+	}
+	share, err := r.client.SMBShare(plan.ClusterID.ValueString(), plan.ShareID.ValueString())
+	if err != nil {
+		return err
+	}
+	if share == nil {
+		return fmt.Errorf("share %s not found after apply",
+			smbShareID(plan.ClusterID.ValueString(), plan.ShareID.ValueString()))
+	}
+	plan.refresh(share)
+	return nil
+}
+
+func (r *smbShareResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan smbShareResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.applyAndRefresh(&plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create SMB share", err)
+		return
+	}
+
+	tflog.Info(ctx, "Created Ceph SMB share", map[string]interface{}{
+		"share": plan.ID.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *smbShareResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state smbShareResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	share, err := r.client.SMBShare(state.ClusterID.ValueString(), state.ShareID.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read SMB share", err)
+		return
+	}
+	if share == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	state.refresh(share)
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *smbShareResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan smbShareResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.applyAndRefresh(&plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update SMB share", err)
+		return
+	}
+
+	tflog.Info(ctx, "Updated Ceph SMB share", map[string]interface{}{
+		"share": plan.ID.ValueString(),
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *smbShareResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state smbShareResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.client.SMBApply(smbRemoval{
+		ResourceType: "ceph.smb.share",
+		ClusterID:    state.ClusterID.ValueString(),
+		ShareID:      state.ShareID.ValueString(),
+		Intent:       "removed",
+	})
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove SMB share", err)
+		return
+	}
+
+	tflog.Info(ctx, "Removed Ceph SMB share", map[string]interface{}{
+		"share": state.ID.ValueString(),
+	})
+}
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Snapshot Retention Policy Resource
+//
+// One retention grammar, counts of hourly, daily, weekly and monthly
+// snapshots, for both snapshot schedulers Ceph has. A CephFS directory
+// gets a `ceph fs snap-schedule` at the finest period with a count, and
+// a retention spec for every count; the snap_schedule manager module
+// takes and prunes the snapshots. RBD only schedules mirror snapshots,
+// with `rbd mirror snapshot schedule`, and keeps the newest
+// rbd_mirroring_max_mirroring_snapshots of them, so an RBD target takes a
+// single count and needs snapshot-based mirroring on its images.
+
+// retentionPeriod is one count of the retention grammar.
+type retentionPeriod struct {
+	Name string
+	// Spec is the period letter of `ceph fs snap-schedule retention add`.
+	Spec string
+	// Interval and RBDInterval take one snapshot per period; RBD has no
+	// week or month unit.
+	Interval    string
+	RBDInterval string
+}
+
+// retentionPeriods runs from the finest period to the coarsest.
+var retentionPeriods = []retentionPeriod{
+	{Name: "hourly", Spec: "h", Interval: "1h", RBDInterval: "1h"},
+	{Name: "daily", Spec: "d", Interval: "1d", RBDInterval: "1d"},
+	{Name: "weekly", Spec: "w", Interval: "1w", RBDInterval: "7d"},
+	{Name: "monthly", Spec: "M", Interval: "1M", RBDInterval: "30d"},
+}
+
+// rbdMaxMirrorSnapshotsOption is the RBD option bounding the number of
+// mirror snapshots kept per image. RBD refuses values below 3.
+const rbdMaxMirrorSnapshotsOption = "rbd_mirroring_max_mirroring_snapshots"
+
+type snapshotRetentionResource struct {
+	client *CephClient
+}
+
+type retentionCephFSModel struct {
+	FSName types.String `tfsdk:"fs_name"`
+	Path   types.String `tfsdk:"path"`
+}
+
+type retentionRBDModel struct {
+	Pool      types.String `tfsdk:"pool"`
+	Namespace types.String `tfsdk:"namespace"`
+	Image     types.String `tfsdk:"image"`
+}
+
+type snapshotRetentionResourceModel struct {
+	ID       types.String          `tfsdk:"id"`
+	CephFS   *retentionCephFSModel `tfsdk:"cephfs"`
+	RBD      *retentionRBDModel    `tfsdk:"rbd"`
+	Hourly   types.Int64           `tfsdk:"hourly"`
+	Daily    types.Int64           `tfsdk:"daily"`
+	Weekly   types.Int64           `tfsdk:"weekly"`
+	Monthly  types.Int64           `tfsdk:"monthly"`
+	Schedule types.String          `tfsdk:"schedule"`
+}
+
+func NewSnapshotRetentionResource() resource.Resource {
+	return &snapshotRetentionResource{}
+}
+
+// counts returns the model's counts in the order of retentionPeriods.
+func (m *snapshotRetentionResourceModel) counts() []*types.Int64 {
+	return []*types.Int64{&m.Hourly, &m.Daily, &m.Weekly, &m.Monthly}
+}
+
+// retention returns the periods with a count, finest first.
+func (m *snapshotRetentionResourceModel) retention() ([]retentionPeriod, []int64) {
+	var periods []retentionPeriod
+	var counts []int64
+	for i, count := range m.counts() {
+		if !count.IsNull() && !count.IsUnknown() {
+			periods = append(periods, retentionPeriods[i])
+			counts = append(counts, count.ValueInt64())
+		}
+	}
+	return periods, counts
+}
+
+// id returns the resource id: cephfs:<fs>:<path>, or rbd:<pool>[/<namespace>][/<image>].
+func (m *snapshotRetentionResourceModel) id() string {
+	if m.CephFS != nil {
+		return "cephfs:" + m.CephFS.FSName.ValueString() + ":" + m.CephFS.Path.ValueString()
+	}
+	return "rbd:" + m.RBD.spec()
+}
+
+// spec returns the target as rbd prints it: pool[/namespace][/image].
+func (t *retentionRBDModel) spec() string {
+	spec := t.Pool.ValueString()
+	if ns := t.Namespace.ValueString(); ns != "" {
+		spec += "/" + ns
+	}
+	if image := t.Image.ValueString(); image != "" {
+		spec += "/" + image
+	}
+	return spec
+}
+
+// scheduleCommand returns an `rbd mirror snapshot schedule` command for
+// the target.
+func (t *retentionRBDModel) scheduleCommand(action string) *CommandBuilder {
+	cmd := NewCommand("rbd", "mirror", "snapshot", "schedule", action).Option("--pool", t.Pool.ValueString())
+	if ns := t.Namespace.ValueString(); ns != "" {
+		cmd.Option("--namespace", ns)
+	}
+	if image := t.Image.ValueString(); image != "" {
+		cmd.Option("--image", image)
+	}
+	return cmd
+}
+
+// configLevel is where the snapshot limit is set: on the image when the
+// target is one, else on the whole pool, namespaces included.
+func (t *retentionRBDModel) configLevel() string {
+	if t.Image.ValueString() != "" {
+		return "image"
+	}
+	return "pool"
+}
+
+// configCommand returns an `rbd config image|pool` command for the
+// snapshot limit.
+func (t *retentionRBDModel) configCommand(action string) *CommandBuilder {
+	if t.configLevel() == "image" {
+		return NewCommand("rbd", "config", "image", action).Arg(t.spec())
+	}
+	return NewCommand("rbd", "config", "pool", action).Arg(t.Pool.ValueString())
+}
+
+func (r *snapshotRetentionResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_snapshot_retention_policy"
+}
+
+func (r *snapshotRetentionResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	count := func(period string) schema.Int64Attribute {
+		return schema.Int64Attribute{
+			Description: fmt.Sprintf("Number of %s snapshots to keep", period),
+			Optional:    true,
+		}
+	}
+	resp.Schema = schema.Schema{
+		Description: "Manages a snapshot schedule and its retention for a CephFS directory or RBD images",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("cephfs:<fs>:<path> or rbd:<pool>[/<namespace>][/<image>]"),
+			"cephfs": schema.SingleNestedAttribute{
+				Description: "CephFS directory to snapshot with the snap_schedule manager module",
+				Optional:    true,
+				Attributes: map[string]schema.Attribute{
+					"fs_name": schema.StringAttribute{
+						Description: "File system name",
+						Required:    true,
+					},
+					"path": schema.StringAttribute{
+						Description: "Absolute path of the directory in the file system",
+						Required:    true,
+					},
+				},
+			},
+			"rbd": schema.SingleNestedAttribute{
+				Description: "RBD pool, namespace or image to take mirror snapshots of; its images need snapshot-based mirroring",
+				Optional:    true,
+				Attributes: map[string]schema.Attribute{
+					"pool": schema.StringAttribute{
+						Description: "Pool name",
+						Required:    true,
+					},
+					"namespace": schema.StringAttribute{
+						Description: "RBD namespace in the pool",
+						Optional:    true,
+					},
+					"image": schema.StringAttribute{
+						Description: "Image name; every mirrored image of the pool or namespace when unset",
+						Optional:    true,
+					},
+				},
+			},
+			"hourly":  count("hourly"),
+			"daily":   count("daily"),
+			"weekly":  count("weekly"),
+			"monthly": count("monthly"),
+			"schedule": schema.StringAttribute{
+				Description: "Snapshot interval derived from the finest count, e.g. 1h",
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (r *snapshotRetentionResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *snapshotRetentionResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config snapshotRetentionResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if (config.CephFS == nil) == (config.RBD == nil) {
+		resp.Diagnostics.AddError("Invalid snapshot retention target", "Set exactly one of cephfs and rbd.")
+	}
+	if config.CephFS != nil && !config.CephFS.Path.IsUnknown() && !strings.HasPrefix(config.CephFS.Path.ValueString(), "/") {
+		resp.Diagnostics.AddAttributeError(path.Root("cephfs").AtName("path"), "Invalid path",
+			fmt.Sprintf("path must be absolute, not %q", config.CephFS.Path.ValueString()))
+	}
+
+	set := 0
+	for i, count := range config.counts() {
+		if count.IsNull() {
+			continue
+		}
+		set++
+		if !count.IsUnknown() && count.ValueInt64() < 1 {
+			resp.Diagnostics.AddAttributeError(path.Root(retentionPeriods[i].Name), "Invalid retention count",
+				fmt.Sprintf("%s must be at least 1; leave it unset to keep no %s snapshots", retentionPeriods[i].Name, retentionPeriods[i].Name))
+		}
+	}
+	if set == 0 {
+		resp.Diagnostics.AddError("Missing retention", "Set at least one of hourly, daily, weekly and monthly.")
+	}
+	if config.RBD == nil || set == 0 {
+		return
+	}
+	// RBD keeps the newest N mirror snapshots and cannot thin them out.
+	if set > 1 {
+		resp.Diagnostics.AddAttributeError(path.Root("rbd"), "RBD keeps a single count",
+			"RBD keeps the newest rbd_mirroring_max_mirroring_snapshots mirror snapshots, so an rbd target takes exactly one of hourly, daily, weekly and monthly.")
+	}
+	for i, count := range config.counts() {
+		if !count.IsNull() && !count.IsUnknown() && count.ValueInt64() < 3 {
+			resp.Diagnostics.AddAttributeError(path.Root(retentionPeriods[i].Name), "Invalid retention count",
+				fmt.Sprintf("RBD keeps at least 3 mirror snapshots, so %s must be at least 3 for an rbd target", retentionPeriods[i].Name))
+		}
+	}
+}
+
+// snapScheduleStatus is one schedule in `ceph fs snap-schedule status`.
+type snapScheduleStatus struct {
+	Path      string           `json:"path"`
+	Schedule  string           `json:"schedule"`
+	Retention map[string]int64 `json:"retention"`
+}
+
+// SnapSchedules returns the snapshot schedules of a CephFS directory.
+func (c *CephClient) SnapSchedules(fs, dir string) ([]snapScheduleStatus, error) {
+	var schedules []snapScheduleStatus
+	err := c.ExecuteJSON(NewCommand("ceph", "fs", "snap-schedule", "status").Arg(dir).Option("--fs", fs), &schedules)
+	if err != nil && strings.Contains(err.Error(), "No such file or directory") {
+		return nil, nil
+	}
+	return schedules, err
+}
+
+// applyRetention schedules snapshots of the plan's target and sets its
+// retention, and returns the schedule interval.
+func (r *snapshotRetentionResource) applyRetention(plan *snapshotRetentionResourceModel) (string, error) {
+	periods, counts := plan.retention()
+	if len(periods) == 0 {
+		return "", fmt.Errorf("no retention count is set")
+	}
+
+	if fs := plan.CephFS; fs != nil {
+		name, dir := fs.FSName.ValueString(), fs.Path.ValueString()
+		interval := periods[0].Interval
+		if _, err := r.client.ExecuteCommand(NewCommand("ceph", "fs", "snap-schedule", "add").Arg(dir, interval).Option("--fs", name)); err != nil {
+			return "", err
+		}
+		for i, period := range periods {
+			cmd := NewCommand("ceph", "fs", "snap-schedule", "retention", "add").Arg(dir, period.Spec).Int(counts[i]).Option("--fs", name)
+			if _, err := r.client.ExecuteCommand(cmd); err != nil {
+				return "", err
+			}
+		}
+		return interval, nil
+	}
+
+	target := plan.RBD
+	interval := periods[0].RBDInterval
+	if _, err := r.client.ExecuteCommand(target.scheduleCommand("add").Arg(interval)); err != nil {
+		return "", err
+	}
+	if _, err := r.client.ExecuteCommand(target.configCommand("set").Arg(rbdMaxMirrorSnapshotsOption).Int(counts[0])); err != nil {
+		return "", err
+	}
+	return interval, nil
+}
+
+// clearRetention removes the schedule recorded in state. CephFS drops the
+// retention with its schedule; snapshots already taken are kept.
+func (r *snapshotRetentionResource) clearRetention(state *snapshotRetentionResourceModel) error {
+	interval := state.Schedule.ValueString()
+	if fs := state.CephFS; fs != nil {
+		_, err := r.client.ExecuteCommand(NewCommand("ceph", "fs", "snap-schedule", "remove").
+			Arg(fs.Path.ValueString(), interval).Option("--fs", fs.FSName.ValueString()))
+		return err
+	}
+
+	target := state.RBD
+	if _, err := r.client.ExecuteCommand(target.scheduleCommand("remove").Arg(interval)); err != nil {
+		return err
+	}
+	_, err := r.client.ExecuteCommand(target.configCommand("remove").Arg(rbdMaxMirrorSnapshotsOption))
+	return err
+}
+
+// readRetention refreshes the counts of state from the cluster. It
+// returns false when the schedule is gone.
+func (r *snapshotRetentionResource) readRetention(state *snapshotRetentionResourceModel) (bool, error) {
+	interval := state.Schedule.ValueString()
+	if fs := state.CephFS; fs != nil {
+		schedules, err := r.client.SnapSchedules(fs.FSName.ValueString(), fs.Path.ValueString())
+		if err != nil {
+			return false, err
+		}
+		for _, schedule := range schedules {
+			if schedule.Path != fs.Path.ValueString() || schedule.Schedule != interval {
+				continue
+			}
+			for i, count := range state.counts() {
+				*count = optionalInt64(schedule.Retention[retentionPeriods[i].Spec])
+			}
+			return true, nil
+		}
+		return false, nil
+	}
+
+	target := state.RBD
+	var schedules []struct {
+		Interval string `json:"interval"`
+	}
+	if err := r.client.ExecuteJSON(target.scheduleCommand("ls"), &schedules); err != nil {
+		return false, err
+	}
+	found := false
+	for _, schedule := range schedules {
+		found = found || schedule.Interval == interval
+	}
+	if !found {
+		return false, nil
+	}
+
+	// The list includes inherited values; only one set at the target's
+	// level is the policy's.
+	var options []struct {
+		Name   string `json:"name"`
+		Value  string `json:"value"`
+		Source string `json:"source"`
+	}
+	if err := r.client.ExecuteJSON(target.configCommand("list"), &options); err != nil {
+		return false, err
+	}
+	for i, count := range state.counts() {
+		if retentionPeriods[i].RBDInterval == interval {
+			*count = types.Int64Null()
+		}
+	}
+	for _, option := range options {
+		if option.Name != rbdMaxMirrorSnapshotsOption || option.Source != target.configLevel() {
+			continue
+		}
+		limit, err := strconv.ParseInt(option.Value, 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s %q", rbdMaxMirrorSnapshotsOption, option.Value)
+		}
+		for i, count := range state.counts() {
+			if retentionPeriods[i].RBDInterval == interval {
+				*count = types.Int64Value(limit)
+			}
+		}
+	}
+	return true, nil
+}
+
+func (r *snapshotRetentionResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan snapshotRetentionResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	interval, err := r.applyRetention(&plan)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to schedule snapshots", err)
+		return
+	}
+	plan.ID = types.StringValue(plan.id())
+	plan.Schedule = types.StringValue(interval)
+
+	tflog.Info(ctx, "Created Ceph snapshot retention policy", map[string]interface{}{
+		"target":   plan.ID.ValueString(),
+		"schedule": interval,
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *snapshotRetentionResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state snapshotRetentionResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	exists, err := r.readRetention(&state)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read snapshot schedule", err)
+		return
+	}
+	if !exists {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *snapshotRetentionResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan snapshotRetentionResourceModel
+	var state snapshotRetentionResourceModel
+
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The old schedule is removed before the new one is added, as its
+	// interval or target may differ. Snapshots already taken are kept
+	// and pruned by the new retention.
+	if err := r.clearRetention(&state); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove snapshot schedule", err)
+		return
+	}
+	interval, err := r.applyRetention(&plan)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to schedule snapshots", err)
+		return
+	}
+	plan.ID = types.StringValue(plan.id())
+	plan.Schedule = types.StringValue(interval)
+
+	tflog.Info(ctx, "Updated Ceph snapshot retention policy", map[string]interface{}{
+		"target":   plan.ID.ValueString(),
+		"schedule": interval,
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *snapshotRetentionResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state snapshotRetentionResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.clearRetention(&state); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove snapshot schedule", err)
+		return
+	}
+
+	tflog.Info(ctx, "Deleted Ceph snapshot retention policy", map[string]interface{}{
+		"target": state.ID.ValueString(),
+	})
+}
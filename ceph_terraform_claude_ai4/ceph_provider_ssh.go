@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Remote execution over SSH and through exec wrappers (cephadm shell,
+// kubectl exec). Each command runs as a small shell script on the other
+// side, which only needs a POSIX shell, base64 and the Ceph CLI. Temp files
+// the provider passes to a command (keyrings, service specs, CRUSH maps)
+// are shipped inside the script, and files the command writes with -o are
+// sent back on stdout. The script always travels on stdin, never on a
+// command line, since it may hold an inline keyring.
+
+type sshTarget struct {
+	Host       string
+	User       string
+	Port       int64
+	PrivateKey string
+
+	// Wrapper, if set, is run on the admin node around the script, e.g.
+	// cephadm shell.
+	Wrapper []string
+}
+
+// remoteOutputMarker separates the command's stdout from the contents of
+// its output files.
+const remoteOutputMarker = "--- ceph-terraform output file ---"
+
+type sshRunner struct {
+	target sshTarget
+}
+
+func newSSHRunner(target sshTarget) commandRunner {
+	r := &sshRunner{target: target}
+	return r.run
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isLocalTempFile reports whether path is one of the provider's own temp
+// files, which only exist on the Terraform host.
+func isLocalTempFile(path string) bool {
+	tmp := filepath.Clean(os.TempDir()) + string(filepath.Separator)
+	return strings.HasPrefix(filepath.Clean(path), tmp)
+}
+
+// remoteScript renders the script that runs args on the other side. It
+// returns the local paths of the -o output files in the order the script
+// prints them.
+func remoteScript(args []string) (string, []string, error) {
+	var b strings.Builder
+	b.WriteString("set -e\nd=$(mktemp -d)\ntrap 'rm -rf \"$d\"' EXIT\n")
+
+	var words, outputs []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-o" && i+1 < len(args) && isLocalTempFile(args[i+1]) {
+			words = append(words, "-o", fmt.Sprintf(`"$d/out%d"`, len(outputs)))
+			outputs = append(outputs, args[i+1])
+			i++
+			continue
+		}
+		if isLocalTempFile(arg) {
+			info, err := os.Stat(arg)
+			if err == nil && info.Mode().IsRegular() {
+				data, err := os.ReadFile(arg)
+				if err != nil {
+					return "", nil, fmt.Errorf("failed to read %s: %w", arg, err)
+				}
+				name := fmt.Sprintf(`"$d/in%d"`, i)
+				fmt.Fprintf(&b, "base64 -d > %s <<'CEPH_TF_EOF'\n%s\nCEPH_TF_EOF\n", name, base64.StdEncoding.EncodeToString(data))
+				words = append(words, name)
+				continue
+			}
+		}
+		words = append(words, shellQuote(arg))
+	}
+
+	b.WriteString(strings.Join(words, " ") + "\n")
+	for i := range outputs {
+		fmt.Fprintf(&b, "printf '\\n%%s\\n' %s\nbase64 \"$d/out%d\"\n", shellQuote(remoteOutputMarker), i)
+	}
+	return b.String(), outputs, nil
+}
+
+// splitRemoteOutput separates the command's stdout from the output files
+// appended by the script and writes the files locally.
+func splitRemoteOutput(stdout string, outputs []string) (string, error) {
+	parts := strings.Split(stdout, "\n"+remoteOutputMarker+"\n")
+	if len(parts) != len(outputs)+1 {
+		return "", fmt.Errorf("expected %d output files from the remote command, got %d", len(outputs), len(parts)-1)
+	}
+	for i, path := range outputs {
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(parts[i+1]), ""))
+		if err != nil {
+			return "", fmt.Errorf("failed to decode %s from the remote command: %w", path, err)
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return parts[0], nil
+}
+
+func (r *sshRunner) run(ctx context.Context, args []string) (string, error) {
+	script, outputs, err := remoteScript(args)
+	if err != nil {
+		return "", err
+	}
+
+	sshArgs := []string{"-o", "BatchMode=yes", "-o", "StrictHostKeyChecking=accept-new"}
+	if r.target.Port != 0 {
+		sshArgs = append(sshArgs, "-p", fmt.Sprint(r.target.Port))
+	}
+	if r.target.PrivateKey != "" {
+		dir, err := os.MkdirTemp("", "ceph-ssh")
+		if err != nil {
+			return "", fmt.Errorf("failed to create temp dir: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		keyFile := filepath.Join(dir, "id")
+		key := strings.TrimSpace(r.target.PrivateKey) + "\n"
+		if err := os.WriteFile(keyFile, []byte(key), 0600); err != nil {
+			return "", fmt.Errorf("failed to write SSH private key: %w", err)
+		}
+		sshArgs = append(sshArgs, "-i", keyFile, "-o", "IdentitiesOnly=yes")
+	}
+	host := r.target.Host
+	if r.target.User != "" {
+		host = r.target.User + "@" + host
+	}
+	sshArgs = append(sshArgs, host)
+
+	// ssh hands the remote shell a single command line, so each word is
+	// quoted for it.
+	var remote []string
+	for _, word := range append(append([]string{}, r.target.Wrapper...), "sh", "-s") {
+		remote = append(remote, shellQuote(word))
+	}
+	cmd := exec.CommandContext(ctx, "ssh", append(sshArgs, strings.Join(remote, " "))...)
+	cmd.Stdin = strings.NewReader(script)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return splitRemoteOutput(string(out), outputs)
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Stretch rules. Stretch mode (`ceph mon enable_stretch_mode`) needs a
+// replicated rule that places two copies in each of two datacenters, and
+// pools using it run with size 4 and min_size 2. No mon command creates a
+// rule with two take steps, so the rule is added to the decompiled CRUSH
+// map and the map is set again, which is easy to get wrong by hand.
+
+// Size and min_size of a pool in stretch mode: two copies per site, and
+// I/O continues with one site down.
+const (
+	stretchRuleSize    = 4
+	stretchRuleMinSize = 2
+)
+
+// crushRuleDump is a rule as `ceph osd crush dump` prints it.
+type crushRuleDump struct {
+	RuleID   int64  `json:"rule_id"`
+	RuleName string `json:"rule_name"`
+	Steps    []struct {
+		Op       string `json:"op"`
+		ItemName string `json:"item_name"`
+		Num      int64  `json:"num"`
+		Type     string `json:"type"`
+	} `json:"steps"`
+}
+
+func parseCrushDump(output string) (*crushDump, error) {
+	var dump crushDump
+	if err := json.Unmarshal([]byte(output), &dump); err != nil {
+		return nil, fmt.Errorf("failed to parse crush dump: %w", err)
+	}
+	return &dump, nil
+}
+
+// stretchRuleText renders the rule in CRUSH map syntax: for each
+// datacenter, take it (or its device class shadow tree) and choose two
+// distinct failure domains.
+func stretchRuleText(name string, id int64, datacenters []string, failureDomain, class string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "rule %s {\n\tid %d\n\ttype replicated\n", name, id)
+	for _, dc := range datacenters {
+		take := dc
+		if class != "" {
+			take += " class " + class
+		}
+		fmt.Fprintf(&b, "\tstep take %s\n\tstep chooseleaf firstn %d type %s\n\tstep emit\n",
+			take, stretchRuleSize/len(datacenters), failureDomain)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// insertCrushRule adds a rule to a decompiled CRUSH map. crushtool wants
+// rules after the buckets and before any choose_args, so the rule goes in
+// front of the first choose_args section or the end marker.
+func insertCrushRule(mapText, rule string) string {
+	for _, marker := range []string{"\n# choose_args", "\nchoose_args ", "\n# end crush map"} {
+		if i := strings.Index(mapText, marker); i >= 0 {
+			return mapText[:i+1] + rule + mapText[i+1:]
+		}
+	}
+	if !strings.HasSuffix(mapText, "\n") {
+		mapText += "\n"
+	}
+	return mapText + rule
+}
+
+// stretchRuleLayout reads back the datacenters, failure domain and device
+// class of a rule from its steps. Device class takes name the shadow
+// bucket, e.g. dc1~ssd.
+func stretchRuleLayout(rule *crushRuleDump) (datacenters []string, failureDomain, class string) {
+	for _, step := range rule.Steps {
+		switch step.Op {
+		case "take":
+			name, shadowClass, _ := strings.Cut(step.ItemName, "~")
+			datacenters = append(datacenters, name)
+			class = shadowClass
+		case "chooseleaf_firstn", "chooseleaf_indep":
+			failureDomain = step.Type
+		}
+	}
+	return datacenters, failureDomain, class
+}
+
+// CreateStretchRule adds the stretch rule to the CRUSH map and returns its
+// id. The datacenters must already be CRUSH buckets.
+func (c *CephClient) CreateStretchRule(name string, datacenters []string, failureDomain, class string) (int64, error) {
+	output, err := c.ReadJSON(NewCommand("ceph", "osd", "crush", "dump"))
+	if err != nil {
+		return 0, err
+	}
+	dump, err := parseCrushDump(output)
+	if err != nil {
+		return 0, err
+	}
+
+	buckets := make(map[string]bool)
+	for _, b := range dump.Buckets {
+		buckets[b.Name] = true
+	}
+	for _, dc := range datacenters {
+		if !buckets[dc] {
+			return 0, fmt.Errorf("CRUSH bucket %s does not exist; add it with `ceph osd crush add-bucket %s datacenter` and move its hosts under it", dc, dc)
+		}
+	}
+
+	var id int64
+	for _, rule := range dump.Rules {
+		if rule.RuleName == name {
+			return 0, fmt.Errorf("CRUSH rule %s already exists", name)
+		}
+		if rule.RuleID >= id {
+			id = rule.RuleID + 1
+		}
+	}
+
+	mapText, err := c.GetCrushMapText()
+	if err != nil {
+		return 0, err
+	}
+	rule := stretchRuleText(name, id, datacenters, failureDomain, class)
+	if err := c.SetCrushMapText(insertCrushRule(mapText, rule)); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// CrushRule returns the rule with the given name from `ceph osd crush
+// dump`, or nil if there is none.
+func (c *CephClient) CrushRule(name string) (*crushRuleDump, error) {
+	output, err := c.ReadJSON(NewCommand("ceph", "osd", "crush", "dump"))
+	if err != nil {
+		return nil, err
+	}
+	dump, err := parseCrushDump(output)
+	if err != nil {
+		return nil, err
+	}
+	for i := range dump.Rules {
+		if dump.Rules[i].RuleName == name {
+			return &dump.Rules[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// OSD Pool Stretch Rule Resource
+type stretchRuleResource struct {
+	client *CephClient
+}
+
+type stretchRuleResourceModel struct {
+	ID            types.String `tfsdk:"id"`
+	Name          types.String `tfsdk:"name"`
+	Datacenters   types.List   `tfsdk:"datacenters"`
+	FailureDomain types.String `tfsdk:"failure_domain"`
+	DeviceClass   types.String `tfsdk:"device_class"`
+	RuleID        types.Int64  `tfsdk:"rule_id"`
+	Size          types.Int64  `tfsdk:"size"`
+	MinSize       types.Int64  `tfsdk:"min_size"`
+}
+
+func NewStretchRuleResource() resource.Resource {
+	return &stretchRuleResource{}
+}
+
+func (r *stretchRuleResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_osd_pool_stretch_rule"
+}
+
+func (r *stretchRuleResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Creates the CRUSH rule for stretch mode, placing two copies in each of two datacenters",
+		Attributes: map[string]schema.Attribute{
+			"id": resourceIDAttribute("Rule name"),
+			"name": schema.StringAttribute{
+				Description: "Rule name, e.g. stretch_rule",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"datacenters": schema.ListAttribute{
+				Description: "The two CRUSH buckets, usually of type datacenter, that hold the sites' hosts",
+				ElementType: types.StringType,
+				Required:    true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"failure_domain": schema.StringAttribute{
+				Description: "Bucket type the two copies in a site are spread over",
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("host"),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"device_class": schema.StringAttribute{
+				Description: "Only place data on OSDs of this device class, e.g. ssd",
+				Optional:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"rule_id": schema.Int64Attribute{
+				Description: "Id of the rule in the CRUSH map",
+				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"size": schema.Int64Attribute{
+				Description: "Size for pools using the rule in stretch mode",
+				Computed:    true,
+				Default:     int64default.StaticInt64(stretchRuleSize),
+			},
+			"min_size": schema.Int64Attribute{
+				Description: "min_size for pools using the rule in stretch mode",
+				Computed:    true,
+				Default:     int64default.StaticInt64(stretchRuleMinSize),
+			},
+		},
+	}
+}
+
+func (r *stretchRuleResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	r.client = req.ProviderData.(*CephClient)
+}
+
+func (r *stretchRuleResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config stretchRuleResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || config.Datacenters.IsUnknown() {
+		return
+	}
+
+	var datacenters []types.String
+	resp.Diagnostics.Append(config.Datacenters.ElementsAs(ctx, &datacenters, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if len(datacenters) != 2 {
+		resp.Diagnostics.AddAttributeError(path.Root("datacenters"), "Invalid datacenters",
+			fmt.Sprintf("Stretch mode spans exactly two datacenters, got %d.", len(datacenters)))
+		return
+	}
+	if !datacenters[0].IsUnknown() && datacenters[0].Equal(datacenters[1]) {
+		resp.Diagnostics.AddAttributeError(path.Root("datacenters"), "Invalid datacenters",
+			fmt.Sprintf("The two datacenters must differ, got %s twice.", datacenters[0].ValueString()))
+	}
+}
+
+func (r *stretchRuleResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan stretchRuleResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var datacenters []string
+	diags = plan.Datacenters.ElementsAs(ctx, &datacenters, false)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	id, err := r.client.CreateStretchRule(plan.Name.ValueString(), datacenters, plan.FailureDomain.ValueString(), plan.DeviceClass.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to create stretch rule", err)
+		return
+	}
+	plan.ID = plan.Name
+	plan.RuleID = types.Int64Value(id)
+
+	tflog.Info(ctx, "Created Ceph stretch rule", map[string]interface{}{
+		"name":    plan.Name.ValueString(),
+		"rule_id": id,
+	})
+
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *stretchRuleResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state stretchRuleResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	rule, err := r.client.CrushRule(state.Name.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read stretch rule", err)
+		return
+	}
+	if rule == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// A rule edited outside Terraform shows up as a replacement.
+	datacenters, failureDomain, class := stretchRuleLayout(rule)
+	state.Datacenters, diags = types.ListValueFrom(ctx, types.StringType, datacenters)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	state.FailureDomain = types.StringValue(failureDomain)
+	state.DeviceClass = types.StringNull()
+	if class != "" {
+		state.DeviceClass = types.StringValue(class)
+	}
+	state.ID = state.Name
+	state.RuleID = types.Int64Value(rule.RuleID)
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *stretchRuleResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Every setting replaces the rule.
+	var plan stretchRuleResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	diags = resp.State.Set(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *stretchRuleResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state stretchRuleResourceModel
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The monitors refuse to remove a rule that a pool still uses.
+	if _, err := r.client.ExecuteCommand(NewCommand("ceph", "osd", "crush", "rule", "rm").Arg(state.Name.ValueString())); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove stretch rule", err)
+		return
+	}
+
+	tflog.Info(ctx, "Removed Ceph stretch rule", map[string]interface{}{
+		"name": state.Name.ValueString(),
+	})
+}
@@ -0,0 +1,524 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	dsschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Tags. Pools and images keep their tags in metadata Ceph already has for
+// them: the application metadata of the pool's first application in name
+// order (moved there when the applications change), and
+// the image's image-meta, under keys starting with "tag.". Ceph users,
+// RGW users and buckets have no metadata an admin can read cluster-wide,
+// so their tags are kept as JSON in the config-key store, under
+// terraform/tags/<kind>/<id>. Tags are only managed and refreshed when a
+// resource sets them; removing them from the configuration clears them.
+
+// tagMetaPrefix marks tags among other pool application metadata and
+// image-meta keys.
+const tagMetaPrefix = "tag."
+
+// tagRegistryPrefix is the config-key prefix of the tag registry.
+const tagRegistryPrefix = "terraform/tags/"
+
+// Kinds in the tag registry, and the resource type each one tags.
+var tagRegistryKinds = map[string]string{
+	"user":       "ceph_user",
+	"rgw_user":   "ceph_rgw_user",
+	"rgw_bucket": "ceph_rgw_bucket",
+}
+
+func tagsAttribute() schema.MapAttribute {
+	return schema.MapAttribute{
+		Description: "Tags for ownership tracking, found again with the ceph_resources_by_tag data source; only refreshed when set",
+		ElementType: types.StringType,
+		Optional:    true,
+	}
+}
+
+// validateTags reports tag keys Ceph metadata cannot hold, and values the
+// CLI would refuse as arguments at apply time.
+func validateTags(tags types.Map, diags *diag.Diagnostics) {
+	if tags.IsNull() || tags.IsUnknown() {
+		return
+	}
+	for key, value := range tags.Elements() {
+		if key == "" || strings.ContainsAny(key, " \t\n=") {
+			diags.AddAttributeError(path.Root("tags"), "Invalid tag key",
+				fmt.Sprintf("tag keys must be non-empty and contain no whitespace or '=', got %q", key))
+		}
+		s, ok := value.(types.String)
+		if !ok || s.IsNull() || s.IsUnknown() {
+			continue
+		}
+		if v := s.ValueString(); v == "" || strings.HasPrefix(v, "-") {
+			diags.AddAttributeError(path.Root("tags").AtMapKey(key), "Invalid tag value",
+				fmt.Sprintf("tag values must be non-empty and cannot start with '-', got %q for %q", v, key))
+		}
+	}
+}
+
+// tagsFromMap returns the tags of a map attribute; a null map has none.
+func tagsFromMap(ctx context.Context, tags types.Map) (map[string]string, error) {
+	result := map[string]string{}
+	if tags.IsNull() || tags.IsUnknown() {
+		return result, nil
+	}
+	if diags := tags.ElementsAs(ctx, &result, false); diags.HasError() {
+		return nil, fmt.Errorf("invalid tags")
+	}
+	return result, nil
+}
+
+// tagsValue returns tags as a map attribute.
+func tagsValue(ctx context.Context, tags map[string]string) (types.Map, error) {
+	value, diags := types.MapValueFrom(ctx, types.StringType, tags)
+	if diags.HasError() {
+		return types.MapNull(types.StringType), fmt.Errorf("invalid tags")
+	}
+	return value, nil
+}
+
+// metaTags returns the tags among metadata keys, without their prefix.
+func metaTags(meta map[string]string) map[string]string {
+	tags := map[string]string{}
+	for key, value := range meta {
+		if strings.HasPrefix(key, tagMetaPrefix) {
+			tags[strings.TrimPrefix(key, tagMetaPrefix)] = value
+		}
+	}
+	return tags
+}
+
+// tagChanges returns the tags to set and the keys to remove, sorted, to
+// get from current to planned.
+func tagChanges(planned, current map[string]string) ([]string, []string) {
+	var set, removed []string
+	for key, value := range planned {
+		if old, ok := current[key]; !ok || old != value {
+			set = append(set, key)
+		}
+	}
+	for key := range current {
+		if _, ok := planned[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(set)
+	sort.Strings(removed)
+	return set, removed
+}
+
+// poolTags returns the application that holds the pool's tags, its first
+// in name order, and the tags. Tags still under another application, where
+// they were before an application sorting earlier was added, are included
+// until the next apply moves them; the holder's values win.
+func poolTags(pool *poolDetail) (string, map[string]string) {
+	apps := pool.Applications()
+	tags := map[string]string{}
+	for i := len(apps) - 1; i >= 0; i-- {
+		for key, value := range applicationTags(pool, apps[i]) {
+			tags[key] = value
+		}
+	}
+	if len(apps) == 0 {
+		return "", tags
+	}
+	return apps[0], tags
+}
+
+// applicationTags returns the tags in one application's metadata.
+func applicationTags(pool *poolDetail, app string) map[string]string {
+	var meta map[string]string
+	_ = json.Unmarshal(pool.ApplicationMetadata[app], &meta)
+	return metaTags(meta)
+}
+
+// SetPoolTags replaces the pool's tags. They live in application
+// metadata, so the pool needs an application; tags left under other
+// applications are removed from them.
+func (c *CephClient) SetPoolTags(name string, tags map[string]string) error {
+	pool, err := c.GetPoolDetail(name)
+	if err == nil && pool == nil {
+		err = fmt.Errorf("pool %s not found", name)
+	}
+	if err != nil {
+		return err
+	}
+	holder, _ := poolTags(pool)
+	if holder == "" {
+		if len(tags) == 0 {
+			return nil
+		}
+		return fmt.Errorf("pool %s has no application to keep tags in; set applications", name)
+	}
+
+	for _, app := range pool.Applications() {
+		planned := tags
+		if app != holder {
+			planned = nil
+		}
+		set, removed := tagChanges(planned, applicationTags(pool, app))
+		for _, key := range set {
+			cmd := NewCommand("ceph", "osd", "pool", "application", "set").Arg(name, app, tagMetaPrefix+key, tags[key])
+			if _, err := c.ExecuteCommand(cmd); err != nil {
+				return err
+			}
+		}
+		for _, key := range removed {
+			cmd := NewCommand("ceph", "osd", "pool", "application", "rm").Arg(name, app, tagMetaPrefix+key)
+			if _, err := c.ExecuteCommand(cmd); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ImageTags returns the tags in an image's image-meta.
+func (c *CephClient) ImageTags(spec string) (map[string]string, error) {
+	var meta map[string]string
+	if err := c.ExecuteJSON(NewCommand("rbd", "image-meta", "list").Arg(spec), &meta); err != nil {
+		return nil, err
+	}
+	return metaTags(meta), nil
+}
+
+// SetImageTags replaces the tags in an image's image-meta.
+func (c *CephClient) SetImageTags(spec string, tags map[string]string) error {
+	current, err := c.ImageTags(spec)
+	if err != nil {
+		return err
+	}
+	set, removed := tagChanges(tags, current)
+	for _, key := range set {
+		if _, err := c.ExecuteCommand(NewCommand("rbd", "image-meta", "set").Arg(spec, tagMetaPrefix+key, tags[key])); err != nil {
+			return err
+		}
+	}
+	for _, key := range removed {
+		if _, err := c.ExecuteCommand(NewCommand("rbd", "image-meta", "remove").Arg(spec, tagMetaPrefix+key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tagRegistryKey(kind, id string) string {
+	return tagRegistryPrefix + kind + "/" + id
+}
+
+// tagRegistry returns the registry entries under prefix, keyed by
+// config-key, in one `ceph config-key dump`.
+func (c *CephClient) tagRegistry(prefix string) (map[string]map[string]string, error) {
+	var entries map[string]string
+	if err := c.ExecuteJSON(NewCommand("ceph", "config-key", "dump").Arg(prefix), &entries); err != nil {
+		return nil, err
+	}
+	registry := make(map[string]map[string]string, len(entries))
+	for key, value := range entries {
+		var tags map[string]string
+		if err := json.Unmarshal([]byte(value), &tags); err != nil {
+			return nil, fmt.Errorf("invalid tags in config-key %s: %w", key, err)
+		}
+		registry[key] = tags
+	}
+	return registry, nil
+}
+
+// RegistryTags returns the tags of a resource in the tag registry.
+func (c *CephClient) RegistryTags(kind, id string) (map[string]string, error) {
+	key := tagRegistryKey(kind, id)
+	registry, err := c.tagRegistry(key)
+	if err != nil {
+		return nil, err
+	}
+	// dump matches a prefix, so client.a also lists client.ab.
+	if tags, ok := registry[key]; ok {
+		return tags, nil
+	}
+	return map[string]string{}, nil
+}
+
+// SetRegistryTags replaces the tags of a resource in the tag registry. No
+// tags remove its entry.
+func (c *CephClient) SetRegistryTags(kind, id string, tags map[string]string) error {
+	key := tagRegistryKey(kind, id)
+	if len(tags) == 0 {
+		current, err := c.RegistryTags(kind, id)
+		if err != nil || len(current) == 0 {
+			return err
+		}
+		return c.RemoveConfigKey(key)
+	}
+	value, err := json.Marshal(tags)
+	if err != nil {
+		return err
+	}
+	return c.SetConfigKey(key, string(value))
+}
+
+// applyRegistryTags replaces a resource's registry tags when planned
+// differs from current, its tags in state (null on create).
+func (c *CephClient) applyRegistryTags(ctx context.Context, kind, id string, planned, current types.Map) error {
+	if planned.Equal(current) {
+		return nil
+	}
+	tags, err := tagsFromMap(ctx, planned)
+	if err != nil {
+		return err
+	}
+	return c.SetRegistryTags(kind, id, tags)
+}
+
+// readRegistryTags refreshes a resource's registry tags, if it manages
+// them.
+func (c *CephClient) readRegistryTags(ctx context.Context, kind, id string, current types.Map) (types.Map, error) {
+	if current.IsNull() {
+		return current, nil
+	}
+	tags, err := c.RegistryTags(kind, id)
+	if err != nil {
+		return current, err
+	}
+	return tagsValue(ctx, tags)
+}
+
+// Resources By Tag Data Source
+type resourcesByTagDataSource struct {
+	client *CephClient
+}
+
+type resourcesByTagDataSourceModel struct {
+	ID        types.String          `tfsdk:"id"`
+	Key       types.String          `tfsdk:"key"`
+	Value     types.String          `tfsdk:"value"`
+	Types     types.Set             `tfsdk:"types"`
+	Resources []taggedResourceModel `tfsdk:"resources"`
+}
+
+type taggedResourceModel struct {
+	Type types.String `tfsdk:"type"`
+	ID   types.String `tfsdk:"id"`
+	Tags types.Map    `tfsdk:"tags"`
+}
+
+// taggedResource is one search result before conversion to the model.
+type taggedResource struct {
+	Type string
+	ID   string
+	Tags map[string]string
+}
+
+func NewResourcesByTagDataSource() datasource.DataSource {
+	return &resourcesByTagDataSource{}
+}
+
+func (d *resourcesByTagDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_resources_by_tag"
+}
+
+func (d *resourcesByTagDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = dsschema.Schema{
+		Description: "Finds pools, images, users and buckets by tag",
+		Attributes: map[string]dsschema.Attribute{
+			"id": dataSourceIDAttribute("key or key=value searched for"),
+			"key": dsschema.StringAttribute{
+				Description: "Tag key",
+				Required:    true,
+			},
+			"value": dsschema.StringAttribute{
+				Description: "Tag value; any value when unset",
+				Optional:    true,
+			},
+			"types": dsschema.SetAttribute{
+				Description: "Resource types to search: ceph_pool, ceph_block_image, ceph_user, ceph_rgw_user and ceph_rgw_bucket (all when unset). Images cost one command each",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"resources": dsschema.ListNestedAttribute{
+				Description: "Matching resources, sorted by type and id",
+				Computed:    true,
+				NestedObject: dsschema.NestedAttributeObject{
+					Attributes: map[string]dsschema.Attribute{
+						"type": dsschema.StringAttribute{Computed: true, Description: "Resource type, e.g. ceph_pool"},
+						"id":   dsschema.StringAttribute{Computed: true, Description: "Id of the resource, as in its resource's id"},
+						"tags": dsschema.MapAttribute{Computed: true, ElementType: types.StringType, Description: "All tags of the resource"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *resourcesByTagDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*CephClient)
+}
+
+// FindTagged returns the resources of the given types, all when empty,
+// with the tag key, set to value unless anyValue.
+func (c *CephClient) FindTagged(key, value string, anyValue bool, searched map[string]bool) ([]taggedResource, error) {
+	want := func(resourceType string) bool { return len(searched) == 0 || searched[resourceType] }
+	matches := func(tags map[string]string) bool {
+		v, ok := tags[key]
+		return ok && (anyValue || v == value)
+	}
+	var found []taggedResource
+
+	if want("ceph_pool") || want("ceph_block_image") {
+		output, err := c.ReadJSON(NewCommand("ceph", "osd", "pool", "ls", "detail"))
+		if err != nil {
+			return nil, err
+		}
+		pools, err := parsePoolDetails(output)
+		if err != nil {
+			return nil, err
+		}
+		for i := range pools {
+			pool := &pools[i]
+			if _, tags := poolTags(pool); want("ceph_pool") && matches(tags) {
+				found = append(found, taggedResource{Type: "ceph_pool", ID: pool.PoolName, Tags: tags})
+			}
+			if _, rbd := pool.ApplicationMetadata["rbd"]; !rbd || !want("ceph_block_image") {
+				continue
+			}
+			images, err := c.findTaggedImages(pool.PoolName, matches)
+			if err != nil {
+				return nil, err
+			}
+			found = append(found, images...)
+		}
+	}
+
+	var registryTypes []string
+	for _, resourceType := range tagRegistryKinds {
+		if want(resourceType) {
+			registryTypes = append(registryTypes, resourceType)
+		}
+	}
+	if len(registryTypes) > 0 {
+		registry, err := c.tagRegistry(tagRegistryPrefix)
+		if err != nil {
+			return nil, err
+		}
+		for entry, tags := range registry {
+			kind, id, ok := strings.Cut(strings.TrimPrefix(entry, tagRegistryPrefix), "/")
+			resourceType := tagRegistryKinds[kind]
+			if ok && resourceType != "" && want(resourceType) && matches(tags) {
+				found = append(found, taggedResource{Type: resourceType, ID: id, Tags: tags})
+			}
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Type != found[j].Type {
+			return found[i].Type < found[j].Type
+		}
+		return found[i].ID < found[j].ID
+	})
+	return found, nil
+}
+
+// findTaggedImages searches the images of an RBD pool, in every
+// namespace, with one `rbd image-meta list` per image.
+func (c *CephClient) findTaggedImages(pool string, matches func(map[string]string) bool) ([]taggedResource, error) {
+	namespaces, err := c.ListRBDNamespaces(pool)
+	if err != nil {
+		return nil, err
+	}
+	var found []taggedResource
+	for _, namespace := range append([]string{""}, namespaces...) {
+		cmd := NewCommand("rbd", "ls").Arg(pool)
+		scope := pool
+		if namespace != "" {
+			cmd.Option("--namespace", namespace)
+			scope = radosNamespaceID(pool, namespace)
+		}
+		var images []string
+		if err := c.ExecuteJSON(cmd, &images); err != nil {
+			return nil, err
+		}
+		for _, image := range images {
+			spec := blockImageID(scope, image)
+			tags, err := c.ImageTags(spec)
+			if err != nil {
+				return nil, err
+			}
+			if matches(tags) {
+				found = append(found, taggedResource{Type: "ceph_block_image", ID: spec, Tags: tags})
+			}
+		}
+	}
+	return found, nil
+}
+
+func (d *resourcesByTagDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state resourcesByTagDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	searched := map[string]bool{}
+	if !state.Types.IsNull() {
+		var names []string
+		resp.Diagnostics.Append(state.Types.ElementsAs(ctx, &names, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		known := map[string]bool{"ceph_pool": true, "ceph_block_image": true}
+		for _, resourceType := range tagRegistryKinds {
+			known[resourceType] = true
+		}
+		for _, name := range names {
+			if !known[name] {
+				resp.Diagnostics.AddAttributeError(path.Root("types"), "Invalid resource type",
+					fmt.Sprintf("%q cannot be tagged; use ceph_pool, ceph_block_image, ceph_user, ceph_rgw_user or ceph_rgw_bucket", name))
+				return
+			}
+			searched[name] = true
+		}
+	}
+
+	key := state.Key.ValueString()
+	found, err := d.client.FindTagged(key, state.Value.ValueString(), state.Value.IsNull(), searched)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to search tags", err)
+		return
+	}
+
+	state.Resources = make([]taggedResourceModel, 0, len(found))
+	for _, res := range found {
+		tags, err := tagsValue(ctx, res.Tags)
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid tags", err.Error())
+			return
+		}
+		state.Resources = append(state.Resources, taggedResourceModel{
+			Type: types.StringValue(res.Type),
+			ID:   types.StringValue(res.ID),
+			Tags: tags,
+		})
+	}
+	state.ID = types.StringValue(key)
+	if !state.Value.IsNull() {
+		state.ID = types.StringValue(key + "=" + state.Value.ValueString())
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Time sync status as reported by `ceph time-sync-status --format json`
+type timeSyncStatus struct {
+	TimeSkewStatus map[string]struct {
+		Skew    float64 `json:"skew"`
+		Latency float64 `json:"latency"`
+		Health  string  `json:"health"`
+		Details []struct {
+			Message string `json:"message"`
+		} `json:"details"`
+	} `json:"time_skew_status"`
+	Timechecks struct {
+		Epoch       int64  `json:"epoch"`
+		Round       int64  `json:"round"`
+		RoundStatus string `json:"round_status"`
+	} `json:"timechecks"`
+}
+
+func parseTimeSyncStatus(output string) (*timeSyncStatus, error) {
+	var status timeSyncStatus
+	if err := json.Unmarshal([]byte(output), &status); err != nil {
+		return nil, fmt.Errorf("failed to parse time sync status: %w", err)
+	}
+	return &status, nil
+}
+
+// Time Sync Status Data Source
+type timeSyncStatusDataSource struct {
+	client *CephClient
+}
+
+type timeSyncStatusDataSourceModel struct {
+	ID             types.String   `tfsdk:"id"`
+	MaxAllowedSkew types.Float64  `tfsdk:"max_allowed_skew"`
+	MaxSkew        types.Float64  `tfsdk:"max_skew"`
+	Healthy        types.Bool     `tfsdk:"healthy"`
+	RoundStatus    types.String   `tfsdk:"round_status"`
+	Monitors       []monSkewModel `tfsdk:"monitors"`
+	RawJSON        types.String   `tfsdk:"raw_json"`
+}
+
+type monSkewModel struct {
+	Name    types.String  `tfsdk:"name"`
+	Skew    types.Float64 `tfsdk:"skew"`
+	Latency types.Float64 `tfsdk:"latency"`
+	Health  types.String  `tfsdk:"health"`
+}
+
+func NewTimeSyncStatusDataSource() datasource.DataSource {
+	return &timeSyncStatusDataSource{}
+}
+
+func (d *timeSyncStatusDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_time_sync_status"
+}
+
+func (d *timeSyncStatusDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Monitor time synchronization status and clock skew",
+		Attributes: map[string]schema.Attribute{
+			"id":       dataSourceIDAttribute("Always time_sync_status"),
+			"raw_json": rawJSONAttribute("`ceph time-sync-status`"),
+			"max_allowed_skew": schema.Float64Attribute{
+				Description: "Fail the read when any monitor's absolute clock skew in seconds exceeds this value or a monitor reports unhealthy time sync",
+				Optional:    true,
+			},
+			"max_skew": schema.Float64Attribute{
+				Description: "Largest absolute clock skew across monitors in seconds",
+				Computed:    true,
+			},
+			"healthy": schema.BoolAttribute{
+				Description: "Whether all monitors report HEALTH_OK time sync",
+				Computed:    true,
+			},
+			"round_status": schema.StringAttribute{
+				Description: "Status of the latest time check round",
+				Computed:    true,
+			},
+			"monitors": schema.ListNestedAttribute{
+				Description: "Per-monitor skew, sorted by monitor name",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Description: "Monitor name",
+							Computed:    true,
+						},
+						"skew": schema.Float64Attribute{
+							Description: "Clock skew relative to the leader in seconds",
+							Computed:    true,
+						},
+						"latency": schema.Float64Attribute{
+							Description: "Latency to the leader in seconds",
+							Computed:    true,
+						},
+						"health": schema.StringAttribute{
+							Description: "Time sync health of the monitor",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *timeSyncStatusDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*CephClient)
+}
+
+func (d *timeSyncStatusDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state timeSyncStatusDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	output, err := d.client.ReadJSON(NewCommand("ceph", "time-sync-status"))
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to get time sync status", err)
+		return
+	}
+
+	status, err := parseTimeSyncStatus(output)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse time sync status", err)
+		return
+	}
+	state.RawJSON, err = rawJSONValue([]byte(output))
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse time sync status", err)
+		return
+	}
+
+	names := make([]string, 0, len(status.TimeSkewStatus))
+	for name := range status.TimeSkewStatus {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	maxSkew := 0.0
+	healthy := true
+	var problems []string
+	state.Monitors = make([]monSkewModel, 0, len(names))
+	for _, name := range names {
+		mon := status.TimeSkewStatus[name]
+		skew := math.Abs(mon.Skew)
+		if skew > maxSkew {
+			maxSkew = skew
+		}
+		if mon.Health != "HEALTH_OK" {
+			healthy = false
+			detail := fmt.Sprintf("mon.%s: %s", name, mon.Health)
+			for _, msg := range mon.Details {
+				detail += " (" + msg.Message + ")"
+			}
+			problems = append(problems, detail)
+		}
+		if !state.MaxAllowedSkew.IsNull() && skew > state.MaxAllowedSkew.ValueFloat64() {
+			problems = append(problems, fmt.Sprintf("mon.%s: skew %.3fs exceeds max_allowed_skew %.3fs",
+				name, mon.Skew, state.MaxAllowedSkew.ValueFloat64()))
+		}
+
+		state.Monitors = append(state.Monitors, monSkewModel{
+			Name:    types.StringValue(name),
+			Skew:    types.Float64Value(mon.Skew),
+			Latency: types.Float64Value(mon.Latency),
+			Health:  types.StringValue(mon.Health),
+		})
+	}
+
+	state.ID = types.StringValue("time_sync_status")
+	state.MaxSkew = types.Float64Value(maxSkew)
+	state.Healthy = types.BoolValue(healthy)
+	state.RoundStatus = types.StringValue(status.Timechecks.RoundStatus)
+
+	if !state.MaxAllowedSkew.IsNull() && len(problems) > 0 {
+		resp.Diagnostics.AddError("Monitor clock skew detected",
+			"Clock skew between monitors can cause flaky behavior while changes are applied. "+
+				"Fix time synchronization (chrony/ntpd) on the monitor hosts before continuing:\n"+
+				strings.Join(problems, "\n"))
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// configureTransport picks how the client reaches the cluster: the local
+// CLI (optionally over SSH or through an exec wrapper), librados or the
+// manager API.
+func configureTransport(ctx context.Context, config *cephProviderModel, client *CephClient) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	mode := config.ConnectionMode.ValueString()
+	if mode == "" && config.Endpoint.ValueString() != "" {
+		mode = "mgr_api"
+	}
+	if mode != "" && mode != "cli" && (config.SSH != nil || config.ExecWrapper != nil) {
+		diags.AddAttributeError(path.Root("connection_mode"), "ssh and exec_wrapper need the cli connection mode",
+			fmt.Sprintf("The ssh and exec_wrapper blocks run the Ceph CLI elsewhere and cannot be combined with connection_mode = %q", mode))
+		return diags
+	}
+
+	switch mode {
+	case "", "cli":
+		var wrapper []string
+		if config.ExecWrapper != nil {
+			cfg := execWrapperConfig{
+				Type:      config.ExecWrapper.Type.ValueString(),
+				Namespace: config.ExecWrapper.Namespace.ValueString(),
+				Pod:       config.ExecWrapper.Pod.ValueString(),
+				Container: config.ExecWrapper.Container.ValueString(),
+			}
+			if !config.ExecWrapper.Command.IsNull() {
+				diags.Append(config.ExecWrapper.Command.ElementsAs(ctx, &cfg.Command, false)...)
+				if diags.HasError() {
+					return diags
+				}
+			}
+			prefix, err := execWrapperPrefix(cfg)
+			if err != nil {
+				diags.AddAttributeError(path.Root("exec_wrapper"), "Invalid exec_wrapper", err.Error())
+				return diags
+			}
+			wrapper = prefix
+		}
+
+		switch {
+		case config.SSH != nil:
+			if config.SSH.Host.ValueString() == "" {
+				diags.AddAttributeError(path.Root("ssh").AtName("host"), "Missing SSH host",
+					"The ssh block needs the host of the admin node to run commands on")
+				return diags
+			}
+			client.runner = newSSHRunner(sshTarget{
+				Host:       config.SSH.Host.ValueString(),
+				User:       config.SSH.User.ValueString(),
+				Port:       config.SSH.Port.ValueInt64(),
+				PrivateKey: config.SSH.PrivateKey.ValueString(),
+				Wrapper:    wrapper,
+			})
+		case wrapper != nil:
+			client.runner = newExecWrapperRunner(wrapper)
+		}
+	case "librados":
+		runner, err := newLibradosRunner(client)
+		if err != nil {
+			diags.AddAttributeError(path.Root("connection_mode"), "librados connection mode unavailable", err.Error())
+			return diags
+		}
+		client.runner = runner
+		// librados fails over between monitors itself.
+		client.MonHosts = nil
+	case "mgr_api":
+		if config.Endpoint.ValueString() == "" || config.APIUser.ValueString() == "" || config.APIPassword.ValueString() == "" {
+			diags.AddAttributeError(path.Root("endpoint"), "Incomplete manager API settings",
+				"connection_mode = \"mgr_api\" needs endpoint, api_user and api_password")
+			return diags
+		}
+		runner, err := newMgrAPIRunner(config.Endpoint.ValueString(), config.APIUser.ValueString(),
+			config.APIPassword.ValueString(), config.APICAFile.ValueString())
+		if err != nil {
+			diags.AddAttributeError(path.Root("api_ca_file"), "Invalid manager API settings", err.Error())
+			return diags
+		}
+		client.runner = runner
+		client.MonHosts = nil
+	default:
+		diags.AddAttributeError(path.Root("connection_mode"), "Invalid connection_mode",
+			fmt.Sprintf("connection_mode must be \"cli\", \"librados\" or \"mgr_api\", got %q", mode))
+		return diags
+	}
+	return diags
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/attr/xattr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Size and duration attributes. Ceph accepts and reports sizes in several
+// spellings ("10G", "10240M", "10737418240") and durations likewise ("90s",
+// "1m30s"). These string types validate the value at plan time and compare
+// values by what they mean, so reading back a different spelling of the
+// configured value never shows up as a diff.
+
+// sizeUnits are the suffixes Ceph accepts for sizes. Like the Ceph CLIs,
+// K, M, G, ... are binary multiples; "KiB" and "KB" spellings mean the same.
+var sizeUnits = map[string]int64{
+	"":  1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+	"P": 1 << 50,
+	"E": 1 << 60,
+}
+
+// parseSize returns the number of bytes in a size such as "10G", "1.5T",
+// "512MiB" or "10737418240".
+func parseSize(value string) (int64, error) {
+	s := strings.TrimSpace(value)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	number, unit := s, ""
+	if i >= 0 {
+		number, unit = s[:i], strings.ToUpper(strings.TrimSpace(s[i:]))
+	}
+	unit = strings.TrimSuffix(strings.TrimSuffix(unit, "B"), "I")
+	multiplier, ok := sizeUnits[unit]
+	if number == "" || !ok {
+		return 0, fmt.Errorf("invalid size %q; use bytes or a number with a K, M, G, T, P or E suffix, e.g. \"10G\"", value)
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", value, err)
+	}
+	bytes := n * float64(multiplier)
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q; it is too large", value)
+	}
+	if bytes != math.Trunc(bytes) {
+		return 0, fmt.Errorf("invalid size %q; it must be a whole number of bytes", value)
+	}
+	return int64(bytes), nil
+}
+
+// sizeType is a string attribute type holding a size.
+type sizeType struct {
+	basetypes.StringType
+}
+
+var _ xattr.TypeWithValidate = sizeType{}
+
+func (t sizeType) Equal(o attr.Type) bool {
+	other, ok := o.(sizeType)
+	return ok && t.StringType.Equal(other.StringType)
+}
+
+func (t sizeType) String() string {
+	return "sizeType"
+}
+
+func (t sizeType) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return sizeValue{StringValue: in}, nil
+}
+
+func (t sizeType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	value, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return sizeValue{StringValue: value.(basetypes.StringValue)}, nil
+}
+
+func (t sizeType) ValueType(ctx context.Context) attr.Value {
+	return sizeValue{}
+}
+
+func (t sizeType) Validate(ctx context.Context, in tftypes.Value, p path.Path) diag.Diagnostics {
+	var diags diag.Diagnostics
+	var s string
+	if !in.IsKnown() || in.IsNull() || in.As(&s) != nil {
+		return diags
+	}
+	if _, err := parseSize(s); err != nil {
+		diags.AddAttributeError(p, "Invalid size", err.Error())
+	}
+	return diags
+}
+
+// sizeValue is a size; sizes with the same number of bytes are equal.
+type sizeValue struct {
+	basetypes.StringValue
+}
+
+var _ basetypes.StringValuableWithSemanticEquals = sizeValue{}
+
+func sizeNull() sizeValue {
+	return sizeValue{StringValue: basetypes.NewStringNull()}
+}
+
+// sizeBytes returns a size of n bytes.
+func sizeBytes(n int64) sizeValue {
+	return sizeValue{StringValue: basetypes.NewStringValue(strconv.FormatInt(n, 10))}
+}
+
+func (v sizeValue) Equal(o attr.Value) bool {
+	other, ok := o.(sizeValue)
+	return ok && v.StringValue.Equal(other.StringValue)
+}
+
+func (v sizeValue) Type(ctx context.Context) attr.Type {
+	return sizeType{}
+}
+
+func (v sizeValue) StringSemanticEquals(ctx context.Context, o basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	other, ok := o.(sizeValue)
+	if !ok {
+		return false, diags
+	}
+	a, errA := parseSize(v.ValueString())
+	b, errB := parseSize(other.ValueString())
+	return errA == nil && errB == nil && a == b, diags
+}
+
+// Bytes returns the size in bytes. The value has been validated by the
+// time resources see it, so a parse error only occurs for null values.
+func (v sizeValue) Bytes() int64 {
+	n, _ := parseSize(v.ValueString())
+	return n
+}
+
+// CLIString returns the size as passed to Ceph CLIs. Some read a bare
+// number as megabytes (`rbd --size`), so a byte count gets a B suffix.
+func (v sizeValue) CLIString() string {
+	s := strings.TrimSpace(v.ValueString())
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return s + "B"
+	}
+	return s
+}
+
+// durationType is a string attribute type holding a Go duration such as
+// "90s" or "1h30m".
+type durationType struct {
+	basetypes.StringType
+}
+
+var _ xattr.TypeWithValidate = durationType{}
+
+func (t durationType) Equal(o attr.Type) bool {
+	other, ok := o.(durationType)
+	return ok && t.StringType.Equal(other.StringType)
+}
+
+func (t durationType) String() string {
+	return "durationType"
+}
+
+func (t durationType) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return durationValue{StringValue: in}, nil
+}
+
+func (t durationType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	value, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return durationValue{StringValue: value.(basetypes.StringValue)}, nil
+}
+
+func (t durationType) ValueType(ctx context.Context) attr.Value {
+	return durationValue{}
+}
+
+func (t durationType) Validate(ctx context.Context, in tftypes.Value, p path.Path) diag.Diagnostics {
+	var diags diag.Diagnostics
+	var s string
+	if !in.IsKnown() || in.IsNull() || in.As(&s) != nil {
+		return diags
+	}
+	if _, err := time.ParseDuration(s); err != nil {
+		diags.AddAttributeError(p, "Invalid duration",
+			fmt.Sprintf("%q is not a duration; use a number with a unit such as \"90s\", \"5m\" or \"1h30m\"", s))
+	}
+	return diags
+}
+
+// durationValue is a duration; durations of the same length are equal.
+type durationValue struct {
+	basetypes.StringValue
+}
+
+var _ basetypes.StringValuableWithSemanticEquals = durationValue{}
+
+func durationNull() durationValue {
+	return durationValue{StringValue: basetypes.NewStringNull()}
+}
+
+// durationSeconds returns a duration of the given number of seconds, as
+// Ceph reports intervals.
+func durationSeconds(s float64) durationValue {
+	d := time.Duration(s * float64(time.Second))
+	return durationValue{StringValue: basetypes.NewStringValue(d.String())}
+}
+
+// Seconds returns the duration in seconds. The value has been validated by
+// the time resources see it, so a parse error only occurs for null values.
+func (v durationValue) Seconds() float64 {
+	d, _ := time.ParseDuration(v.ValueString())
+	return d.Seconds()
+}
+
+func (v durationValue) Equal(o attr.Value) bool {
+	other, ok := o.(durationValue)
+	return ok && v.StringValue.Equal(other.StringValue)
+}
+
+func (v durationValue) Type(ctx context.Context) attr.Type {
+	return durationType{}
+}
+
+func (v durationValue) StringSemanticEquals(ctx context.Context, o basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	other, ok := o.(durationValue)
+	if !ok {
+		return false, diags
+	}
+	a, errA := time.ParseDuration(v.ValueString())
+	b, errB := time.ParseDuration(other.ValueString())
+	return errA == nil && errB == nil && a == b, diags
+}
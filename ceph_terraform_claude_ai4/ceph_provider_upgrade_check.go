@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Upgrade check as reported by `ceph orch upgrade check --image <image>`.
+// Daemons not running a Ceph image, such as monitoring containers, are
+// listed in non_ceph_image_daemons and left alone by the upgrade.
+type upgradeCheck struct {
+	TargetName    string `json:"target_name"`
+	TargetID      string `json:"target_id"`
+	TargetVersion string `json:"target_version"`
+	TargetDigest  string `json:"target_digest"`
+	NeedsUpdate   map[string]struct {
+		CurrentName    string `json:"current_name"`
+		CurrentID      string `json:"current_id"`
+		CurrentVersion string `json:"current_version"`
+	} `json:"needs_update"`
+	UpToDate            []string `json:"up_to_date"`
+	NonCephImageDaemons []string `json:"non_ceph_image_daemons"`
+}
+
+func parseUpgradeCheck(output string) (*upgradeCheck, error) {
+	var check upgradeCheck
+	if err := json.Unmarshal([]byte(output), &check); err != nil {
+		return nil, fmt.Errorf("failed to parse upgrade check: %w", err)
+	}
+	return &check, nil
+}
+
+// parseDaemonVersion reads a version such as "17.2.6" or the longer
+// "ceph version 18.2.0 (...) reef (stable)" form. It returns nil if there
+// is no version to compare.
+func parseDaemonVersion(version string) []int {
+	if !strings.HasPrefix(version, "ceph version ") {
+		version = "ceph version " + version
+	}
+	m := versionPattern.FindStringSubmatch(version)
+	if m == nil {
+		return nil
+	}
+	parts := make([]int, 3)
+	for i := range parts {
+		parts[i], _ = strconv.Atoi(m[i+1])
+	}
+	return parts
+}
+
+// upgradeBlockers returns, sorted, the reasons the upgrade cannot run as
+// checked: cephadm does not downgrade daemons, and a target image without
+// a Ceph version cannot be an upgrade target.
+func upgradeBlockers(check *upgradeCheck) []string {
+	target := parseDaemonVersion(check.TargetVersion)
+	if target == nil {
+		return []string{fmt.Sprintf("image %s does not report a Ceph version", check.TargetName)}
+	}
+
+	blockers := make([]string, 0)
+	for name, daemon := range check.NeedsUpdate {
+		current := parseDaemonVersion(daemon.CurrentVersion)
+		if current != nil && versionLess(target, current) {
+			blockers = append(blockers, fmt.Sprintf("%s runs %s, newer than the target; downgrades are not supported", name, daemon.CurrentVersion))
+		}
+	}
+	sort.Strings(blockers)
+	return blockers
+}
+
+// Upgrade Check Data Source
+//
+// Reports what `ceph orch upgrade start` would do for a target image
+// without starting it, so an upgrade can be reviewed in the plan.
+type upgradeCheckDataSource struct {
+	client *CephClient
+}
+
+type upgradeCheckDataSourceModel struct {
+	ID                  types.String         `tfsdk:"id"`
+	Image               types.String         `tfsdk:"image"`
+	FailOnBlockers      types.Bool           `tfsdk:"fail_on_blockers"`
+	TargetVersion       types.String         `tfsdk:"target_version"`
+	TargetDigest        types.String         `tfsdk:"target_digest"`
+	NeedsUpdate         []upgradeDaemonModel `tfsdk:"needs_update"`
+	UpToDate            types.List           `tfsdk:"up_to_date"`
+	NonCephImageDaemons types.List           `tfsdk:"non_ceph_image_daemons"`
+	Blockers            types.List           `tfsdk:"blockers"`
+	RawJSON             types.String         `tfsdk:"raw_json"`
+}
+
+type upgradeDaemonModel struct {
+	Name           types.String `tfsdk:"name"`
+	CurrentImage   types.String `tfsdk:"current_image"`
+	CurrentVersion types.String `tfsdk:"current_version"`
+}
+
+func NewUpgradeCheckDataSource() datasource.DataSource {
+	return &upgradeCheckDataSource{}
+}
+
+func (d *upgradeCheckDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_upgrade_check"
+}
+
+func (d *upgradeCheckDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Daemons an orchestrator upgrade to a target image would change, and anything blocking it, from `ceph orch upgrade check`",
+		Attributes: map[string]schema.Attribute{
+			"id":       dataSourceIDAttribute("Target image"),
+			"raw_json": rawJSONAttribute("`ceph orch upgrade check`"),
+			"image": schema.StringAttribute{
+				Description: "Container image to check, e.g. quay.io/ceph/ceph:v18.2.4",
+				Required:    true,
+			},
+			"fail_on_blockers": schema.BoolAttribute{
+				Description: "Fail the read when the upgrade has blockers",
+				Optional:    true,
+			},
+			"target_version": schema.StringAttribute{
+				Description: "Ceph version of the target image",
+				Computed:    true,
+			},
+			"target_digest": schema.StringAttribute{
+				Description: "Digest the target image resolved to",
+				Computed:    true,
+			},
+			"needs_update": schema.ListNestedAttribute{
+				Description: "Daemons the upgrade would redeploy, sorted by name",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Description: "Daemon name, e.g. osd.3",
+							Computed:    true,
+						},
+						"current_image": schema.StringAttribute{
+							Description: "Image the daemon runs now",
+							Computed:    true,
+						},
+						"current_version": schema.StringAttribute{
+							Description: "Ceph version the daemon runs now",
+							Computed:    true,
+						},
+					},
+				},
+			},
+			"up_to_date": schema.ListAttribute{
+				Description: "Daemons already on the target image, sorted",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"non_ceph_image_daemons": schema.ListAttribute{
+				Description: "Daemons not running a Ceph image, which the upgrade leaves alone, sorted",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"blockers": schema.ListAttribute{
+				Description: "Reasons the upgrade cannot run, sorted; empty when it can",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *upgradeCheckDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	d.client = req.ProviderData.(*CephClient)
+}
+
+func (d *upgradeCheckDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var state upgradeCheckDataSourceModel
+	diags := req.Config.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	image := state.Image.ValueString()
+	output, err := d.client.ReadJSON(NewCommand("ceph", "orch", "upgrade", "check").Option("--image", image))
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to check upgrade", err)
+		return
+	}
+
+	check, err := parseUpgradeCheck(output)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse upgrade check", err)
+		return
+	}
+	state.RawJSON, err = rawJSONValue([]byte(output))
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse upgrade check", err)
+		return
+	}
+
+	names := make([]string, 0, len(check.NeedsUpdate))
+	for name := range check.NeedsUpdate {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	state.NeedsUpdate = make([]upgradeDaemonModel, 0, len(names))
+	for _, name := range names {
+		daemon := check.NeedsUpdate[name]
+		state.NeedsUpdate = append(state.NeedsUpdate, upgradeDaemonModel{
+			Name:           types.StringValue(name),
+			CurrentImage:   types.StringValue(daemon.CurrentName),
+			CurrentVersion: types.StringValue(daemon.CurrentVersion),
+		})
+	}
+
+	upToDate := append([]string{}, check.UpToDate...)
+	sort.Strings(upToDate)
+	nonCeph := append([]string{}, check.NonCephImageDaemons...)
+	sort.Strings(nonCeph)
+	blockers := upgradeBlockers(check)
+
+	state.ID = types.StringValue(image)
+	state.TargetVersion = types.StringValue(check.TargetVersion)
+	state.TargetDigest = types.StringValue(check.TargetDigest)
+	state.UpToDate, diags = types.ListValueFrom(ctx, types.StringType, upToDate)
+	resp.Diagnostics.Append(diags...)
+	state.NonCephImageDaemons, diags = types.ListValueFrom(ctx, types.StringType, nonCeph)
+	resp.Diagnostics.Append(diags...)
+	state.Blockers, diags = types.ListValueFrom(ctx, types.StringType, blockers)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if state.FailOnBlockers.ValueBool() && len(blockers) > 0 {
+		resp.Diagnostics.AddError("Upgrade blocked",
+			fmt.Sprintf("The upgrade to %s cannot run:\n%s", image, strings.Join(blockers, "\n")))
+		return
+	}
+
+	diags = resp.State.Set(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+}
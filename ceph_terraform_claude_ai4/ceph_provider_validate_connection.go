@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// Connection validation. Without it a misconfigured provider only fails on
+// the first resource operation, with an error about that resource. With
+// validate_connection set, Configure reaches the cluster once and reports
+// the settings it used when that fails.
+
+// validateConnection checks the fsid, if one is configured, and asks the
+// monitors for their version. It returns the version string.
+func (c *CephClient) validateConnection() (string, error) {
+	if err := c.checkFSID(); err != nil {
+		return "", err
+	}
+
+	output, err := c.executeCommand(NewCommand("ceph", "version").Flag("--format", "json"))
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return "", fmt.Errorf("failed to parse ceph version: %w", err)
+	}
+	return result.Version, nil
+}
+
+// connectionSettings describes how the client reaches the cluster, one
+// setting per line, with the defaults the CLI falls back to spelled out.
+func connectionSettings(config *cephProviderModel, client *CephClient) string {
+	var transport string
+	switch mode := config.ConnectionMode.ValueString(); {
+	case mode == "librados":
+		transport = "librados"
+	case mode == "mgr_api" || (mode == "" && config.Endpoint.ValueString() != ""):
+		transport = "mgr_api at " + config.Endpoint.ValueString()
+	case config.SSH != nil:
+		transport = "cli over ssh to " + config.SSH.Host.ValueString()
+	default:
+		transport = "cli"
+	}
+	if config.ExecWrapper != nil {
+		wrapper := config.ExecWrapper.Type.ValueString()
+		if wrapper == "" {
+			wrapper = "custom"
+		}
+		transport += ", in exec_wrapper " + wrapper
+	}
+
+	cluster := client.Cluster
+	if cluster == "" {
+		cluster = "ceph"
+	}
+	configFile := client.ConfigFile
+	if configFile == "" {
+		configFile = fmt.Sprintf("/etc/ceph/%s.conf (default)", cluster)
+	}
+	keyring := client.Keyring
+	switch {
+	case client.Key != "":
+		keyring = "inline key"
+	case keyring == "":
+		keyring = "from config file or /etc/ceph (default)"
+	}
+	monHosts := strings.Join(client.MonHosts, ", ")
+	if monHosts == "" {
+		monHosts = "from config file"
+	}
+
+	lines := []string{
+		"connection:  " + transport,
+		"cluster:     " + cluster,
+		"config_file: " + configFile,
+		"keyring:     " + keyring,
+		"user:        " + client.entity(),
+		"mon_hosts:   " + monHosts,
+	}
+	if client.FSID != "" {
+		lines = append(lines, "fsid:        "+client.FSID)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// addConnectionError reports a failed connection check together with the
+// settings that were used.
+func addConnectionError(diags *diag.Diagnostics, err error, settings string) {
+	summary, detail := "Cannot connect to the Ceph cluster", err.Error()
+	var accessErr *cephAccessError
+	if errors.As(err, &accessErr) {
+		summary, detail = accessErr.Summary(), accessErr.Detail()
+	}
+	diags.AddError(summary, "validate_connection is set and the provider could not reach the cluster.\n\n"+
+		detail+"\n\nEffective connection settings:\n"+settings)
+}
@@ -2,27 +2,96 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Provider definition
-type cephProvider struct{}
+type cephProvider struct {
+	// shutdown is cancelled when the plugin is stopped; nil in tests.
+	shutdown context.Context
+}
 
 type cephProviderModel struct {
 	ConfigFile types.String `tfsdk:"config_file"`
 	Keyring    types.String `tfsdk:"keyring"`
 	User       types.String `tfsdk:"user"`
+	MonHosts   types.List   `tfsdk:"mon_hosts"`
+	Cluster    types.String `tfsdk:"cluster"`
+	FSID       types.String `tfsdk:"fsid"`
+	Key        types.String `tfsdk:"key"`
+	KeySecret  types.String `tfsdk:"key_secret"`
+
+	BootstrapKeyring types.Bool `tfsdk:"bootstrap_keyring"`
+
+	RecordCommandsFile types.String  `tfsdk:"record_commands_file"`
+	AuditLogPath       types.String  `tfsdk:"audit_log_path"`
+	MaxConcurrent      types.Int64   `tfsdk:"max_concurrent_commands"`
+	StatsdAddress      types.String  `tfsdk:"statsd_address"`
+	MetricsPrefix      types.String  `tfsdk:"metrics_prefix"`
+	EmitMetricsFile    types.String  `tfsdk:"emit_metrics_file"`
+	CommandTimeout     durationValue `tfsdk:"command_timeout"`
+	ConnectionMode     types.String  `tfsdk:"connection_mode"`
+	ValidateConnection types.Bool    `tfsdk:"validate_connection"`
+	ReadOnly           types.Bool    `tfsdk:"read_only"`
+	ApplyReport        types.Bool    `tfsdk:"apply_report"`
+	PoolSizeSafety     types.String  `tfsdk:"pool_size_safety"`
+
+	RGWRealm     types.String `tfsdk:"rgw_realm"`
+	RGWZonegroup types.String `tfsdk:"rgw_zonegroup"`
+	RGWZone      types.String `tfsdk:"rgw_zone"`
+
+	Endpoint    types.String `tfsdk:"endpoint"`
+	APIUser     types.String `tfsdk:"api_user"`
+	APIPassword types.String `tfsdk:"api_password"`
+	APICAFile   types.String `tfsdk:"api_ca_file"`
+
+	SSH         *providerSSHModel         `tfsdk:"ssh"`
+	ExecWrapper *providerExecWrapperModel `tfsdk:"exec_wrapper"`
+	RGWAdmin    *providerRGWAdminModel    `tfsdk:"rgw_admin"`
+}
+
+type providerSSHModel struct {
+	Host       types.String `tfsdk:"host"`
+	User       types.String `tfsdk:"user"`
+	PrivateKey types.String `tfsdk:"private_key"`
+	Port       types.Int64  `tfsdk:"port"`
+}
+
+type providerExecWrapperModel struct {
+	Type      types.String `tfsdk:"type"`
+	Namespace types.String `tfsdk:"namespace"`
+	Pod       types.String `tfsdk:"pod"`
+	Container types.String `tfsdk:"container"`
+	Command   types.List   `tfsdk:"command"`
+}
+
+type providerRGWAdminModel struct {
+	Endpoint  types.String `tfsdk:"endpoint"`
+	AccessKey types.String `tfsdk:"access_key"`
+	SecretKey types.String `tfsdk:"secret_key"`
 }
 
 func New() provider.Provider {
@@ -45,10 +114,184 @@ func (p *cephProvider) Schema(ctx context.Context, req provider.SchemaRequest, r
 				Description: "Path to Ceph keyring file",
 				Optional:    true,
 			},
+			"cluster": schema.StringAttribute{
+				Description: "Cluster name passed to every command with --cluster, selecting /etc/ceph/<cluster>.conf and its keyrings",
+				Optional:    true,
+			},
+			"fsid": schema.StringAttribute{
+				Description: "Expected cluster fsid; commands fail if the provider reaches a different cluster",
+				Optional:    true,
+			},
+			"key": schema.StringAttribute{
+				Description: "Cephx secret of the user, as printed by `ceph auth get-key`; used instead of a keyring file",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"key_secret": schema.StringAttribute{
+				Description: "Keyring text holding the user's key, as printed by `ceph auth get`, e.g. read from Vault; used instead of a keyring file",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"bootstrap_keyring": schema.BoolAttribute{
+				Description: "Generate a minimal ceph.conf and keyring for every command from key or key_secret, user, mon_hosts and fsid, so nothing under /etc/ceph is needed",
+				Optional:    true,
+			},
 			"user": schema.StringAttribute{
 				Description: "Ceph user name",
 				Optional:    true,
 			},
+			"mon_hosts": schema.ListAttribute{
+				Description: "Monitor addresses to connect to directly. Each is tried in turn until a healthy one is found",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"record_commands_file": schema.StringAttribute{
+				Description: "Path of a JSON file recording every command the provider executes during this run, and the command each planned change to a `ceph_pool`, `ceph_user`, `ceph_block_image` or `ceph_auth_import` needs, marked as planned",
+				Optional:    true,
+			},
+			"audit_log_path": schema.StringAttribute{
+				Description: "Path of a JSON Lines file that every executed command is appended to, with its time, exit code and duration; secrets in arguments are redacted",
+				Optional:    true,
+			},
+			"max_concurrent_commands": schema.Int64Attribute{
+				Description: "Maximum number of commands run at the same time; further commands wait for a free slot. Unset or 0 means no limit",
+				Optional:    true,
+			},
+			"statsd_address": schema.StringAttribute{
+				Description: "host:port of a statsd server to send command counts, failures and durations to, per operation",
+				Optional:    true,
+			},
+			"metrics_prefix": schema.StringAttribute{
+				Description: "Prefix of the metric names sent to statsd_address (default terraform.ceph)",
+				Optional:    true,
+			},
+			"emit_metrics_file": schema.StringAttribute{
+				Description: "Path of a JSON file with the count, failures and latency of each operation during this run",
+				Optional:    true,
+			},
+			"command_timeout": schema.StringAttribute{
+				Description: "Maximum duration of a single command, e.g. \"5m\"; commands are killed when it expires. Unset means no limit",
+				Optional:    true,
+				CustomType:  durationType{},
+			},
+			"validate_connection": schema.BoolAttribute{
+				Description: "Check during provider configuration that the cluster can be reached, reporting the effective connection settings if not",
+				Optional:    true,
+			},
+			"read_only": schema.BoolAttribute{
+				Description: "Refuse every command that would change the cluster, so creates, updates and deletes fail while reads and data sources work",
+				Optional:    true,
+			},
+			"apply_report": schema.BoolAttribute{
+				Description: "Snapshot `ceph status` and `ceph df` when the provider is configured, as the baseline a ceph_apply_report compares the cluster with after the apply",
+				Optional:    true,
+			},
+			"pool_size_safety": schema.StringAttribute{
+				Description: "How plans giving a pool min_size 1 or min_size equal to size are reported: warn (default), error, adjust (plan a safe min_size when it is not configured, warn otherwise) or off",
+				Optional:    true,
+			},
+			"rgw_realm": schema.StringAttribute{
+				Description: "Realm every radosgw-admin command acts on (--rgw-realm); resources can override it with rgw_scope",
+				Optional:    true,
+			},
+			"rgw_zonegroup": schema.StringAttribute{
+				Description: "Zonegroup every radosgw-admin command acts on (--rgw-zonegroup); resources can override it with rgw_scope",
+				Optional:    true,
+			},
+			"rgw_zone": schema.StringAttribute{
+				Description: "Zone every radosgw-admin command acts on (--rgw-zone); resources can override it with rgw_scope",
+				Optional:    true,
+			},
+			"connection_mode": schema.StringAttribute{
+				Description: "How commands reach the cluster: cli runs the ceph binaries, librados sends ceph commands through go-ceph, mgr_api sends them to the manager's restful API. Defaults to mgr_api when endpoint is set, otherwise cli",
+				Optional:    true,
+			},
+			"endpoint": schema.StringAttribute{
+				Description: "URL of the ceph-mgr restful API, e.g. https://mgr.example.com:8003",
+				Optional:    true,
+			},
+			"api_user": schema.StringAttribute{
+				Description: "User name for the manager API",
+				Optional:    true,
+			},
+			"api_password": schema.StringAttribute{
+				Description: "API key for api_user, as printed by `ceph restful create-key`",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"api_ca_file": schema.StringAttribute{
+				Description: "PEM file with the CA that signed the manager API certificate, if not a system CA",
+				Optional:    true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"ssh": schema.SingleNestedBlock{
+				Description: "Run commands on a remote admin node over SSH instead of locally. config_file and keyring then refer to paths on that node",
+				Attributes: map[string]schema.Attribute{
+					"host": schema.StringAttribute{
+						Description: "Admin node to connect to",
+						Optional:    true,
+					},
+					"user": schema.StringAttribute{
+						Description: "SSH user; defaults to the local SSH configuration",
+						Optional:    true,
+					},
+					"private_key": schema.StringAttribute{
+						Description: "PEM private key; defaults to the SSH agent and the local SSH configuration",
+						Optional:    true,
+						Sensitive:   true,
+					},
+					"port": schema.Int64Attribute{
+						Description: "SSH port (default 22)",
+						Optional:    true,
+					},
+				},
+			},
+			"exec_wrapper": schema.SingleNestedBlock{
+				Description: "Run the Ceph CLI inside a container: cephadm shell, kubectl exec into the Rook toolbox, or a custom command. Combines with ssh to run the wrapper on the admin node",
+				Attributes: map[string]schema.Attribute{
+					"type": schema.StringAttribute{
+						Description: "cephadm, kubectl or custom",
+						Optional:    true,
+					},
+					"namespace": schema.StringAttribute{
+						Description: "Kubernetes namespace for kubectl (default rook-ceph)",
+						Optional:    true,
+					},
+					"pod": schema.StringAttribute{
+						Description: "Pod or workload to exec into for kubectl (default deploy/rook-ceph-tools)",
+						Optional:    true,
+					},
+					"container": schema.StringAttribute{
+						Description: "Container within the pod for kubectl",
+						Optional:    true,
+					},
+					"command": schema.ListAttribute{
+						Description: "Command prefix for type custom, e.g. [\"sudo\", \"cephadm\", \"shell\", \"--\"]; the script is passed to sh -c after it",
+						ElementType: types.StringType,
+						Optional:    true,
+					},
+				},
+			},
+			"rgw_admin": schema.SingleNestedBlock{
+				Description: "Credentials for the RGW admin ops REST API, used by RGW resources instead of the radosgw-admin binary",
+				Attributes: map[string]schema.Attribute{
+					"endpoint": schema.StringAttribute{
+						Description: "URL of a Rados Gateway, e.g. https://rgw.example.com",
+						Optional:    true,
+					},
+					"access_key": schema.StringAttribute{
+						Description: "S3 access key of an RGW user with admin caps, e.g. users=*;buckets=*",
+						Optional:    true,
+						Sensitive:   true,
+					},
+					"secret_key": schema.StringAttribute{
+						Description: "S3 secret key matching access_key",
+						Optional:    true,
+						Sensitive:   true,
+					},
+				},
+			},
 		},
 	}
 }
@@ -61,10 +304,151 @@ func (p *cephProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 		return
 	}
 
+	var monHosts []string
+	if !config.MonHosts.IsNull() {
+		diags = config.MonHosts.ElementsAs(ctx, &monHosts, false)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	client := &CephClient{
 		ConfigFile: config.ConfigFile.ValueString(),
 		Keyring:    config.Keyring.ValueString(),
 		User:       config.User.ValueString(),
+		MonHosts:   monHosts,
+		Cluster:    config.Cluster.ValueString(),
+		FSID:       config.FSID.ValueString(),
+		ReadOnly:   config.ReadOnly.ValueBool(),
+		RGWScope: rgwScope{
+			Realm:     config.RGWRealm.ValueString(),
+			Zonegroup: config.RGWZonegroup.ValueString(),
+			Zone:      config.RGWZone.ValueString(),
+		},
+		ctx: loggingContext(p.shutdown, ctx),
+	}
+
+	timeout, err := parseCommandTimeout(config.CommandTimeout.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("command_timeout"), "Invalid command_timeout", err.Error())
+		return
+	}
+	client.Timeout = timeout
+
+	client.PoolSizeSafety, err = parsePoolSizeSafety(config.PoolSizeSafety.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("pool_size_safety"), "Invalid pool_size_safety", err.Error())
+		return
+	}
+
+	slots, err := newCommandSlots(config.MaxConcurrent.ValueInt64())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("max_concurrent_commands"), "Invalid max_concurrent_commands", err.Error())
+		return
+	}
+	client.slots = slots
+
+	resp.Diagnostics.Append(configureInlineKey(&config, client)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(configureBootstrap(&config, client)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(configureTransport(ctx, &config, client)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(configureRGWAdmin(&config, client)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if path := config.RecordCommandsFile.ValueString(); path != "" {
+		recorder, err := newCommandRecorder(path)
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to open record_commands_file", err)
+			return
+		}
+		client.recorder = recorder
+	}
+
+	if path := config.AuditLogPath.ValueString(); path != "" {
+		auditLog, err := newAuditLog(path)
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to open audit_log_path", err)
+			return
+		}
+		client.auditLog = auditLog
+	}
+
+	if address := config.StatsdAddress.ValueString(); address != "" {
+		metrics, err := newCommandMetrics(address, config.MetricsPrefix.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("statsd_address"), "Invalid statsd_address", err.Error())
+			return
+		}
+		client.metrics = metrics
+	}
+
+	if path := config.EmitMetricsFile.ValueString(); path != "" {
+		metricsFile, err := newMetricsFile(path)
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to open emit_metrics_file", err)
+			return
+		}
+		client.metricsFile = metricsFile
+	}
+
+	if config.ValidateConnection.ValueBool() {
+		version, err := client.validateConnection()
+		if err != nil {
+			addConnectionError(&resp.Diagnostics, err, connectionSettings(&config, client))
+			return
+		}
+		tflog.Info(ctx, "Connected to Ceph cluster", map[string]interface{}{
+			"version": version,
+		})
+	}
+
+	// Without a detected release, features are not gated and an old
+	// cluster reports unsupported commands itself.
+	if err := client.detectRelease(); err != nil {
+		tflog.Warn(ctx, "Could not detect the Ceph release", map[string]interface{}{
+			"error": err.Error(),
+		})
+	} else {
+		tflog.Info(ctx, "Detected Ceph release", map[string]interface{}{
+			"release": client.release.String(),
+			"version": client.version,
+		})
+	}
+
+	// Without probed caps, commands are not checked and a missing cap is
+	// reported when the cluster refuses the command.
+	if client.probeCaps() {
+		tflog.Info(ctx, "Probed provider user caps", map[string]interface{}{
+			"entity": client.entity(),
+			"caps":   client.caps,
+		})
+	} else {
+		tflog.Warn(ctx, "Could not read the provider user's caps", map[string]interface{}{
+			"entity": client.entity(),
+		})
+	}
+
+	if config.ApplyReport.ValueBool() {
+		client.captureBaseline()
+		if client.baselineErr != nil {
+			tflog.Warn(ctx, "Could not capture the apply report baseline", map[string]interface{}{
+				"error": client.baselineErr.Error(),
+			})
+		}
 	}
 
 	resp.DataSourceData = client
@@ -75,7 +459,37 @@ func (p *cephProvider) Resources(ctx context.Context) []func() resource.Resource
 	return []func() resource.Resource{
 		NewPoolResource,
 		NewUserResource,
+		NewAuthImportResource,
 		NewBlockImageResource,
+		NewCrushMapResource,
+		NewRGWTenantResource,
+		NewRGWUserResource,
+		NewRGWBucketResource,
+		NewRGWUserPolicyResource,
+		NewPoolQuotaResource,
+		NewSnapshotRetentionResource,
+		NewPoolSnapshotResource,
+		NewMclockProfileResource,
+		NewRuntimeOptionResource,
+		NewOSDFullRatiosResource,
+		NewRGWAdminUserResource,
+		NewRBDRollbackResource,
+		NewRadosNamespaceResource,
+		NewClusterLogMarkerResource,
+		NewSMBClusterResource,
+		NewSMBShareResource,
+		NewMirrorDaemonResource,
+		NewMgrResource,
+		NewOrchDeviceZapResource,
+		NewRGWCertificateResource,
+		NewRGWCloudTierResource,
+		NewDashboardCertificateResource,
+		NewPoolRenameResource,
+		NewRBDTrashRestoreResource,
+		NewCrushWeightSetResource,
+		NewStretchRuleResource,
+		NewApplyReportResource,
+		NewFSSubvolumeResource,
 	}
 }
 
@@ -83,6 +497,19 @@ func (p *cephProvider) DataSources(ctx context.Context) []func() datasource.Data
 	return []func() datasource.DataSource{
 		NewClusterStatusDataSource,
 		NewPoolDataSource,
+		NewPoolsDataSource,
+		NewBlockImagesDataSource,
+		NewUsersDataSource,
+		NewRGWBucketsDataSource,
+		NewCrushMapDataSource,
+		NewTimeSyncStatusDataSource,
+		NewDaemonPerfDataSource,
+		NewRGWMultisiteStatusDataSource,
+		NewOSDDownDetectionDataSource,
+		NewUpgradeCheckDataSource,
+		NewPoolNamespacesDataSource,
+		NewResourcesByTagDataSource,
+		NewPoolSnapshotsDataSource,
 	}
 }
 
@@ -91,10 +518,82 @@ type CephClient struct {
 	ConfigFile string
 	Keyring    string
 	User       string
+	MonHosts   []string
+
+	// Key is an inline secret for User, used instead of Keyring.
+	Key string
+
+	// Bootstrap writes a ceph.conf along with the inline keyring, so no
+	// config under /etc/ceph is needed.
+	Bootstrap bool
+
+	// ReadOnly refuses commands that would change the cluster.
+	ReadOnly bool
+
+	// RGWScope selects the realm, zonegroup and zone of radosgw-admin
+	// commands that do not select their own.
+	RGWScope rgwScope
+
+	// PoolSizeSafety is how unsafe pool size/min_size plans are reported.
+	PoolSizeSafety string
+
+	// Cluster is the cluster name passed with --cluster; FSID, if set, is
+	// checked before the first command.
+	Cluster  string
+	FSID     string
+	fsidOnce sync.Once
+	fsidErr  error
+
+	// release is the oldest Ceph release the daemons run, detected at
+	// Configure; zero if detection failed.
+	release cephRelease
+	version string
+
+	// caps are the provider user's caps, probed at Configure; nil if they
+	// could not be read.
+	caps map[string]string
+
+	// Timeout bounds each command; zero means no limit. Commands are also
+	// cancelled when ctx is, on interrupt or plugin shutdown.
+	Timeout time.Duration
+	ctx     context.Context
+
+	// slots limits how many commands run at once; nil means no limit.
+	slots commandSlots
+
+	monMu     sync.Mutex
+	activeMon string
+
+	recorder    *commandRecorder
+	auditLog    *auditLog
+	metrics     *commandMetrics
+	metricsFile *metricsFile
+	runner      commandRunner
+
+	// rgwAdmin is the RGW admin ops API client; nil without rgw_admin.
+	rgwAdmin *rgwAdminAPI
+
+	// baseline is the cluster as it was when the provider was configured,
+	// for apply reports; nil without apply_report.
+	baseline    *clusterSnapshot
+	baselineErr error
 }
 
-func (c *CephClient) buildCmdArgs(cmd string) []string {
-	args := strings.Split(cmd, " ")
+// commandRunner runs one CLI invocation and returns its stdout, giving up
+// when ctx is done. Tests substitute a fake to stand in for a cluster.
+type commandRunner func(ctx context.Context, args []string) (string, error)
+
+func execRunner(ctx context.Context, args []string) (string, error) {
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	return string(out), err
+}
+
+// buildCmdArgs appends the connection flags to a command's arguments.
+func (c *CephClient) buildCmdArgs(cmdArgs []string) []string {
+	args := append([]string(nil), cmdArgs...)
+	if c.Cluster != "" {
+		args = append(args, "--cluster", c.Cluster)
+	}
 	if c.ConfigFile != "" {
 		args = append(args, "--conf", c.ConfigFile)
 	}
@@ -107,13 +606,111 @@ func (c *CephClient) buildCmdArgs(cmd string) []string {
 	return args
 }
 
-func (c *CephClient) ExecuteCommand(cmd string) (string, error) {
-	args := c.buildCmdArgs(cmd)
-	out, err := exec.Command(args[0], args[1:]...).Output()
+func (c *CephClient) ExecuteCommand(cmd *CommandBuilder) (string, error) {
+	c.RGWScope.apply(cmd)
+	if err := c.checkReadOnly(cmd); err != nil {
+		return "", err
+	}
+	if err := checkConfirmed(cmd); err != nil {
+		return "", err
+	}
+	if err := c.checkFSID(); err != nil {
+		return "", err
+	}
+	if args, err := cmd.Args(); err == nil {
+		if err := c.checkRelease(args); err != nil {
+			return "", err
+		}
+		if err := c.checkCaps(args); err != nil {
+			return "", err
+		}
+	}
+	return c.executeCommand(cmd)
+}
+
+func (c *CephClient) executeCommand(cmd *CommandBuilder) (string, error) {
+	args, err := cmd.Args()
+	if err != nil {
+		return "", err
+	}
+
+	var output string
+	if len(c.MonHosts) > 0 {
+		output, err = c.executeWithMonFailover(args)
+	} else {
+		output, err = c.execute(c.buildCmdArgs(args))
+	}
+	if err != nil {
+		return "", c.diagnose(strings.Join(args, " "), err)
+	}
+	return output, nil
+}
+
+func (c *CephClient) execute(args []string) (string, error) {
+	run := c.runner
+	if run == nil {
+		run = execRunner
+	}
+	if c.Key != "" {
+		keyArgs, cleanup, err := c.writeInlineKeyring()
+		if err != nil {
+			return "", err
+		}
+		defer cleanup()
+		args = append(args, keyArgs...)
+	}
+
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	release, err := c.slots.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	out, err := run(ctx, args)
+	duration := time.Since(start)
 	if err != nil {
-		return "", fmt.Errorf("command failed: %w", err)
+		if ctx.Err() != nil {
+			err = commandContextError(ctx, c.Timeout)
+		}
+		err = newCommandError(args, err)
+	}
+	if c.recorder != nil {
+		if recErr := c.recorder.Record(args, err); recErr != nil {
+			tflog.Warn(ctx, "Could not update record_commands_file", map[string]interface{}{
+				"error": recErr.Error(),
+			})
+		}
+	}
+	if c.auditLog != nil {
+		if logErr := c.auditLog.Log(c, args, duration, err); logErr != nil {
+			tflog.Warn(ctx, "Could not write to the audit log", map[string]interface{}{
+				"error": logErr.Error(),
+			})
+		}
 	}
-	return string(out), nil
+	if c.metrics != nil {
+		c.metrics.Observe(args, duration, err)
+	}
+	if c.metricsFile != nil {
+		if metricsErr := c.metricsFile.Observe(args, duration, err); metricsErr != nil {
+			tflog.Warn(ctx, "Could not update emit_metrics_file", map[string]interface{}{
+				"error": metricsErr.Error(),
+			})
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return out, nil
 }
 
 // Pool Resource
@@ -122,13 +719,59 @@ type poolResource struct {
 }
 
 type poolResourceModel struct {
-	Name       types.String `tfsdk:"name"`
-	PgNum      types.Int64  `tfsdk:"pg_num"`
-	PgpNum     types.Int64  `tfsdk:"pgp_num"`
-	Size       types.Int64  `tfsdk:"size"`
-	MinSize    types.Int64  `tfsdk:"min_size"`
-	Type       types.String `tfsdk:"type"`
-	CrushRule  types.String `tfsdk:"crush_rule"`
+	ID          types.String `tfsdk:"id"`
+	PoolID      types.Int64  `tfsdk:"pool_id"`
+	Name        types.String `tfsdk:"name"`
+
+	StoredBytes types.Int64   `tfsdk:"stored_bytes"`
+	Objects     types.Int64   `tfsdk:"objects"`
+	PercentUsed types.Float64 `tfsdk:"percent_used"`
+
+	PgNum       types.Int64  `tfsdk:"pg_num"`
+	PgpNum      types.Int64  `tfsdk:"pgp_num"`
+	Size        types.Int64  `tfsdk:"size"`
+	MinSize     types.Int64  `tfsdk:"min_size"`
+	Type        types.String `tfsdk:"type"`
+	CrushRule   types.String `tfsdk:"crush_rule"`
+	DeviceClass types.String `tfsdk:"device_class"`
+
+	PgAutoscaleMode types.String  `tfsdk:"pg_autoscale_mode"`
+	TargetSizeBytes sizeValue     `tfsdk:"target_size_bytes"`
+	TargetSizeRatio types.Float64 `tfsdk:"target_size_ratio"`
+	Bulk            types.Bool    `tfsdk:"bulk"`
+
+	ScrubMinInterval  durationValue `tfsdk:"scrub_min_interval"`
+	ScrubMaxInterval  durationValue `tfsdk:"scrub_max_interval"`
+	DeepScrubInterval durationValue `tfsdk:"deep_scrub_interval"`
+	RecoveryPriority  types.Int64   `tfsdk:"recovery_priority"`
+
+	ErasureCodeProfile types.String `tfsdk:"erasure_code_profile"`
+	AllowECOverwrites  types.Bool   `tfsdk:"allow_ec_overwrites"`
+
+	Applications types.Set `tfsdk:"applications"`
+
+	QuotaMaxBytes   sizeValue   `tfsdk:"quota_max_bytes"`
+	QuotaMaxObjects types.Int64 `tfsdk:"quota_max_objects"`
+
+	PostCreateCommands types.List `tfsdk:"post_create_commands"`
+	PreDestroyCommands types.List `tfsdk:"pre_destroy_commands"`
+	PostCreateOutput   types.List `tfsdk:"post_create_output"`
+
+	ToggleMonAllowPoolDelete types.Bool `tfsdk:"toggle_mon_allow_pool_delete"`
+	ConfirmDataLoss          types.Bool `tfsdk:"confirm_data_loss"`
+	DeleteProtection         types.Bool `tfsdk:"delete_protection"`
+
+	MaxChangePercent types.Int64 `tfsdk:"max_change_percent"`
+	Force            types.Bool  `tfsdk:"force"`
+	AllowRename      types.Bool  `tfsdk:"allow_rename"`
+
+	CacheTier *poolCacheTierModel `tfsdk:"cache_tier"`
+
+	RequireHealth      types.String  `tfsdk:"require_health"`
+	WaitForActiveClean types.Bool    `tfsdk:"wait_for_active_clean"`
+	ActiveCleanTimeout durationValue `tfsdk:"active_clean_timeout"`
+
+	Tags types.Map `tfsdk:"tags"`
 }
 
 func NewPoolResource() resource.Resource {
@@ -142,44 +785,716 @@ func (r *poolResource) Metadata(ctx context.Context, req resource.MetadataReques
 func (r *poolResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Manages a Ceph pool",
-		Attributes: map[string]schema.Attribute{
+		Attributes: withHookAttributes(map[string]schema.Attribute{
+			"id":             resourceIDAttribute("Pool name"),
+			"require_health": requireHealthAttribute(),
+			"pool_id": schema.Int64Attribute{
+				Description: "Numeric pool id assigned by the cluster, as used in PG ids and CRUSH dumps",
+				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"stored_bytes": schema.Int64Attribute{
+				Description: "Bytes of user data stored in the pool, before replication, as of the last refresh",
+				Computed:    true,
+			},
+			"objects": schema.Int64Attribute{
+				Description: "Number of objects in the pool as of the last refresh",
+				Computed:    true,
+			},
+			"percent_used": schema.Float64Attribute{
+				Description: "Percentage of the pool's available capacity in use, from 0 to 100, as of the last refresh",
+				Computed:    true,
+			},
 			"name": schema.StringAttribute{
-				Description: "Pool name",
+				Description: "Pool name; changing it replaces the pool unless allow_rename is set",
 				Required:    true,
 			},
+			"allow_rename": schema.BoolAttribute{
+				Description: "Rename the pool in place with `ceph osd pool rename` when name changes, keeping its data, instead of replacing it",
+				Optional:    true,
+			},
 			"pg_num": schema.Int64Attribute{
-				Description: "Placement group number",
-				Required:    true,
+				Description: "Placement group number; read from the cluster when unset, so the PG autoscaler can change it",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
 			},
 			"pgp_num": schema.Int64Attribute{
-				Description: "Placement group for placement number",
+				Description: "Placement group for placement number; read from the cluster when unset",
 				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
 			},
 			"size": schema.Int64Attribute{
-				Description: "Pool replication size",
+				Description: "Pool replication size; read from the cluster when unset",
 				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
 			},
 			"min_size": schema.Int64Attribute{
-				Description: "Pool minimum replication size",
+				Description: "Pool minimum replication size; read from the cluster when unset",
 				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
 			},
 			"type": schema.StringAttribute{
-				Description: "Pool type (replicated or erasure)",
+				Description: "Pool type (replicated or erasure, default replicated); changing it replaces the pool",
 				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("replicated"),
 			},
 			"crush_rule": schema.StringAttribute{
-				Description: "CRUSH rule name",
+				Description: "CRUSH rule name; read from the cluster when unset",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"device_class": schema.StringAttribute{
+				Description: "Place the pool on OSDs of this device class (e.g. ssd, hdd) using a replicated rule created on demand; conflicts with crush_rule",
 				Optional:    true,
 			},
-		},
+			"pg_autoscale_mode": schema.StringAttribute{
+				Description: "PG autoscaler mode of the pool: on, off or warn; read from the cluster when unset",
+				Optional:    true,
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"target_size_bytes": schema.StringAttribute{
+				Description: "Expected size of the pool, in bytes or with a unit such as 10T, for the PG autoscaler to size pg_num ahead of the data",
+				Optional:    true,
+				CustomType:  sizeType{},
+			},
+			"target_size_ratio": schema.Float64Attribute{
+				Description: "Expected share of the cluster's capacity relative to other pools with a ratio, for the PG autoscaler; takes precedence over target_size_bytes",
+				Optional:    true,
+			},
+			"bulk": schema.BoolAttribute{
+				Description: "Mark the pool as expected to hold most of the cluster's data, so the PG autoscaler starts it with a full complement of PGs",
+				Optional:    true,
+			},
+			"scrub_min_interval": schema.StringAttribute{
+				Description: "Minimum time between scrubs of a PG while the cluster is busy, e.g. \"24h\"; the OSD default when unset",
+				CustomType:  durationType{},
+				Optional:    true,
+			},
+			"scrub_max_interval": schema.StringAttribute{
+				Description: "Maximum time between scrubs of a PG regardless of load, e.g. \"168h\"; the OSD default when unset",
+				CustomType:  durationType{},
+				Optional:    true,
+			},
+			"deep_scrub_interval": schema.StringAttribute{
+				Description: "Time between deep scrubs of a PG, e.g. \"336h\"; the OSD default when unset",
+				CustomType:  durationType{},
+				Optional:    true,
+			},
+			"recovery_priority": schema.Int64Attribute{
+				Description: "Recovery priority of the pool relative to other pools, from -10 to 10; 0 when unset",
+				Optional:    true,
+			},
+			"erasure_code_profile": schema.StringAttribute{
+				Description: "Erasure code profile of an erasure-coded pool; Ceph's default profile when unset. Changing it replaces the pool",
+				Optional:    true,
+			},
+			"allow_ec_overwrites": schema.BoolAttribute{
+				Description: "Allow partial writes to an erasure-coded pool, as RBD and CephFS data pools need; cannot be turned off once enabled",
+				Optional:    true,
+			},
+			"applications": schema.SetAttribute{
+				Description: "Applications enabled on the pool (rbd, cephfs, rgw or a custom name); Ceph raises POOL_APP_NOT_ENABLED for pools without one",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"quota_max_bytes": schema.StringAttribute{
+				Description: "Pool quota size, in bytes or with a unit such as 100G (unlimited when unset)",
+				Optional:    true,
+				CustomType:  sizeType{},
+			},
+			"quota_max_objects": schema.Int64Attribute{
+				Description: "Pool quota in objects (unlimited when unset)",
+				Optional:    true,
+			},
+			"cache_tier": cacheTierAttribute(),
+			"tags":       tagsAttribute(),
+			"wait_for_active_clean": schema.BoolAttribute{
+				Description: "Wait after creating the pool until all its PGs are active+clean, so resources using the pool do not block on peering PGs",
+				Optional:    true,
+			},
+			"active_clean_timeout": schema.StringAttribute{
+				Description: "How long wait_for_active_clean waits before failing, e.g. \"15m\" (default 10m)",
+				CustomType:  durationType{},
+				Optional:    true,
+			},
+			"toggle_mon_allow_pool_delete": schema.BoolAttribute{
+				Description: "If mon_allow_pool_delete is false when the pool is destroyed, enable it for the delete and restore it afterwards instead of failing",
+				Optional:    true,
+			},
+			"confirm_data_loss": schema.BoolAttribute{
+				Description: "Confirm that destroying the pool deletes all of its data; destroy fails unless this is true in state. Also needed to disable an application",
+				Optional:    true,
+			},
+			"delete_protection": schema.BoolAttribute{
+				Description: "Set the pool's nodelete flag and refuse to destroy or replace the pool until this is set to false and applied",
+				Optional:    true,
+			},
+			"max_change_percent": schema.Int64Attribute{
+				Description: "Fail the plan when pg_num, pgp_num or size changes by more than this percentage, unless force is set",
+				Optional:    true,
+			},
+			"force": schema.BoolAttribute{
+				Description: "Allow changes beyond max_change_percent",
+				Optional:    true,
+			},
+		}),
+	}
+}
+
+func (r *poolResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config poolResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validatePoolLayout(&config, &resp.Diagnostics)
+	validateCacheTier(&config, &resp.Diagnostics)
+	validateRequireHealth(config.RequireHealth, &resp.Diagnostics)
+	validateActiveCleanWait(config.WaitForActiveClean, config.ActiveCleanTimeout, &resp.Diagnostics)
+	validateTags(config.Tags, &resp.Diagnostics)
+
+	erasure := config.Type.IsUnknown() || config.Type.ValueString() == "erasure"
+	for name, value := range map[string]attr.Value{
+		"erasure_code_profile": config.ErasureCodeProfile,
+		"allow_ec_overwrites":  config.AllowECOverwrites,
+	} {
+		if !erasure && !value.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root(name), "Setting requires an erasure-coded pool",
+				fmt.Sprintf("%s only applies to pools with type = \"erasure\"", name))
+		}
+	}
+	if mode := config.PgAutoscaleMode.ValueString(); !config.PgAutoscaleMode.IsNull() && !config.PgAutoscaleMode.IsUnknown() &&
+		mode != "on" && mode != "off" && mode != "warn" {
+		resp.Diagnostics.AddAttributeError(path.Root("pg_autoscale_mode"), "Invalid pg_autoscale_mode",
+			fmt.Sprintf("pg_autoscale_mode must be on, off or warn, not %q", mode))
+	}
+	if config.PgAutoscaleMode.ValueString() == "on" && !config.PgNum.IsNull() {
+		resp.Diagnostics.AddAttributeWarning(path.Root("pg_num"), "pg_num is managed by the autoscaler",
+			"With pg_autoscale_mode = \"on\" the autoscaler changes pg_num, and every plan would change it back. "+
+				"Leave pg_num unset, or use target_size_bytes or target_size_ratio to guide the autoscaler.")
+	}
+	if !config.TargetSizeBytes.IsNull() && !config.TargetSizeRatio.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("target_size_ratio"), "Conflicting autoscaler targets",
+			"Ceph ignores target_size_bytes when target_size_ratio is set; set only one of them")
+	}
+	if !config.TargetSizeRatio.IsNull() && !config.TargetSizeRatio.IsUnknown() && config.TargetSizeRatio.ValueFloat64() < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("target_size_ratio"), "Invalid target_size_ratio",
+			"target_size_ratio cannot be negative")
+	}
+	for name, value := range map[string]durationValue{
+		"scrub_min_interval":  config.ScrubMinInterval,
+		"scrub_max_interval":  config.ScrubMaxInterval,
+		"deep_scrub_interval": config.DeepScrubInterval,
+	} {
+		if !value.IsNull() && !value.IsUnknown() && value.Seconds() < 0 {
+			resp.Diagnostics.AddAttributeError(path.Root(name), "Invalid scrub interval",
+				fmt.Sprintf("%s cannot be negative; leave it unset for the OSD default", name))
+		}
+	}
+	if min, max := config.ScrubMinInterval, config.ScrubMaxInterval; !min.IsNull() && !min.IsUnknown() && !max.IsNull() && !max.IsUnknown() &&
+		min.Seconds() > max.Seconds() {
+		resp.Diagnostics.AddAttributeError(path.Root("scrub_min_interval"), "Invalid scrub interval",
+			"scrub_min_interval cannot be longer than scrub_max_interval")
+	}
+	if p := config.RecoveryPriority; !p.IsNull() && !p.IsUnknown() && (p.ValueInt64() < -10 || p.ValueInt64() > 10) {
+		resp.Diagnostics.AddAttributeError(path.Root("recovery_priority"), "Invalid recovery_priority",
+			fmt.Sprintf("recovery_priority must be between -10 and 10, not %d", p.ValueInt64()))
+	}
+	if !config.QuotaMaxObjects.IsNull() && !config.QuotaMaxObjects.IsUnknown() && config.QuotaMaxObjects.ValueInt64() < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("quota_max_objects"), "Invalid quota",
+			"quota_max_objects cannot be negative; leave it unset for no quota")
+	}
+	if config.DeviceClass.IsNull() {
+		return
+	}
+
+	if !config.CrushRule.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("device_class"), "Conflicting placement settings",
+			"device_class selects its own CRUSH rule; set either device_class or crush_rule, not both")
+	}
+	if !config.Type.IsNull() && !config.Type.IsUnknown() && config.Type.ValueString() != "replicated" {
+		resp.Diagnostics.AddAttributeError(path.Root("device_class"), "device_class requires a replicated pool",
+			"Erasure-coded pools take their device class from the erasure code profile (crush-device-class)")
 	}
 }
 
+func (r *poolResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.client.planResourceCommand(ctx, req, &resp.Diagnostics, "ceph_pool", "ceph osd pool create", "ceph osd pool set", "ceph osd pool delete")
+	if req.Plan.Raw.IsNull() {
+		var state poolResourceModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		incomplete, diags := poolCreateIncomplete(ctx, req.Private)
+		resp.Diagnostics.Append(diags...)
+		switch {
+		case resp.Diagnostics.HasError():
+		case incomplete:
+			// Delete checks that the pool is still empty.
+		case state.DeleteProtection.ValueBool():
+			resp.Diagnostics.AddError("Pool is protected from deletion", poolDeleteProtected(state.Name.ValueString()))
+		case !state.ConfirmDataLoss.ValueBool():
+			resp.Diagnostics.AddError("Pool deletion not confirmed", poolDeletionNotConfirmed(state.Name.ValueString()))
+		}
+		return
+	}
+	var plan poolResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state poolResourceModel
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		checkPoolChangeGuard(&plan, &state, &resp.Diagnostics)
+		// A renamed pool keeps its data, and its id follows the name.
+		if !plan.Name.Equal(state.Name) && plan.AllowRename.ValueBool() {
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("id"), plan.Name)...)
+		}
+		var release cephRelease
+		if r.client != nil {
+			release = r.client.release
+		}
+		if replace := poolReplacements(&plan, &state, release); len(replace) > 0 {
+			if state.DeleteProtection.ValueBool() {
+				resp.Diagnostics.AddAttributeError(replace[0], "Pool is protected from deletion", poolDeleteProtected(state.Name.ValueString()))
+			} else {
+				resp.RequiresReplace = append(resp.RequiresReplace, replace...)
+			}
+		}
+		if !plan.Applications.IsUnknown() && !plan.ConfirmDataLoss.ValueBool() {
+			if _, removed, err := poolApplicationChanges(ctx, &plan, &state); err == nil && len(removed) > 0 {
+				resp.Diagnostics.AddAttributeError(path.Root("applications"), "Disabling pool applications not confirmed",
+					fmt.Sprintf("Disabling %s on pool %s cuts its clients off from the pool. Set confirm_data_loss = true "+
+						"on the pool to disable it.", strings.Join(removed, ", "), plan.Name.ValueString()))
+			}
+		}
+		if state.AllowECOverwrites.ValueBool() && !plan.AllowECOverwrites.IsUnknown() && !plan.AllowECOverwrites.ValueBool() {
+			resp.Diagnostics.AddAttributeError(path.Root("allow_ec_overwrites"), "EC overwrites cannot be disabled",
+				fmt.Sprintf("Pool %s already allows EC overwrites, and Ceph cannot turn them off again", plan.Name.ValueString()))
+		}
+	}
+
+	var configMinSize types.Int64
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("min_size"), &configMinSize)...)
+	mode := ""
+	if r.client != nil {
+		mode = r.client.PoolSizeSafety
+	}
+	if checkPoolSizeSafety(mode, !configMinSize.IsNull(), &plan, &resp.Diagnostics) {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("min_size"), plan.MinSize)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// device_class decides the rule, so a rule changed by hand shows up as
+	// drift and is set back on apply.
+	if !plan.DeviceClass.IsNull() && !plan.DeviceClass.IsUnknown() {
+		rule := types.StringValue(deviceClassRuleName(plan.DeviceClass.ValueString()))
+		if !plan.CrushRule.Equal(rule) {
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("crush_rule"), rule)...)
+		}
+		return
+	}
+
+	// Only check rules being set now, not ones already in use.
+	if r.client == nil || plan.CrushRule.IsNull() || plan.CrushRule.IsUnknown() || state.CrushRule.Equal(plan.CrushRule) {
+		return
+	}
+
+	r.client.warnMissingCrushRule(ctx, &resp.Diagnostics, path.Root("crush_rule"), plan.CrushRule.ValueString())
+}
+
+// setSettings copies the pool's settings, as read from the cluster, into
+// the model.
+func (m *poolResourceModel) setSettings(settings *poolSettings) {
+	m.PgNum = types.Int64Value(settings.PgNum)
+	m.PgpNum = types.Int64Value(settings.PgpNum)
+	m.Size = types.Int64Value(settings.Size)
+	m.MinSize = types.Int64Value(settings.MinSize)
+	m.Type = types.StringValue(settings.TypeName())
+	m.CrushRule = types.StringValue(settings.CrushRule)
+	m.PgAutoscaleMode = types.StringNull()
+	if settings.PgAutoscaleMode != "" {
+		m.PgAutoscaleMode = types.StringValue(settings.PgAutoscaleMode)
+	}
+	m.TargetSizeBytes = sizeNull()
+	if settings.TargetSizeBytes > 0 {
+		m.TargetSizeBytes = sizeBytes(settings.TargetSizeBytes)
+	}
+	m.TargetSizeRatio = types.Float64Null()
+	if settings.TargetSizeRatio > 0 {
+		m.TargetSizeRatio = types.Float64Value(settings.TargetSizeRatio)
+	}
+	m.ScrubMinInterval = refreshInterval(m.ScrubMinInterval, settings.ScrubMinInterval)
+	m.ScrubMaxInterval = refreshInterval(m.ScrubMaxInterval, settings.ScrubMaxInterval)
+	m.DeepScrubInterval = refreshInterval(m.DeepScrubInterval, settings.DeepScrubInterval)
+	// Ceph reports an unset recovery_priority as 0, so a configured 0 is
+	// kept rather than read back as null.
+	if settings.RecoveryPriority != 0 || m.RecoveryPriority.IsNull() || m.RecoveryPriority.ValueInt64() != 0 {
+		m.RecoveryPriority = types.Int64Null()
+		if settings.RecoveryPriority != 0 {
+			m.RecoveryPriority = types.Int64Value(settings.RecoveryPriority)
+		}
+	}
+	// bulk is reported either way, and refreshed only when managed.
+	if !m.Bulk.IsNull() {
+		m.Bulk = types.BoolValue(settings.Bulk)
+	}
+	// Optional EC settings are refreshed only when managed.
+	if !m.ErasureCodeProfile.IsNull() {
+		m.ErasureCodeProfile = types.StringValue(settings.ErasureCodeProfile)
+	}
+	if !m.AllowECOverwrites.IsNull() {
+		m.AllowECOverwrites = types.BoolValue(settings.AllowECOverwrites)
+	}
+	if !m.DeleteProtection.IsNull() {
+		m.DeleteProtection = types.BoolValue(settings.NoDelete)
+	}
+}
+
+// refreshInterval returns a scrub interval as Ceph reports it, or null when
+// it is unset. Ceph reports an unset interval as 0, so a configured zero such
+// as "0s" is kept as written.
+func refreshInterval(current durationValue, seconds float64) durationValue {
+	if seconds > 0 {
+		return durationSeconds(seconds)
+	}
+	if !current.IsNull() && !current.IsUnknown() && current.Seconds() == 0 {
+		return current
+	}
+	return durationNull()
+}
+
+// readComputed fills in the settings left to the cluster, which are
+// unknown in the plan until the pool has been created or changed.
+func (r *poolResource) readComputed(plan *poolResourceModel) error {
+	if !plan.PgNum.IsUnknown() && !plan.PgpNum.IsUnknown() && !plan.Size.IsUnknown() && !plan.MinSize.IsUnknown() &&
+		!plan.CrushRule.IsUnknown() && !plan.PgAutoscaleMode.IsUnknown() {
+		return nil
+	}
+	settings, err := r.client.GetPoolSettings(plan.Name.ValueString())
+	if err != nil {
+		return err
+	}
+	if plan.PgNum.IsUnknown() {
+		plan.PgNum = types.Int64Value(settings.PgNum)
+	}
+	if plan.PgAutoscaleMode.IsUnknown() {
+		plan.PgAutoscaleMode = types.StringNull()
+		if settings.PgAutoscaleMode != "" {
+			plan.PgAutoscaleMode = types.StringValue(settings.PgAutoscaleMode)
+		}
+	}
+	if plan.PgpNum.IsUnknown() {
+		plan.PgpNum = types.Int64Value(settings.PgpNum)
+	}
+	if plan.Size.IsUnknown() {
+		plan.Size = types.Int64Value(settings.Size)
+	}
+	if plan.MinSize.IsUnknown() {
+		plan.MinSize = types.Int64Value(settings.MinSize)
+	}
+	if plan.CrushRule.IsUnknown() {
+		plan.CrushRule = types.StringValue(settings.CrushRule)
+	}
+	return nil
+}
+
+// applyAutoscale sets the autoscaler mode and targets that differ from
+// state. A target removed from the configuration is cleared.
+func (r *poolResource) applyAutoscale(plan, state *poolResourceModel) error {
+	name := plan.Name.ValueString()
+	if !plan.PgAutoscaleMode.IsUnknown() && !plan.PgAutoscaleMode.IsNull() && !plan.PgAutoscaleMode.Equal(state.PgAutoscaleMode) {
+		cmd := NewCommand("ceph", "osd", "pool", "set").Arg(name, "pg_autoscale_mode", plan.PgAutoscaleMode.ValueString())
+		if _, err := r.client.ExecuteCommand(cmd); err != nil {
+			return err
+		}
+	}
+	if planBytes := bytesOrZero(plan.TargetSizeBytes); planBytes != bytesOrZero(state.TargetSizeBytes) {
+		cmd := NewCommand("ceph", "osd", "pool", "set").Arg(name, "target_size_bytes").Int(planBytes)
+		if _, err := r.client.ExecuteCommand(cmd); err != nil {
+			return err
+		}
+	}
+	if plan.TargetSizeRatio.ValueFloat64() != state.TargetSizeRatio.ValueFloat64() {
+		cmd := NewCommand("ceph", "osd", "pool", "set").Arg(name, "target_size_ratio").Float(plan.TargetSizeRatio.ValueFloat64())
+		if _, err := r.client.ExecuteCommand(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyTunables sets the bulk flag, scrub intervals and recovery priority
+// that differ from state. A setting removed from the configuration is
+// reset to 0, which Ceph reads as unset.
+func (r *poolResource) applyTunables(plan, state *poolResourceModel) error {
+	name := plan.Name.ValueString()
+	if plan.Bulk.ValueBool() != state.Bulk.ValueBool() {
+		cmd := NewCommand("ceph", "osd", "pool", "set").Arg(name, "bulk", strconv.FormatBool(plan.Bulk.ValueBool()))
+		if _, err := r.client.ExecuteCommand(cmd); err != nil {
+			return err
+		}
+	}
+	for _, interval := range []struct {
+		option      string
+		plan, state durationValue
+	}{
+		{"scrub_min_interval", plan.ScrubMinInterval, state.ScrubMinInterval},
+		{"scrub_max_interval", plan.ScrubMaxInterval, state.ScrubMaxInterval},
+		{"deep_scrub_interval", plan.DeepScrubInterval, state.DeepScrubInterval},
+	} {
+		if interval.plan.Seconds() == interval.state.Seconds() {
+			continue
+		}
+		cmd := NewCommand("ceph", "osd", "pool", "set").Arg(name, interval.option).Float(interval.plan.Seconds())
+		if _, err := r.client.ExecuteCommand(cmd); err != nil {
+			return err
+		}
+	}
+	if plan.RecoveryPriority.ValueInt64() != state.RecoveryPriority.ValueInt64() {
+		cmd := NewCommand("ceph", "osd", "pool", "set").Arg(name, "recovery_priority").Int(plan.RecoveryPriority.ValueInt64())
+		if _, err := r.client.ExecuteCommand(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poolQuota returns a quota as `ceph osd pool set-quota` takes it, where 0
+// means unlimited.
+func poolQuota(bytes sizeValue, objects types.Int64) (int64, int64) {
+	var maxObjects int64
+	if !objects.IsNull() && !objects.IsUnknown() {
+		maxObjects = objects.ValueInt64()
+	}
+	return bytesOrZero(bytes), maxObjects
+}
+
+// bytesOrZero returns a size in bytes, or 0, which Ceph reads as unset,
+// for a null size.
+func bytesOrZero(v sizeValue) int64 {
+	if v.IsNull() || v.IsUnknown() {
+		return 0
+	}
+	return v.Bytes()
+}
+
+// applyQuota sets the quotas that differ from state. A quota removed from
+// the configuration is cleared.
+func (r *poolResource) applyQuota(plan, state *poolResourceModel) error {
+	planBytes, planObjects := poolQuota(plan.QuotaMaxBytes, plan.QuotaMaxObjects)
+	stateBytes, stateObjects := poolQuota(state.QuotaMaxBytes, state.QuotaMaxObjects)
+	return r.client.SetPoolQuota(plan.Name.ValueString(), planBytes, planObjects, stateBytes, stateObjects)
+}
+
+// applyTags replaces the pool's tags with the planned ones; none clears
+// them. Applications are enabled first, since the tags live in their
+// metadata.
+func (r *poolResource) applyTags(ctx context.Context, plan *poolResourceModel) error {
+	tags, err := tagsFromMap(ctx, plan.Tags)
+	if err != nil {
+		return err
+	}
+	return r.client.SetPoolTags(plan.Name.ValueString(), tags)
+}
+
+// poolApplicationChanges returns the applications to enable and the ones
+// removed from the configuration, which are disabled.
+func poolApplicationChanges(ctx context.Context, plan, state *poolResourceModel) (added, removed []string, err error) {
+	var planned, current []string
+	if !plan.Applications.IsNull() {
+		if diags := plan.Applications.ElementsAs(ctx, &planned, false); diags.HasError() {
+			return nil, nil, fmt.Errorf("invalid applications")
+		}
+	}
+	if !state.Applications.IsNull() {
+		if diags := state.Applications.ElementsAs(ctx, &current, false); diags.HasError() {
+			return nil, nil, fmt.Errorf("invalid applications in state")
+		}
+	}
+
+	enabled := make(map[string]bool, len(current))
+	for _, app := range current {
+		enabled[app] = true
+	}
+	for _, app := range planned {
+		if enabled[app] {
+			delete(enabled, app)
+			continue
+		}
+		added = append(added, app)
+	}
+	// Whatever is left was enabled before and is no longer configured.
+	for app := range enabled {
+		removed = append(removed, app)
+	}
+	sort.Strings(removed)
+	return added, removed, nil
+}
+
+// applyApplications enables the planned applications on the pool and
+// disables the ones removed from the configuration. Ceph guards disabling
+// with a force flag, since the application's clients lose the pool, so it
+// is only passed with confirm_data_loss.
+func (r *poolResource) applyApplications(ctx context.Context, plan, state *poolResourceModel) error {
+	added, removed, err := poolApplicationChanges(ctx, plan, state)
+	if err != nil {
+		return err
+	}
+	for _, app := range added {
+		cmd := NewCommand("ceph", "osd", "pool", "application", "enable").Arg(plan.Name.ValueString(), app)
+		if _, err := r.client.ExecuteCommand(cmd); err != nil {
+			return err
+		}
+	}
+	for _, app := range removed {
+		cmd := NewCommand("ceph", "osd", "pool", "application", "disable").
+			Arg(plan.Name.ValueString(), app).
+			Flag("--yes-i-really-mean-it")
+		if plan.ConfirmDataLoss.ValueBool() {
+			cmd.Confirmed()
+		}
+		if _, err := r.client.ExecuteCommand(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyDeviceClass points the pool at the replicated rule for its device
+// class, creating the rule if no pool has used the class yet.
+func (r *poolResource) applyDeviceClass(plan *poolResourceModel) error {
+	rule, err := r.client.EnsureDeviceClassRule(plan.DeviceClass.ValueString())
+	if err != nil {
+		return err
+	}
+	_, err = r.client.ExecuteCommand(NewCommand("ceph", "osd", "pool", "set").
+		Arg(plan.Name.ValueString(), "crush_rule", rule))
+	if err != nil {
+		return err
+	}
+	plan.CrushRule = types.StringValue(rule)
+	return nil
+}
+
 func (r *poolResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
 	}
-	r.client = req.ProviderData.(*CephClient)
+	r.client = req.ProviderData.(*CephClient)
+}
+
+// poolAdoptConflict explains why Create cannot take over an existing pool,
+// or returns "". live is the plan refreshed from the pool, as Read would.
+// The pool is adopted only if it holds no objects and every configured
+// setting already matches, as for a pool whose create succeeded in an
+// apply that then stopped before recording it. Anything else may belong
+// to someone else and has to be imported explicitly.
+func poolAdoptConflict(ctx context.Context, plan, live *poolResourceModel, poolType string) string {
+	name := plan.Name.ValueString()
+	importHint := fmt.Sprintf(" To manage it, import it with `terraform import <address> %s`, or pick another name.", name)
+	if live.Objects.ValueInt64() != 0 {
+		return fmt.Sprintf("Pool %q already exists and holds %d objects.", name, live.Objects.ValueInt64()) + importHint
+	}
+	if live.Type.ValueString() != poolType {
+		return fmt.Sprintf("Pool %q already exists with type %s, but type %s is planned.", name, live.Type.ValueString(), poolType) + importHint
+	}
+
+	settings := []struct {
+		name          string
+		planned, live attr.Value
+	}{
+		{"pg_num", plan.PgNum, live.PgNum},
+		{"pgp_num", plan.PgpNum, live.PgpNum},
+		{"size", plan.Size, live.Size},
+		{"min_size", plan.MinSize, live.MinSize},
+		{"crush_rule", plan.CrushRule, live.CrushRule},
+		{"pg_autoscale_mode", plan.PgAutoscaleMode, live.PgAutoscaleMode},
+		{"target_size_bytes", plan.TargetSizeBytes, live.TargetSizeBytes},
+		{"target_size_ratio", plan.TargetSizeRatio, live.TargetSizeRatio},
+		{"bulk", plan.Bulk, live.Bulk},
+		{"scrub_min_interval", plan.ScrubMinInterval, live.ScrubMinInterval},
+		{"scrub_max_interval", plan.ScrubMaxInterval, live.ScrubMaxInterval},
+		{"deep_scrub_interval", plan.DeepScrubInterval, live.DeepScrubInterval},
+		{"recovery_priority", plan.RecoveryPriority, live.RecoveryPriority},
+		{"erasure_code_profile", plan.ErasureCodeProfile, live.ErasureCodeProfile},
+		{"allow_ec_overwrites", plan.AllowECOverwrites, live.AllowECOverwrites},
+		{"delete_protection", plan.DeleteProtection, live.DeleteProtection},
+		{"applications", plan.Applications, live.Applications},
+		{"quota_max_bytes", plan.QuotaMaxBytes, live.QuotaMaxBytes},
+		{"quota_max_objects", plan.QuotaMaxObjects, live.QuotaMaxObjects},
+		{"tags", plan.Tags, live.Tags},
+	}
+	if plan.CacheTier != nil {
+		if live.CacheTier == nil {
+			return fmt.Sprintf("Pool %q already exists without the planned cache_tier.", name) + importHint
+		}
+		settings = append(settings, []struct {
+			name          string
+			planned, live attr.Value
+		}{
+			{"cache_tier.pool", plan.CacheTier.Pool, live.CacheTier.Pool},
+			{"cache_tier.mode", plan.CacheTier.Mode, live.CacheTier.Mode},
+			{"cache_tier.hit_set_type", plan.CacheTier.HitSetType, live.CacheTier.HitSetType},
+			{"cache_tier.hit_set_count", plan.CacheTier.HitSetCount, live.CacheTier.HitSetCount},
+			{"cache_tier.hit_set_period", plan.CacheTier.HitSetPeriod, live.CacheTier.HitSetPeriod},
+			{"cache_tier.target_max_bytes", plan.CacheTier.TargetMaxBytes, live.CacheTier.TargetMaxBytes},
+		}...)
+	}
+	for _, s := range settings {
+		if !settingMatches(ctx, s.planned, s.live) {
+			return fmt.Sprintf("Pool %q already exists with %s = %s, but %s is planned.", name, s.name, s.live, s.planned) + importHint
+		}
+	}
+	return ""
+}
+
+// settingMatches reports whether a live value satisfies the planned one.
+// Values left to the cluster (null or unknown) always do; sizes and
+// durations compare by what they mean, not how they are written.
+func settingMatches(ctx context.Context, planned, live attr.Value) bool {
+	if planned.IsNull() || planned.IsUnknown() || planned.Equal(live) {
+		return true
+	}
+	semantic, ok := planned.(basetypes.StringValuableWithSemanticEquals)
+	if !ok {
+		return false
+	}
+	liveString, ok := live.(basetypes.StringValuable)
+	if !ok {
+		return false
+	}
+	equal, diags := semantic.StringSemanticEquals(ctx, liveString)
+	return equal && !diags.HasError()
 }
 
 func (r *poolResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -195,49 +1510,183 @@ func (r *poolResource) Create(ctx context.Context, req resource.CreateRequest, r
 		poolType = plan.Type.ValueString()
 	}
 
-	cmd := fmt.Sprintf("ceph osd pool create %s %d %d %s",
-		plan.Name.ValueString(),
-		plan.PgNum.ValueInt64(),
-		plan.PgpNum.ValueInt64(),
-		poolType)
+	if err := r.client.checkHealth(plan.RequireHealth.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Cluster health requirement not met", err)
+		return
+	}
 
-	_, err := r.client.ExecuteCommand(cmd)
+	// An apply that stopped right after creating the pool leaves it outside
+	// state; take it over if it is empty and already configured as planned.
+	existing, err := r.client.GetPoolDetail(plan.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create pool", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to check for existing pool", err)
+		return
+	}
+
+	var cmd *CommandBuilder
+	var folded poolCreateFolded
+	usage := &poolUsage{}
+	adopted := existing != nil
+	if adopted {
+		live := plan
+		if err := r.refresh(ctx, &live, existing); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to read existing pool", err)
+			return
+		}
+		if conflict := poolAdoptConflict(ctx, &plan, &live, poolType); conflict != "" {
+			resp.Diagnostics.AddError("Pool already exists", conflict)
+			return
+		}
+		usage = &poolUsage{StoredBytes: live.StoredBytes.ValueInt64(), PercentUsed: live.PercentUsed.ValueFloat64()}
+
+		tflog.Info(ctx, "Adopting existing Ceph pool", map[string]interface{}{
+			"name": plan.Name.ValueString(),
+		})
+	} else {
+		cmd, folded = poolCreateCommand(&plan, poolType, r.client.release)
+		_, err = r.client.ExecuteCommand(cmd)
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to create pool", err)
+			return
+		}
+
+		existing, err = r.client.GetPoolDetail(plan.Name.ValueString())
+		if err == nil && existing == nil {
+			err = fmt.Errorf("pool %s not found after creation", plan.Name.ValueString())
+		}
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to read created pool", err)
+			return
+		}
+	}
+
+	plan.ID = plan.Name
+	plan.PoolID = types.Int64Value(existing.PoolID)
+
+	// Record the pool as soon as it exists; see setPartialState. If a step
+	// below fails, the pool is marked so that the replacement may delete
+	// it while it is still empty.
+	resp.Diagnostics.Append(setPartialState(ctx, &resp.State, plan)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
+	if !adopted {
+		defer func() {
+			if resp.Diagnostics.HasError() {
+				resp.Diagnostics.Append(resp.Private.SetKey(ctx, poolCreateIncompleteKey, []byte("true"))...)
+			}
+		}()
+	}
 
-	// Set pool properties
-	if !plan.Size.IsNull() {
-		cmd = fmt.Sprintf("ceph osd pool set %s size %d",
-			plan.Name.ValueString(), plan.Size.ValueInt64())
+	// Set pool properties. Unconfigured ones are unknown in the plan and
+	// read back from the cluster below.
+	if !plan.Size.IsUnknown() && !folded.Size {
+		cmd = NewCommand("ceph", "osd", "pool", "set").Arg(plan.Name.ValueString(), "size").Int(plan.Size.ValueInt64())
 		_, err = r.client.ExecuteCommand(cmd)
 		if err != nil {
-			resp.Diagnostics.AddError("Failed to set pool size", err.Error())
+			addCommandError(&resp.Diagnostics, "Failed to set pool size", err)
 			return
 		}
 	}
 
-	if !plan.MinSize.IsNull() {
-		cmd = fmt.Sprintf("ceph osd pool set %s min_size %d",
-			plan.Name.ValueString(), plan.MinSize.ValueInt64())
+	if !plan.MinSize.IsUnknown() {
+		cmd = NewCommand("ceph", "osd", "pool", "set").Arg(plan.Name.ValueString(), "min_size").Int(plan.MinSize.ValueInt64())
 		_, err = r.client.ExecuteCommand(cmd)
 		if err != nil {
-			resp.Diagnostics.AddError("Failed to set pool min_size", err.Error())
+			addCommandError(&resp.Diagnostics, "Failed to set pool min_size", err)
 			return
 		}
 	}
 
-	if !plan.CrushRule.IsNull() {
-		cmd = fmt.Sprintf("ceph osd pool set %s crush_rule %s",
-			plan.Name.ValueString(), plan.CrushRule.ValueString())
+	// A device class rule may not exist yet; applyDeviceClass sets it.
+	if !plan.CrushRule.IsUnknown() && plan.DeviceClass.IsNull() && !folded.CrushRule {
+		cmd = NewCommand("ceph", "osd", "pool", "set").
+			Arg(plan.Name.ValueString(), "crush_rule", plan.CrushRule.ValueString())
 		_, err = r.client.ExecuteCommand(cmd)
 		if err != nil {
-			resp.Diagnostics.AddError("Failed to set crush rule", err.Error())
+			addCommandError(&resp.Diagnostics, "Failed to set crush rule", err)
+			return
+		}
+	}
+
+	if !plan.DeviceClass.IsNull() {
+		if err := r.applyDeviceClass(&plan); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to apply device class", err)
+			return
+		}
+	}
+
+	if plan.AllowECOverwrites.ValueBool() {
+		if err := r.client.AllowECOverwrites(plan.Name.ValueString()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to allow EC overwrites", err)
+			return
+		}
+	}
+
+	if err := r.applyApplications(ctx, &plan, &poolResourceModel{Applications: types.SetNull(types.StringType)}); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to enable pool applications", err)
+		return
+	}
+
+	if !plan.Tags.IsNull() {
+		if err := r.applyTags(ctx, &plan); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to set pool tags", err)
+			return
+		}
+	}
+
+	created := &poolResourceModel{}
+	if folded.AutoscaleMode {
+		created.PgAutoscaleMode = plan.PgAutoscaleMode
+	}
+	if err := r.applyAutoscale(&plan, created); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to configure the PG autoscaler", err)
+		return
+	}
+
+	if err := r.applyTunables(&plan, &poolResourceModel{}); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to set pool tunables", err)
+		return
+	}
+
+	if err := r.applyCacheTier(&plan, &poolResourceModel{}); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to configure cache tier", err)
+		return
+	}
+
+	if !plan.DeleteProtection.IsNull() {
+		if err := r.client.SetPoolNoDelete(plan.Name.ValueString(), plan.DeleteProtection.ValueBool()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to set pool nodelete flag", err)
+			return
+		}
+	}
+
+	current := &poolResourceModel{QuotaMaxBytes: sizeBytes(existing.QuotaMaxBytes), QuotaMaxObjects: types.Int64Value(existing.QuotaMaxObjects)}
+	if err := r.applyQuota(&plan, current); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to set pool quota", err)
+		return
+	}
+
+	if plan.WaitForActiveClean.ValueBool() {
+		if err := r.client.WaitForPoolActiveClean(ctx, plan.Name.ValueString(), activeCleanTimeout(plan.ActiveCleanTimeout)); err != nil {
+			addCommandError(&resp.Diagnostics, "Pool PGs did not become active+clean", err)
 			return
 		}
 	}
 
+	if err := r.readComputed(&plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read created pool", err)
+		return
+	}
+	// The pool is empty, whether created just now or adopted.
+	plan.setUsage(usage)
+
+	plan.PostCreateOutput, err = r.client.runHooks(ctx, plan.PostCreateCommands, "post_create_commands")
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Pool post-create command failed", err)
+		return
+	}
+
 	tflog.Info(ctx, "Created Ceph pool", map[string]interface{}{
 		"name": plan.Name.ValueString(),
 	})
@@ -254,69 +1703,218 @@ func (r *poolResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	cmd := fmt.Sprintf("ceph osd pool get %s all", state.Name.ValueString())
-	output, err := r.client.ExecuteCommand(cmd)
+	detail, err := r.client.GetPoolDetail(state.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to read pool", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to read pool", err)
+		return
+	}
+	if detail == nil {
+		resp.State.RemoveResource(ctx)
 		return
 	}
 
-	// Parse output to update state
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "size:") {
-			parts := strings.Split(line, ":")
-			if len(parts) == 2 {
-				size, _ := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
-				state.Size = types.Int64Value(size)
-			}
-		}
-		if strings.Contains(line, "min_size:") {
-			parts := strings.Split(line, ":")
-			if len(parts) == 2 {
-				minSize, _ := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
-				state.MinSize = types.Int64Value(minSize)
-			}
-		}
+	if err := r.refresh(ctx, &state, detail); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read pool", err)
+		return
 	}
 
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
 }
 
+// refresh reads the pool's settings and usage from the cluster into m.
+func (r *poolResource) refresh(ctx context.Context, m *poolResourceModel, detail *poolDetail) error {
+	settings, err := r.client.GetPoolSettings(m.Name.ValueString())
+	if err != nil {
+		return err
+	}
+	usage, err := r.client.GetPoolUsage(m.Name.ValueString())
+	if err != nil {
+		return err
+	}
+	m.ID = m.Name
+	m.PoolID = types.Int64Value(detail.PoolID)
+	m.setUsage(usage)
+	m.setSettings(settings)
+	// Quotas are always refreshed, so one set outside Terraform shows up
+	// as drift; 0 means no quota.
+	m.QuotaMaxBytes = sizeNull()
+	if detail.QuotaMaxBytes > 0 {
+		m.QuotaMaxBytes = sizeBytes(detail.QuotaMaxBytes)
+	}
+	m.QuotaMaxObjects = optionalInt64(detail.QuotaMaxObjects)
+	// Applications are only refreshed when managed, so ones enabled by
+	// other tools (e.g. rgw creating its pools) do not show as drift.
+	if !m.Applications.IsNull() {
+		apps, diags := types.SetValueFrom(ctx, types.StringType, detail.Applications())
+		if diags.HasError() {
+			return fmt.Errorf("invalid pool applications")
+		}
+		m.Applications = apps
+	}
+	if !m.Tags.IsNull() {
+		_, tags := poolTags(detail)
+		if m.Tags, err = tagsValue(ctx, tags); err != nil {
+			return fmt.Errorf("invalid pool tags: %w", err)
+		}
+	}
+	if m.CacheTier != nil {
+		if m.CacheTier, err = r.readCacheTier(detail, m.CacheTier); err != nil {
+			return fmt.Errorf("failed to read cache tier: %w", err)
+		}
+	}
+	return nil
+}
+
+// ImportState adopts an existing pool by name, e.g.
+// `terraform import ceph_pool.data data`.
+func (r *poolResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+}
+
 func (r *poolResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	var plan poolResourceModel
+	var state poolResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	if err := r.client.checkHealth(plan.RequireHealth.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Cluster health requirement not met", err)
+		return
+	}
+
+	// Rename first, so the settings below address the pool by its new name.
+	if !plan.Name.Equal(state.Name) {
+		if _, _, err := r.client.EnsurePoolRenamed(state.Name.ValueString(), plan.Name.ValueString()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to rename pool", err)
+			return
+		}
+	}
+
 	// Update pool properties
-	if !plan.Size.IsNull() {
-		cmd := fmt.Sprintf("ceph osd pool set %s size %d",
-			plan.Name.ValueString(), plan.Size.ValueInt64())
+	if !plan.PgNum.IsUnknown() && !plan.PgNum.Equal(state.PgNum) {
+		cmd := NewCommand("ceph", "osd", "pool", "set").Arg(plan.Name.ValueString(), "pg_num").Int(plan.PgNum.ValueInt64())
+		if _, err := r.client.ExecuteCommand(cmd); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update pool pg_num", err)
+			return
+		}
+	}
+
+	if !plan.PgpNum.IsUnknown() && !plan.PgpNum.Equal(state.PgpNum) {
+		cmd := NewCommand("ceph", "osd", "pool", "set").Arg(plan.Name.ValueString(), "pgp_num").Int(plan.PgpNum.ValueInt64())
+		if _, err := r.client.ExecuteCommand(cmd); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update pool pgp_num", err)
+			return
+		}
+	}
+
+	if !plan.Size.IsUnknown() && !plan.Size.Equal(state.Size) {
+		cmd := NewCommand("ceph", "osd", "pool", "set").Arg(plan.Name.ValueString(), "size").Int(plan.Size.ValueInt64())
 		_, err := r.client.ExecuteCommand(cmd)
 		if err != nil {
-			resp.Diagnostics.AddError("Failed to update pool size", err.Error())
+			addCommandError(&resp.Diagnostics, "Failed to update pool size", err)
 			return
 		}
 	}
 
-	if !plan.MinSize.IsNull() {
-		cmd := fmt.Sprintf("ceph osd pool set %s min_size %d",
-			plan.Name.ValueString(), plan.MinSize.ValueInt64())
+	if !plan.MinSize.IsUnknown() && !plan.MinSize.Equal(state.MinSize) {
+		cmd := NewCommand("ceph", "osd", "pool", "set").Arg(plan.Name.ValueString(), "min_size").Int(plan.MinSize.ValueInt64())
 		_, err := r.client.ExecuteCommand(cmd)
 		if err != nil {
-			resp.Diagnostics.AddError("Failed to update pool min_size", err.Error())
+			addCommandError(&resp.Diagnostics, "Failed to update pool min_size", err)
+			return
+		}
+	}
+
+	if plan.DeviceClass.IsNull() && !plan.CrushRule.IsUnknown() && !plan.CrushRule.Equal(state.CrushRule) {
+		cmd := NewCommand("ceph", "osd", "pool", "set").
+			Arg(plan.Name.ValueString(), "crush_rule", plan.CrushRule.ValueString())
+		if _, err := r.client.ExecuteCommand(cmd); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update crush rule", err)
+			return
+		}
+	}
+
+	if !plan.DeviceClass.IsNull() {
+		if err := r.applyDeviceClass(&plan); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to apply device class", err)
+			return
+		}
+	}
+
+	if plan.AllowECOverwrites.ValueBool() && !state.AllowECOverwrites.ValueBool() {
+		if err := r.client.AllowECOverwrites(plan.Name.ValueString()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to allow EC overwrites", err)
+			return
+		}
+	}
+
+	if !plan.Applications.Equal(state.Applications) {
+		if err := r.applyApplications(ctx, &plan, &state); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update pool applications", err)
+			return
+		}
+	}
+
+	// Tags live in the first application's metadata, so a change of
+	// applications can move them.
+	if !plan.Tags.Equal(state.Tags) || !plan.Tags.IsNull() && !plan.Applications.Equal(state.Applications) {
+		if err := r.applyTags(ctx, &plan); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update pool tags", err)
+			return
+		}
+	}
+
+	if err := r.applyQuota(&plan, &state); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update pool quota", err)
+		return
+	}
+
+	if err := r.applyAutoscale(&plan, &state); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update the PG autoscaler", err)
+		return
+	}
+
+	if err := r.applyTunables(&plan, &state); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update pool tunables", err)
+		return
+	}
+
+	if err := r.applyCacheTier(&plan, &state); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update cache tier", err)
+		return
+	}
+
+	if !plan.DeleteProtection.Equal(state.DeleteProtection) {
+		if err := r.client.SetPoolNoDelete(plan.Name.ValueString(), plan.DeleteProtection.ValueBool()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update pool nodelete flag", err)
 			return
 		}
 	}
 
+	if err := r.readComputed(&plan); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read updated pool", err)
+		return
+	}
+	usage, err := r.client.GetPoolUsage(plan.Name.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read pool usage", err)
+		return
+	}
+	plan.setUsage(usage)
+
 	tflog.Info(ctx, "Updated Ceph pool", map[string]interface{}{
 		"name": plan.Name.ValueString(),
 	})
 
+	plan.ID = plan.Name
+
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
 }
@@ -329,11 +1927,107 @@ func (r *poolResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
-	cmd := fmt.Sprintf("ceph osd pool delete %s %s --yes-i-really-really-mean-it",
-		state.Name.ValueString(), state.Name.ValueString())
-	_, err := r.client.ExecuteCommand(cmd)
+	// A pool whose create failed part way is deleted without the checks
+	// below as long as it holds no objects.
+	incomplete, diags := poolCreateIncomplete(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if incomplete {
+		usage, err := r.client.GetPoolUsage(state.Name.ValueString())
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to read pool usage", err)
+			return
+		}
+		incomplete = usage.Objects == 0
+	}
+
+	// Checked again here as a replacement plans no destroy of its own.
+	if state.DeleteProtection.ValueBool() && !incomplete {
+		resp.Diagnostics.AddError("Pool is protected from deletion", poolDeleteProtected(state.Name.ValueString()))
+		return
+	}
+	if !state.ConfirmDataLoss.ValueBool() && !incomplete {
+		resp.Diagnostics.AddError("Pool deletion not confirmed", poolDeletionNotConfirmed(state.Name.ValueString()))
+		return
+	}
+
+	if err := r.client.checkHealth(state.RequireHealth.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Cluster health requirement not met", err)
+		return
+	}
+
+	// A nodelete flag set outside Terraform makes the monitors refuse the
+	// delete with an EPERM that names neither the flag nor the pool.
+	settings, err := r.client.GetPoolSettings(state.Name.ValueString())
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read pool", err)
+		return
+	}
+	// The failed create may have set the flag itself.
+	if settings.NoDelete && incomplete {
+		if err := r.client.SetPoolNoDelete(state.Name.ValueString(), false); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to clear pool nodelete flag", err)
+			return
+		}
+	} else if settings.NoDelete {
+		resp.Diagnostics.AddError("Pool deletion is disabled",
+			fmt.Sprintf("Pool %s has the nodelete flag set. Run `ceph osd pool set %s nodelete false` "+
+				"and destroy again.", state.Name.ValueString(), state.Name.ValueString()))
+		return
+	}
+
+	if _, err := r.client.runHooks(ctx, state.PreDestroyCommands, "pre_destroy_commands"); err != nil {
+		addCommandError(&resp.Diagnostics, "Pool pre-destroy command failed", err)
+		return
+	}
+
+	// The monitors refuse to delete a pool that still has tiers.
+	if state.CacheTier != nil {
+		if err := r.client.RemoveCacheTier(state.Name.ValueString(), state.CacheTier.Pool.ValueString()); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to remove cache tier", err)
+			return
+		}
+	}
+
+	// The monitors refuse pool deletes unless mon_allow_pool_delete is set,
+	// with an EPERM that would otherwise read as a missing capability.
+	allowed, err := r.client.PoolDeletionAllowed()
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read mon_allow_pool_delete", err)
+		return
+	}
+	if !allowed {
+		if !state.ToggleMonAllowPoolDelete.ValueBool() {
+			resp.Diagnostics.AddError("Pool deletion is disabled",
+				fmt.Sprintf("Pool %s cannot be deleted because mon_allow_pool_delete is false. "+
+					"Run `ceph config set mon mon_allow_pool_delete true` and destroy again, or set "+
+					"toggle_mon_allow_pool_delete = true on the pool (and apply it) so the provider "+
+					"enables the option for the delete and restores it afterwards.", state.Name.ValueString()))
+			return
+		}
+
+		restore, err := r.client.EnablePoolDeletion()
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to enable mon_allow_pool_delete", err)
+			return
+		}
+		defer func() {
+			if err := restore(); err != nil {
+				resp.Diagnostics.AddWarning("Failed to restore mon_allow_pool_delete",
+					fmt.Sprintf("mon_allow_pool_delete was left enabled: %s", err))
+			}
+		}()
+	}
+
+	cmd := NewCommand("ceph", "osd", "pool", "delete").
+		Arg(state.Name.ValueString(), state.Name.ValueString()).
+		Flag("--yes-i-really-really-mean-it").
+		Confirmed()
+	_, err = r.client.ExecuteCommand(cmd)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete pool", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to delete pool", err)
 		return
 	}
 
@@ -342,21 +2036,211 @@ func (r *poolResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 	})
 }
 
+// poolCreateIncompleteKey is the private state key marking a pool whose
+// Create failed after creating it.
+const poolCreateIncompleteKey = "create_incomplete"
+
+// privateState reads provider private state, as carried by requests.
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+}
+
+// poolCreateIncomplete reports whether the pool was left behind by a
+// failed Create.
+func poolCreateIncomplete(ctx context.Context, private privateState) (bool, diag.Diagnostics) {
+	value, diags := private.GetKey(ctx, poolCreateIncompleteKey)
+	return string(value) == "true", diags
+}
+
+// poolDeleteProtected explains how to lift a pool's delete protection.
+func poolDeleteProtected(name string) string {
+	return fmt.Sprintf("Pool %s has delete_protection = true, which also sets its nodelete flag. Set "+
+		"delete_protection = false and apply it before destroying or replacing the pool.", name)
+}
+
+// poolDeletionNotConfirmed explains how to confirm destroying a pool.
+func poolDeletionNotConfirmed(name string) string {
+	return fmt.Sprintf("Destroying pool %s deletes all of its data. Set confirm_data_loss = true on the pool "+
+		"and apply it before destroying the pool.", name)
+}
+
+// Pool details as reported by `ceph osd pool ls detail --format json`
+type poolDetail struct {
+	PoolName  string `json:"pool_name"`
+	PoolID    int64  `json:"pool"`
+	Type      int64  `json:"type"`
+	Size      int64  `json:"size"`
+	MinSize   int64  `json:"min_size"`
+	PgNum     int64  `json:"pg_num"`
+	PgpNum    int64  `json:"pg_placement_num"`
+	CrushRule int64  `json:"crush_rule"`
+	ECProfile string `json:"erasure_code_profile"`
+
+	ApplicationMetadata map[string]json.RawMessage `json:"application_metadata"`
+
+	FlagsNames string         `json:"flags_names"`
+	PoolSnaps  []poolSnapshot `json:"pool_snaps"`
+
+	QuotaMaxBytes   int64 `json:"quota_max_bytes"`
+	QuotaMaxObjects int64 `json:"quota_max_objects"`
+
+	// Cache tiering; pool ids are -1 when unset.
+	TierOf       int64  `json:"tier_of"`
+	ReadTier     int64  `json:"read_tier"`
+	CacheMode    string `json:"cache_mode"`
+	HitSetParams struct {
+		Type string `json:"type"`
+	} `json:"hit_set_params"`
+	HitSetCount    int64 `json:"hit_set_count"`
+	HitSetPeriod   int64 `json:"hit_set_period"`
+	TargetMaxBytes int64 `json:"target_max_bytes"`
+
+	// Raw is the pool's full entry, for raw_json.
+	Raw json.RawMessage `json:"-"`
+}
+
+// Applications returns the applications enabled on the pool, sorted.
+func (p *poolDetail) Applications() []string {
+	apps := make([]string, 0, len(p.ApplicationMetadata))
+	for app := range p.ApplicationMetadata {
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+	return apps
+}
+
+func (p *poolDetail) TypeName() string {
+	switch p.Type {
+	case 1:
+		return "replicated"
+	case 3:
+		return "erasure"
+	default:
+		return strconv.FormatInt(p.Type, 10)
+	}
+}
+
+func parsePoolDetails(output string) ([]poolDetail, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal([]byte(output), &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse pool details: %w", err)
+	}
+
+	pools := make([]poolDetail, len(entries))
+	for i, entry := range entries {
+		if err := json.Unmarshal(entry, &pools[i]); err != nil {
+			return nil, fmt.Errorf("failed to parse pool details: %w", err)
+		}
+		pools[i].Raw = entry
+	}
+	return pools, nil
+}
+
+// GetPoolDetail returns the details of the named pool, or nil if the pool
+// does not exist.
+func (c *CephClient) GetPoolDetail(name string) (*poolDetail, error) {
+	output, err := c.ReadJSON(NewCommand("ceph", "osd", "pool", "ls", "detail"))
+	if err != nil {
+		return nil, err
+	}
+
+	pools, err := parsePoolDetails(output)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pools {
+		if pools[i].PoolName == name {
+			return &pools[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// poolUsage is a pool's utilization as reported by `ceph df detail`.
+type poolUsage struct {
+	StoredBytes int64
+	Objects     int64
+	PercentUsed float64
+}
+
+// GetPoolUsage returns the utilization of the named pool. A pool the
+// monitors have not reported stats for yet, such as one created a moment
+// ago, has zero usage.
+func (c *CephClient) GetPoolUsage(name string) (*poolUsage, error) {
+	var df dfOutput
+	if err := c.ExecuteJSON(NewCommand("ceph", "df", "detail"), &df); err != nil {
+		return nil, err
+	}
+	for _, pool := range df.Pools {
+		if pool.Name == name {
+			// percent_used is a fraction despite its name.
+			return &poolUsage{
+				StoredBytes: pool.Stats.Stored,
+				Objects:     pool.Stats.Objects,
+				PercentUsed: pool.Stats.PercentUsed * 100,
+			}, nil
+		}
+	}
+	return &poolUsage{}, nil
+}
+
+// setUsage records a pool's utilization.
+func (m *poolResourceModel) setUsage(usage *poolUsage) {
+	m.StoredBytes = types.Int64Value(usage.StoredBytes)
+	m.Objects = types.Int64Value(usage.Objects)
+	m.PercentUsed = types.Float64Value(usage.PercentUsed)
+}
+
+// GetPoolDetailByID returns the pool with the given numeric id, or nil if
+// it does not exist.
+func (c *CephClient) GetPoolDetailByID(id int64) (*poolDetail, error) {
+	output, err := c.ReadJSON(NewCommand("ceph", "osd", "pool", "ls", "detail"))
+	if err != nil {
+		return nil, err
+	}
+
+	pools, err := parsePoolDetails(output)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pools {
+		if pools[i].PoolID == id {
+			return &pools[i], nil
+		}
+	}
+	return nil, nil
+}
+
 // User Resource
 type userResource struct {
 	client *CephClient
 }
 
 type userResourceModel struct {
+	ID       types.String `tfsdk:"id"`
 	Name     types.String `tfsdk:"name"`
 	Caps     types.Map    `tfsdk:"caps"`
 	Key      types.String `tfsdk:"key"`
+	Keyring  types.String `tfsdk:"keyring"`
+	KeyB64   types.String `tfsdk:"key_base64"`
+	Tags     types.Map    `tfsdk:"tags"`
+
+	RequireHealth types.String `tfsdk:"require_health"`
 }
 
 func NewUserResource() resource.Resource {
 	return &userResource{}
 }
 
+// setKey sets the key and the outputs derived from it. The keyring is
+// what `ceph auth get` prints, for a client's keyring file; key_base64
+// encodes the key once more, as a Kubernetes Secret's data holds it.
+func (m *userResourceModel) setKey(key string, caps map[string]string) {
+	m.Key = types.StringValue(key)
+	m.Keyring = types.StringValue(renderKeyring(m.Name.ValueString(), key, caps))
+	m.KeyB64 = types.StringValue(base64.StdEncoding.EncodeToString([]byte(key)))
+}
+
 func (r *userResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + "_user"
 }
@@ -365,6 +2249,8 @@ func (r *userResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 	resp.Schema = schema.Schema{
 		Description: "Manages a Ceph user",
 		Attributes: map[string]schema.Attribute{
+			"id":             resourceIDAttribute("Entity name, e.g. client.foo"),
+			"require_health": requireHealthAttribute(),
 			"name": schema.StringAttribute{
 				Description: "User name",
 				Required:    true,
@@ -374,10 +2260,28 @@ func (r *userResource) Schema(ctx context.Context, req resource.SchemaRequest, r
 				ElementType: types.StringType,
 				Required:    true,
 			},
-			"key": schema.StringAttribute{
-				Description: "User key (computed)",
+			"key": schema.StringAttribute{
+				Description: "User key (computed)",
+				Computed:    true,
+				Sensitive:   true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"keyring": schema.StringAttribute{
+				Description: "Keyring file content for the user, with its key and caps",
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"key_base64": schema.StringAttribute{
+				Description: "User key, base64-encoded once more for a Kubernetes Secret's data",
 				Computed:    true,
+				Sensitive:   true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
+			"tags": tagsAttribute(),
 		},
 	}
 }
@@ -389,6 +2293,46 @@ func (r *userResource) Configure(ctx context.Context, req resource.ConfigureRequ
 	r.client = req.ProviderData.(*CephClient)
 }
 
+func (r *userResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.client.planResourceCommand(ctx, req, &resp.Diagnostics, "ceph_user", "ceph auth get-or-create", "ceph auth caps", "ceph auth del")
+	if req.Plan.Raw.IsNull() || r.client == nil {
+		return
+	}
+	var plan userResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || plan.Caps.IsUnknown() {
+		return
+	}
+	var state userResourceModel
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if state.Caps.Equal(plan.Caps) {
+			return
+		}
+	}
+
+	caps := make(map[string]types.String)
+	diags = plan.Caps.ElementsAs(ctx, &caps, false)
+	resp.Diagnostics.Append(diags...)
+	if osd, ok := caps["osd"]; ok && !osd.IsUnknown() {
+		r.client.warnMissingPools(ctx, &resp.Diagnostics, path.Root("caps").AtMapKey("osd"),
+			capsPoolReferences(osd.ValueString())...)
+	}
+}
+
+func (r *userResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config userResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validateRequireHealth(config.RequireHealth, &resp.Diagnostics)
+	validateTags(config.Tags, &resp.Diagnostics)
+}
+
 func (r *userResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var plan userResourceModel
 	diags := req.Plan.Get(ctx, &plan)
@@ -397,6 +2341,11 @@ func (r *userResource) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
+	if err := r.client.checkHealth(plan.RequireHealth.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Cluster health requirement not met", err)
+		return
+	}
+
 	// Build caps string
 	capsMap := make(map[string]string)
 	diags = plan.Caps.ElementsAs(ctx, &capsMap, false)
@@ -405,35 +2354,24 @@ func (r *userResource) Create(ctx context.Context, req resource.CreateRequest, r
 		return
 	}
 
-	var capsArgs []string
-	for daemon, caps := range capsMap {
-		capsArgs = append(capsArgs, daemon, caps)
-	}
-
-	cmd := fmt.Sprintf("ceph auth get-or-create %s %s",
-		plan.Name.ValueString(), strings.Join(capsArgs, " "))
-	
-	output, err := r.client.ExecuteCommand(cmd)
+	entry, err := r.client.GetOrCreateAuth(plan.Name.ValueString(), capsMap)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create user", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to create user", err)
 		return
 	}
+	plan.setKey(entry.Key, entry.Caps)
 
-	// Extract key from output
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "key =") {
-			parts := strings.Split(line, "=")
-			if len(parts) == 2 {
-				plan.Key = types.StringValue(strings.TrimSpace(parts[1]))
-			}
-		}
+	if err := r.client.applyRegistryTags(ctx, "user", plan.Name.ValueString(), plan.Tags, types.MapNull(types.StringType)); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to set user tags", err)
+		return
 	}
 
 	tflog.Info(ctx, "Created Ceph user", map[string]interface{}{
 		"name": plan.Name.ValueString(),
 	})
 
+	plan.ID = plan.Name
+
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
 }
@@ -446,20 +2384,36 @@ func (r *userResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	cmd := fmt.Sprintf("ceph auth get %s", state.Name.ValueString())
-	output, err := r.client.ExecuteCommand(cmd)
+	entry, err := r.client.GetAuth(state.Name.ValueString())
 	if err != nil {
-		if strings.Contains(err.Error(), "entity does not exist") {
+		if isAuthNotFound(err) {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Failed to read user", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to read user", err)
 		return
 	}
+	state.ID = state.Name
+	state.setKey(entry.Key, entry.Caps)
 
-	// Parse output to verify user exists
-	if !strings.Contains(output, state.Name.ValueString()) {
-		resp.State.RemoveResource(ctx)
+	// Ceph may store caps in a different spelling than they were given;
+	// only report a change when the access granted differs.
+	stateCaps := make(map[string]string)
+	diags = state.Caps.ElementsAs(ctx, &stateCaps, false)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	caps := reconcileCaps(stateCaps, entry.Caps)
+	state.Caps, diags = types.MapValueFrom(ctx, types.StringType, caps)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	state.Tags, err = r.client.readRegistryTags(ctx, "user", state.Name.ValueString(), state.Tags)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read user tags", err)
 		return
 	}
 
@@ -469,12 +2423,20 @@ func (r *userResource) Read(ctx context.Context, req resource.ReadRequest, resp
 
 func (r *userResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	var plan userResourceModel
+	var state userResourceModel
 	diags := req.Plan.Get(ctx, &plan)
 	resp.Diagnostics.Append(diags...)
+	diags = req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	if err := r.client.checkHealth(plan.RequireHealth.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Cluster health requirement not met", err)
+		return
+	}
+
 	// Build caps string
 	capsMap := make(map[string]string)
 	diags = plan.Caps.ElementsAs(ctx, &capsMap, false)
@@ -483,17 +2445,21 @@ func (r *userResource) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
-	var capsArgs []string
+	cmd := NewCommand("ceph", "auth", "caps").Arg(plan.Name.ValueString())
 	for daemon, caps := range capsMap {
-		capsArgs = append(capsArgs, daemon, caps)
+		cmd.Arg(daemon, caps)
 	}
 
-	cmd := fmt.Sprintf("ceph auth caps %s %s",
-		plan.Name.ValueString(), strings.Join(capsArgs, " "))
-	
 	_, err := r.client.ExecuteCommand(cmd)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to update user caps", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to update user caps", err)
+		return
+	}
+	// Changing caps keeps the key.
+	plan.setKey(state.Key.ValueString(), capsMap)
+
+	if err := r.client.applyRegistryTags(ctx, "user", plan.Name.ValueString(), plan.Tags, state.Tags); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to update user tags", err)
 		return
 	}
 
@@ -501,6 +2467,8 @@ func (r *userResource) Update(ctx context.Context, req resource.UpdateRequest, r
 		"name": plan.Name.ValueString(),
 	})
 
+	plan.ID = plan.Name
+
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
 }
@@ -513,10 +2481,20 @@ func (r *userResource) Delete(ctx context.Context, req resource.DeleteRequest, r
 		return
 	}
 
-	cmd := fmt.Sprintf("ceph auth del %s", state.Name.ValueString())
+	if err := r.client.checkHealth(state.RequireHealth.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Cluster health requirement not met", err)
+		return
+	}
+
+	cmd := NewCommand("ceph", "auth", "del").Arg(state.Name.ValueString())
 	_, err := r.client.ExecuteCommand(cmd)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete user", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to delete user", err)
+		return
+	}
+
+	if err := r.client.applyRegistryTags(ctx, "user", state.Name.ValueString(), types.MapNull(types.StringType), state.Tags); err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to remove user tags", err)
 		return
 	}
 
@@ -531,10 +2509,33 @@ type blockImageResource struct {
 }
 
 type blockImageResourceModel struct {
-	Name     types.String `tfsdk:"name"`
-	Pool     types.String `tfsdk:"pool"`
-	Size     types.String `tfsdk:"size"`
-	Features types.Set    `tfsdk:"features"`
+	ID        types.String `tfsdk:"id"`
+	Name      types.String `tfsdk:"name"`
+	Pool      types.String `tfsdk:"pool"`
+	Namespace types.String `tfsdk:"namespace"`
+	Size      sizeValue    `tfsdk:"size"`
+	Features  types.Set    `tfsdk:"features"`
+
+	PostCreateCommands types.List `tfsdk:"post_create_commands"`
+	PreDestroyCommands types.List `tfsdk:"pre_destroy_commands"`
+	PostCreateOutput   types.List `tfsdk:"post_create_output"`
+
+	Parent     types.String `tfsdk:"parent"`
+	CloneDepth types.Int64  `tfsdk:"clone_depth"`
+
+	RequireHealth   types.String `tfsdk:"require_health"`
+	ConfirmDataLoss types.Bool   `tfsdk:"confirm_data_loss"`
+
+	Tags types.Map `tfsdk:"tags"`
+}
+
+// spec returns the image spec rbd takes: pool/image, or
+// pool/namespace/image for an image in a namespace.
+func (m *blockImageResourceModel) spec() string {
+	if m.Namespace.IsNull() || m.Namespace.ValueString() == "" {
+		return blockImageID(m.Pool.ValueString(), m.Name.ValueString())
+	}
+	return blockImageID(radosNamespaceID(m.Pool.ValueString(), m.Namespace.ValueString()), m.Name.ValueString())
 }
 
 func NewBlockImageResource() resource.Resource {
@@ -548,7 +2549,9 @@ func (r *blockImageResource) Metadata(ctx context.Context, req resource.Metadata
 func (r *blockImageResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Description: "Manages a Ceph RBD block image",
-		Attributes: map[string]schema.Attribute{
+		Attributes: withHookAttributes(map[string]schema.Attribute{
+			"id":             resourceIDAttribute("Image spec in pool/image form, or pool/namespace/image for an image in a namespace"),
+			"require_health": requireHealthAttribute(),
 			"name": schema.StringAttribute{
 				Description: "Image name",
 				Required:    true,
@@ -557,16 +2560,43 @@ func (r *blockImageResource) Schema(ctx context.Context, req resource.SchemaRequ
 				Description: "Pool name",
 				Required:    true,
 			},
+			"namespace": schema.StringAttribute{
+				Description: "RBD namespace in the pool to create the image in, e.g. from ceph_rados_namespace; changing it replaces the image",
+				Optional:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
 			"size": schema.StringAttribute{
-				Description: "Image size (e.g., 10G, 1T)",
+				Description: "Image size, in bytes or with a unit (e.g., 10G, 1T)",
 				Required:    true,
+				CustomType:  sizeType{},
 			},
 			"features": schema.SetAttribute{
 				Description: "RBD features",
 				ElementType: types.StringType,
 				Optional:    true,
 			},
-		},
+			"tags": tagsAttribute(),
+			"confirm_data_loss": schema.BoolAttribute{
+				Description: "Confirm that destroying the image deletes its data; destroy fails unless this is true in state",
+				Optional:    true,
+			},
+			"parent": schema.StringAttribute{
+				Description: "Parent snapshot of a clone as pool/image@snapshot, with the namespace after the pool if it has one; null for an image that is not a clone",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"clone_depth": schema.Int64Attribute{
+				Description: "Number of images in the clone chain above this one; 0 for an image that is not a clone",
+				Computed:    true,
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+		}),
 	}
 }
 
@@ -577,6 +2607,48 @@ func (r *blockImageResource) Configure(ctx context.Context, req resource.Configu
 	r.client = req.ProviderData.(*CephClient)
 }
 
+func (r *blockImageResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	r.client.planResourceCommand(ctx, req, &resp.Diagnostics, "ceph_block_image", "rbd create", "rbd resize", "rbd rm")
+	if req.Plan.Raw.IsNull() {
+		var state blockImageResourceModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if !resp.Diagnostics.HasError() && !state.ConfirmDataLoss.ValueBool() {
+			resp.Diagnostics.AddError("Block image deletion not confirmed", blockImageDeletionNotConfirmed(state.spec()))
+		}
+		return
+	}
+	if r.client == nil {
+		return
+	}
+	var plan blockImageResourceModel
+	diags := req.Plan.Get(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || plan.Pool.IsUnknown() {
+		return
+	}
+	var state blockImageResourceModel
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if state.Pool.Equal(plan.Pool) {
+			return
+		}
+	}
+
+	r.client.warnMissingPools(ctx, &resp.Diagnostics, path.Root("pool"), plan.Pool.ValueString())
+}
+
+func (r *blockImageResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config blockImageResourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validateRequireHealth(config.RequireHealth, &resp.Diagnostics)
+	validateTags(config.Tags, &resp.Diagnostics)
+}
+
 func (r *blockImageResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var plan blockImageResourceModel
 	diags := req.Plan.Get(ctx, &plan)
@@ -585,10 +2657,14 @@ func (r *blockImageResource) Create(ctx context.Context, req resource.CreateRequ
 		return
 	}
 
-	cmd := fmt.Sprintf("rbd create --size %s %s/%s",
-		plan.Size.ValueString(),
-		plan.Pool.ValueString(),
-		plan.Name.ValueString())
+	if err := r.client.checkHealth(plan.RequireHealth.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Cluster health requirement not met", err)
+		return
+	}
+
+	cmd := NewCommand("rbd", "create").
+		Option("--size", plan.Size.CLIString()).
+		Arg(plan.spec())
 
 	if !plan.Features.IsNull() {
 		var features []string
@@ -599,13 +2675,13 @@ func (r *blockImageResource) Create(ctx context.Context, req resource.CreateRequ
 		}
 		
 		if len(features) > 0 {
-			cmd += " --image-feature " + strings.Join(features, ",")
+			cmd.Option("--image-feature", strings.Join(features, ","))
 		}
 	}
 
 	_, err := r.client.ExecuteCommand(cmd)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create block image", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to create block image", err)
 		return
 	}
 
@@ -614,6 +2690,31 @@ func (r *blockImageResource) Create(ctx context.Context, req resource.CreateRequ
 		"pool": plan.Pool.ValueString(),
 	})
 
+	plan.ID = types.StringValue(plan.spec())
+	plan.PostCreateOutput = types.ListNull(types.StringType)
+	plan.Parent = types.StringNull()
+	plan.CloneDepth = types.Int64Value(0)
+
+	// Record the image before tagging it and running hooks; see
+	// setPartialState.
+	resp.Diagnostics.Append(setPartialState(ctx, &resp.State, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !plan.Tags.IsNull() {
+		if err := r.applyTags(ctx, &plan); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to set block image tags", err)
+			return
+		}
+	}
+
+	plan.PostCreateOutput, err = r.client.runHooks(ctx, plan.PostCreateCommands, "post_create_commands")
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Block image post-create command failed", err)
+		return
+	}
+
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
 }
@@ -626,35 +2727,73 @@ func (r *blockImageResource) Read(ctx context.Context, req resource.ReadRequest,
 		return
 	}
 
-	cmd := fmt.Sprintf("rbd info %s/%s --format json",
-		state.Pool.ValueString(),
-		state.Name.ValueString())
-	
-	output, err := r.client.ExecuteCommand(cmd)
+	cmd := NewCommand("rbd", "info").
+		Arg(state.spec())
+
+	output, err := r.client.ReadJSON(cmd)
 	if err != nil {
 		if strings.Contains(err.Error(), "No such file or directory") {
 			resp.State.RemoveResource(ctx)
 			return
 		}
-		resp.Diagnostics.AddError("Failed to read block image", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to read block image", err)
 		return
 	}
 
 	var imageInfo map[string]interface{}
 	if err := json.Unmarshal([]byte(output), &imageInfo); err != nil {
-		resp.Diagnostics.AddError("Failed to parse image info", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to parse image info", err)
 		return
 	}
+	state.ID = types.StringValue(state.spec())
 
 	// Update size from actual image
 	if size, ok := imageInfo["size"].(float64); ok {
-		state.Size = types.StringValue(fmt.Sprintf("%.0fB", size))
+		state.Size = sizeBytes(int64(size))
+	}
+
+	parent, err := parseRBDParent(output)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse image info", err)
+		return
+	}
+	depth, err := r.client.RBDCloneDepth(parent)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to read clone chain", err)
+		return
+	}
+	state.Parent = types.StringNull()
+	if parent != nil {
+		state.Parent = types.StringValue(parent.String())
+	}
+	state.CloneDepth = types.Int64Value(depth)
+
+	if !state.Tags.IsNull() {
+		tags, err := r.client.ImageTags(state.spec())
+		if err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to read block image tags", err)
+			return
+		}
+		if state.Tags, err = tagsValue(ctx, tags); err != nil {
+			resp.Diagnostics.AddError("Invalid block image tags", err.Error())
+			return
+		}
 	}
 
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
 }
 
+// applyTags replaces the image's tags with the planned ones; none clears
+// them.
+func (r *blockImageResource) applyTags(ctx context.Context, plan *blockImageResourceModel) error {
+	tags, err := tagsFromMap(ctx, plan.Tags)
+	if err != nil {
+		return err
+	}
+	return r.client.SetImageTags(plan.spec(), tags)
+}
+
 func (r *blockImageResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	var plan blockImageResourceModel
 	var state blockImageResourceModel
@@ -667,16 +2806,27 @@ func (r *blockImageResource) Update(ctx context.Context, req resource.UpdateRequ
 		return
 	}
 
+	if err := r.client.checkHealth(plan.RequireHealth.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Cluster health requirement not met", err)
+		return
+	}
+
 	// Update size if changed
-	if !plan.Size.Equal(state.Size) {
-		cmd := fmt.Sprintf("rbd resize --size %s %s/%s",
-			plan.Size.ValueString(),
-			plan.Pool.ValueString(),
-			plan.Name.ValueString())
-		
+	if plan.Size.Bytes() != state.Size.Bytes() {
+		cmd := NewCommand("rbd", "resize").
+			Option("--size", plan.Size.CLIString()).
+			Arg(plan.spec())
+
 		_, err := r.client.ExecuteCommand(cmd)
 		if err != nil {
-			resp.Diagnostics.AddError("Failed to resize block image", err.Error())
+			addCommandError(&resp.Diagnostics, "Failed to resize block image", err)
+			return
+		}
+	}
+
+	if !plan.Tags.Equal(state.Tags) {
+		if err := r.applyTags(ctx, &plan); err != nil {
+			addCommandError(&resp.Diagnostics, "Failed to update block image tags", err)
 			return
 		}
 	}
@@ -686,6 +2836,8 @@ func (r *blockImageResource) Update(ctx context.Context, req resource.UpdateRequ
 		"pool": plan.Pool.ValueString(),
 	})
 
+	plan.ID = types.StringValue(plan.spec())
+
 	diags = resp.State.Set(ctx, plan)
 	resp.Diagnostics.Append(diags...)
 }
@@ -698,13 +2850,29 @@ func (r *blockImageResource) Delete(ctx context.Context, req resource.DeleteRequ
 		return
 	}
 
-	cmd := fmt.Sprintf("rbd rm %s/%s",
-		state.Pool.ValueString(),
-		state.Name.ValueString())
-	
+	// Checked again here as a replacement plans no destroy of its own.
+	if !state.ConfirmDataLoss.ValueBool() {
+		resp.Diagnostics.AddError("Block image deletion not confirmed", blockImageDeletionNotConfirmed(state.spec()))
+		return
+	}
+
+	if err := r.client.checkHealth(state.RequireHealth.ValueString()); err != nil {
+		addCommandError(&resp.Diagnostics, "Cluster health requirement not met", err)
+		return
+	}
+
+	if _, err := r.client.runHooks(ctx, state.PreDestroyCommands, "pre_destroy_commands"); err != nil {
+		addCommandError(&resp.Diagnostics, "Block image pre-destroy command failed", err)
+		return
+	}
+
+	cmd := NewCommand("rbd", "rm").
+		Arg(state.spec()).
+		Confirmed()
+
 	_, err := r.client.ExecuteCommand(cmd)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to delete block image", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to delete block image", err)
 		return
 	}
 
@@ -714,17 +2882,92 @@ func (r *blockImageResource) Delete(ctx context.Context, req resource.DeleteRequ
 	})
 }
 
+// blockImageDeletionNotConfirmed explains how to confirm destroying an
+// image.
+func blockImageDeletionNotConfirmed(spec string) string {
+	return fmt.Sprintf("Destroying image %s deletes all of its data. Set confirm_data_loss = true on the image "+
+		"and apply it before destroying the image.", spec)
+}
+
 // Cluster Status Data Source
 type clusterStatusDataSource struct {
 	client *CephClient
 }
 
 type clusterStatusDataSourceModel struct {
-	Health     types.String `tfsdk:"health"`
-	OSDCount   types.Int64  `tfsdk:"osd_count"`
-	MonCount   types.Int64  `tfsdk:"mon_count"`
-	MGRCount   types.Int64  `tfsdk:"mgr_count"`
-	PoolCount  types.Int64  `tfsdk:"pool_count"`
+	ID                      types.String  `tfsdk:"id"`
+	Health                  types.String  `tfsdk:"health"`
+	OSDCount                types.Int64   `tfsdk:"osd_count"`
+	MonCount                types.Int64   `tfsdk:"mon_count"`
+	MGRCount                types.Int64   `tfsdk:"mgr_count"`
+	PoolCount               types.Int64   `tfsdk:"pool_count"`
+	PGCount                 types.Int64   `tfsdk:"pg_count"`
+	PGsByState              types.Map     `tfsdk:"pgs_by_state"`
+	AllPGsActiveClean       types.Bool    `tfsdk:"all_pgs_active_clean"`
+	DegradedPercent         types.Float64 `tfsdk:"degraded_percent"`
+	MisplacedPercent        types.Float64 `tfsdk:"misplaced_percent"`
+	RecoveringBytesPerSec   types.Int64   `tfsdk:"recovering_bytes_per_sec"`
+	RecoveringObjectsPerSec types.Int64   `tfsdk:"recovering_objects_per_sec"`
+	ClientReadBytesPerSec   types.Int64   `tfsdk:"client_read_bytes_per_sec"`
+	ClientWriteBytesPerSec  types.Int64   `tfsdk:"client_write_bytes_per_sec"`
+	ClientReadOpsPerSec     types.Int64   `tfsdk:"client_read_ops_per_sec"`
+	ClientWriteOpsPerSec    types.Int64   `tfsdk:"client_write_ops_per_sec"`
+	RawJSON                 types.String  `tfsdk:"raw_json"`
+}
+
+// cephPGMap is the pgmap section of `ceph status`. Rate fields are only
+// present while there is IO or recovery, so missing means zero.
+type cephPGMap struct {
+	PGsByState []struct {
+		StateName string `json:"state_name"`
+		Count     int64  `json:"count"`
+	} `json:"pgs_by_state"`
+	NumPGs                  int64   `json:"num_pgs"`
+	DegradedRatio           float64 `json:"degraded_ratio"`
+	MisplacedRatio          float64 `json:"misplaced_ratio"`
+	RecoveringBytesPerSec   int64   `json:"recovering_bytes_per_sec"`
+	RecoveringObjectsPerSec int64   `json:"recovering_objects_per_sec"`
+	ReadBytesSec            int64   `json:"read_bytes_sec"`
+	WriteBytesSec           int64   `json:"write_bytes_sec"`
+	ReadOpPerSec            int64   `json:"read_op_per_sec"`
+	WriteOpPerSec           int64   `json:"write_op_per_sec"`
+}
+
+func parseCephPGMap(output string) (*cephPGMap, error) {
+	var status struct {
+		PGMap cephPGMap `json:"pgmap"`
+	}
+	if err := json.Unmarshal([]byte(output), &status); err != nil {
+		return nil, fmt.Errorf("failed to parse pgmap: %w", err)
+	}
+	return &status.PGMap, nil
+}
+
+// stateCounts returns PG counts keyed by combined state, e.g. active+clean.
+func (m *cephPGMap) stateCounts() map[string]int64 {
+	counts := make(map[string]int64, len(m.PGsByState))
+	for _, state := range m.PGsByState {
+		counts[state.StateName] += state.Count
+	}
+	return counts
+}
+
+// allActiveClean reports whether every PG is active+clean. Scrubbing does
+// not count against it, as it runs constantly on a healthy cluster.
+func (m *cephPGMap) allActiveClean() bool {
+	var clean int64
+	for _, state := range m.PGsByState {
+		parts := map[string]bool{}
+		for _, part := range strings.Split(state.StateName, "+") {
+			parts[part] = true
+		}
+		delete(parts, "scrubbing")
+		delete(parts, "deep")
+		if len(parts) == 2 && parts["active"] && parts["clean"] {
+			clean += state.Count
+		}
+	}
+	return clean == m.NumPGs
 }
 
 func NewClusterStatusDataSource() datasource.DataSource {
@@ -739,6 +2982,8 @@ func (d *clusterStatusDataSource) Schema(ctx context.Context, req datasource.Sch
 	resp.Schema = schema.Schema{
 		Description: "Ceph cluster status data source",
 		Attributes: map[string]schema.Attribute{
+			"id":       dataSourceIDAttribute("Cluster fsid"),
+			"raw_json": rawJSONAttribute("`ceph status`"),
 			"health": schema.StringAttribute{
 				Description: "Cluster health status",
 				Computed:    true,
@@ -759,6 +3004,51 @@ func (d *clusterStatusDataSource) Schema(ctx context.Context, req datasource.Sch
 				Description: "Number of pools",
 				Computed:    true,
 			},
+			"pg_count": schema.Int64Attribute{
+				Description: "Number of placement groups",
+				Computed:    true,
+			},
+			"pgs_by_state": schema.MapAttribute{
+				Description: "PG counts keyed by state, e.g. active+clean",
+				ElementType: types.Int64Type,
+				Computed:    true,
+			},
+			"all_pgs_active_clean": schema.BoolAttribute{
+				Description: "Whether every PG is active+clean, ignoring scrubbing",
+				Computed:    true,
+			},
+			"degraded_percent": schema.Float64Attribute{
+				Description: "Percentage of object copies that are degraded",
+				Computed:    true,
+			},
+			"misplaced_percent": schema.Float64Attribute{
+				Description: "Percentage of object copies that are misplaced",
+				Computed:    true,
+			},
+			"recovering_bytes_per_sec": schema.Int64Attribute{
+				Description: "Recovery throughput in bytes per second",
+				Computed:    true,
+			},
+			"recovering_objects_per_sec": schema.Int64Attribute{
+				Description: "Objects recovered per second",
+				Computed:    true,
+			},
+			"client_read_bytes_per_sec": schema.Int64Attribute{
+				Description: "Client read throughput in bytes per second",
+				Computed:    true,
+			},
+			"client_write_bytes_per_sec": schema.Int64Attribute{
+				Description: "Client write throughput in bytes per second",
+				Computed:    true,
+			},
+			"client_read_ops_per_sec": schema.Int64Attribute{
+				Description: "Client read operations per second",
+				Computed:    true,
+			},
+			"client_write_ops_per_sec": schema.Int64Attribute{
+				Description: "Client write operations per second",
+				Computed:    true,
+			},
 		},
 	}
 }
@@ -774,15 +3064,24 @@ func (d *clusterStatusDataSource) Read(ctx context.Context, req datasource.ReadR
 	var state clusterStatusDataSourceModel
 
 	// Get cluster status
-	output, err := d.client.ExecuteCommand("ceph status --format json")
+	output, err := d.client.ReadJSON(NewCommand("ceph", "status"))
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get cluster status", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to get cluster status", err)
 		return
 	}
 
 	var status map[string]interface{}
 	if err := json.Unmarshal([]byte(output), &status); err != nil {
-		resp.Diagnostics.AddError("Failed to parse cluster status", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to parse cluster status", err)
+		return
+	}
+
+	if fsid, ok := status["fsid"].(string); ok {
+		state.ID = types.StringValue(fsid)
+	}
+	state.RawJSON, err = rawJSONValue([]byte(output))
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse cluster status", err)
 		return
 	}
 
@@ -814,14 +3113,37 @@ func (d *clusterStatusDataSource) Read(ctx context.Context, req datasource.ReadR
 		}
 	}
 
+	// Parse PG summary and IO rates
+	pgmap, err := parseCephPGMap(output)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to parse cluster status", err)
+		return
+	}
+	state.PGCount = types.Int64Value(pgmap.NumPGs)
+	pgsByState, diags := types.MapValueFrom(ctx, types.Int64Type, pgmap.stateCounts())
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	state.PGsByState = pgsByState
+	state.AllPGsActiveClean = types.BoolValue(pgmap.allActiveClean())
+	state.DegradedPercent = types.Float64Value(pgmap.DegradedRatio * 100)
+	state.MisplacedPercent = types.Float64Value(pgmap.MisplacedRatio * 100)
+	state.RecoveringBytesPerSec = types.Int64Value(pgmap.RecoveringBytesPerSec)
+	state.RecoveringObjectsPerSec = types.Int64Value(pgmap.RecoveringObjectsPerSec)
+	state.ClientReadBytesPerSec = types.Int64Value(pgmap.ReadBytesSec)
+	state.ClientWriteBytesPerSec = types.Int64Value(pgmap.WriteBytesSec)
+	state.ClientReadOpsPerSec = types.Int64Value(pgmap.ReadOpPerSec)
+	state.ClientWriteOpsPerSec = types.Int64Value(pgmap.WriteOpPerSec)
+
 	// Get pool count
-	poolOutput, err := d.client.ExecuteCommand("ceph osd pool ls")
+	poolOutput, err := d.client.ExecuteCommand(NewCommand("ceph", "osd", "pool", "ls"))
 	if err == nil {
 		pools := strings.Split(strings.TrimSpace(poolOutput), "\n")
 		state.PoolCount = types.Int64Value(int64(len(pools)))
 	}
 
-	diags := resp.State.Set(ctx, &state)
+	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
 }
 
@@ -831,11 +3153,14 @@ type poolDataSource struct {
 }
 
 type poolDataSourceModel struct {
+	ID      types.String `tfsdk:"id"`
 	Name    types.String `tfsdk:"name"`
+	PoolID  types.Int64  `tfsdk:"pool_id"`
 	PgNum   types.Int64  `tfsdk:"pg_num"`
 	Size    types.Int64  `tfsdk:"size"`
 	MinSize types.Int64  `tfsdk:"min_size"`
 	Type    types.String `tfsdk:"type"`
+	RawJSON types.String `tfsdk:"raw_json"`
 }
 
 func NewPoolDataSource() datasource.DataSource {
@@ -850,9 +3175,16 @@ func (d *poolDataSource) Schema(ctx context.Context, req datasource.SchemaReques
 	resp.Schema = schema.Schema{
 		Description: "Ceph pool data source",
 		Attributes: map[string]schema.Attribute{
+			"id": dataSourceIDAttribute("Pool name"),
 			"name": schema.StringAttribute{
-				Description: "Pool name",
-				Required:    true,
+				Description: "Pool name; set either name or pool_id",
+				Optional:    true,
+				Computed:    true,
+			},
+			"pool_id": schema.Int64Attribute{
+				Description: "Numeric pool id; set either name or pool_id",
+				Optional:    true,
+				Computed:    true,
 			},
 			"pg_num": schema.Int64Attribute{
 				Description: "Placement group number",
@@ -870,6 +3202,7 @@ func (d *poolDataSource) Schema(ctx context.Context, req datasource.SchemaReques
 				Description: "Pool type",
 				Computed:    true,
 			},
+			"raw_json": rawJSONAttribute("`ceph osd pool ls detail` entry of the pool"),
 		},
 	}
 }
@@ -881,6 +3214,20 @@ func (d *poolDataSource) Configure(ctx context.Context, req datasource.Configure
 	d.client = req.ProviderData.(*CephClient)
 }
 
+func (d *poolDataSource) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	var config poolDataSourceModel
+	diags := req.Config.Get(ctx, &config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || config.Name.IsUnknown() || config.PoolID.IsUnknown() {
+		return
+	}
+
+	if config.Name.IsNull() == config.PoolID.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("name"), "Invalid pool selector",
+			"Set exactly one of name or pool_id")
+	}
+}
+
 func (d *poolDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
 	var config poolDataSourceModel
 	diags := req.Config.Get(ctx, &config)
@@ -889,53 +3236,46 @@ func (d *poolDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 		return
 	}
 
-	// Get pool information
-	cmd := fmt.Sprintf("ceph osd pool get %s all", config.Name.ValueString())
-	output, err := d.client.ExecuteCommand(cmd)
+	// Resolve the pool either way round, so both name and pool_id are set
+	var detail *poolDetail
+	var err error
+	if config.PoolID.IsNull() {
+		detail, err = d.client.GetPoolDetail(config.Name.ValueString())
+	} else {
+		detail, err = d.client.GetPoolDetailByID(config.PoolID.ValueInt64())
+	}
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get pool information", err.Error())
+		addCommandError(&resp.Diagnostics, "Failed to get pool information", err)
 		return
 	}
-
-	var state poolDataSourceModel
-	state.Name = config.Name
-
-	// Parse pool properties
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "size:") {
-			parts := strings.Split(line, ":")
-			if len(parts) == 2 {
-				size, _ := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
-				state.Size = types.Int64Value(size)
-			}
-		}
-		if strings.Contains(line, "min_size:") {
-			parts := strings.Split(line, ":")
-			if len(parts) == 2 {
-				minSize, _ := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
-				state.MinSize = types.Int64Value(minSize)
-			}
-		}
-		if strings.Contains(line, "pg_num:") {
-			parts := strings.Split(line, ":")
-			if len(parts) == 2 {
-				pgNum, _ := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
-				state.PgNum = types.Int64Value(pgNum)
-			}
+	if detail == nil {
+		selector := fmt.Sprintf("name %q", config.Name.ValueString())
+		if !config.PoolID.IsNull() {
+			selector = fmt.Sprintf("id %d", config.PoolID.ValueInt64())
 		}
+		resp.Diagnostics.AddError("Pool not found", fmt.Sprintf("No pool with %s exists", selector))
+		return
 	}
 
-	// Get pool type
-	cmd = fmt.Sprintf("ceph osd pool get %s type", config.Name.ValueString())
-	output, err = d.client.ExecuteCommand(cmd)
-	if err == nil {
-		parts := strings.Split(output, ":")
-		if len(parts) == 2 {
-			poolType := strings.TrimSpace(parts[1])
-			state.Type = types.StringValue(poolType)
-		}
+	settings, err := d.client.GetPoolSettings(detail.PoolName)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to get pool information", err)
+		return
+	}
+
+	var state poolDataSourceModel
+	state.ID = types.StringValue(detail.PoolName)
+	state.Name = types.StringValue(detail.PoolName)
+	state.PoolID = types.Int64Value(detail.PoolID)
+	state.RawJSON, err = rawJSONValue(detail.Raw)
+	if err != nil {
+		addCommandError(&resp.Diagnostics, "Failed to get pool information", err)
+		return
 	}
+	state.Size = types.Int64Value(settings.Size)
+	state.MinSize = types.Int64Value(settings.MinSize)
+	state.PgNum = types.Int64Value(settings.PgNum)
+	state.Type = types.StringValue(detail.TypeName())
 
 	diags = resp.State.Set(ctx, &state)
 	resp.Diagnostics.Append(diags...)
@@ -943,7 +3283,12 @@ func (d *poolDataSource) Read(ctx context.Context, req datasource.ReadRequest, r
 
 // Main function
 func main() {
-	provider.Serve(context.Background(), provider.ServeOpts{
-		ProviderFunc: New,
+	ctx, stop := shutdownContext()
+	defer stop()
+
+	provider.Serve(ctx, provider.ServeOpts{
+		ProviderFunc: func() provider.Provider {
+			return &cephProvider{shutdown: ctx}
+		},
 	})
 }
\ No newline at end of file